@@ -0,0 +1,359 @@
+package telnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+type AuthStatus int
+
+const (
+	AuthContinue AuthStatus = iota
+	AuthAccepted
+	AuthRejected
+)
+
+// ErrAuthenticationRejected is returned by WaitAuthenticated when the exchange
+// fails.
+var ErrAuthenticationRejected = errors.New("telnet: authentication rejected")
+
+// ErrAuthenticationRefused is returned by WaitAuthenticated when the peer won't
+// use the AUTHENTICATION option at all, or we don't allow it to.
+var ErrAuthenticationRefused = errors.New("telnet: authentication refused")
+
+// authReject is the REPLY code for REJECT, which the mechanisms of RFCs 2942
+// to 2944 share, and which we send when the exchange fails on our end.
+const authReject = 1
+
+// Authenticator is a single authentication mechanism. The same value is used
+// on both ends of the exchange: the client calls Start and Reply, while the
+// server calls Is. Authenticators hold per-connection state, so each
+// connection needs its own.
+type Authenticator interface {
+	// Type returns the authentication-type-pair this mechanism handles.
+	Type() (authType, modifiers byte)
+
+	// Start returns the data for the first IS sent by the client.
+	Start() ([]byte, error)
+
+	// Is handles the data from an IS sent by the client, along with the name
+	// the client sent with NAME (if any), and returns the data for the REPLY.
+	// A nil reply means no REPLY is sent.
+	Is(name string, data []byte) (reply []byte, status AuthStatus, err error)
+
+	// Reply handles the data from a REPLY sent by the server and returns the
+	// data for the next IS. A nil next means no IS is sent.
+	Reply(data []byte) (next []byte, status AuthStatus, err error)
+
+	// Principal returns the authenticated identity once the exchange has been
+	// accepted.
+	Principal() string
+}
+
+// SessionKeyer is implemented by authenticators that agree on a session key,
+// which is passed along in AuthenticatedEvent for use by the ENCRYPT option.
+type SessionKeyer interface {
+	SessionKey() []byte
+}
+
+type AuthenticationOption struct {
+	Option
+	authenticators []Authenticator
+	current        Authenticator
+
+	mu        sync.Mutex
+	name      string
+	principal string
+	err       error
+	done      chan struct{}
+}
+
+func NewAuthenticationOption(authenticators ...Authenticator) *AuthenticationOption {
+	return &AuthenticationOption{
+		Option:         NewOption(Authentication),
+		authenticators: authenticators,
+		done:           make(chan struct{}),
+	}
+}
+
+func (a *AuthenticationOption) Bind(conn Conn, sink EventSink) {
+	a.Option.Bind(conn, sink)
+	conn.AddListener("update-option", a)
+}
+
+// Name returns the remote user name. On the client this is the name that will
+// be sent with NAME, and on the server it is the name that the client sent.
+func (a *AuthenticationOption) Name() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.name
+}
+
+func (a *AuthenticationOption) Principal() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.principal
+}
+
+func (a *AuthenticationOption) SetName(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.name = name
+}
+
+// WaitAuthenticated waits for the exchange to finish, and returns the
+// principal if it was accepted. If it was rejected, the error wraps
+// ErrAuthenticationRejected. A server handler can call it before doing
+// anything else; if the client refuses AUTHENTICATION altogether, it returns
+// ErrAuthenticationRefused.
+func (a *AuthenticationOption) WaitAuthenticated(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	done := a.done
+	a.mu.Unlock()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.principal, a.err
+}
+
+func (a *AuthenticationOption) receive(c byte) error {
+	err := a.Option.receive(c)
+	a.checkRefused(c)
+	return err
+}
+
+func (a *AuthenticationOption) refuse(c byte) error {
+	err := a.Option.refuse(c)
+	a.checkRefused(c)
+	return err
+}
+
+// checkRefused finishes the exchange if the peer's WILL or WONT has left
+// AUTHENTICATION disabled for them. An exchange that has already finished
+// keeps its result.
+func (a *AuthenticationOption) checkRefused(c byte) {
+	if (c != WILL && c != WONT) || a.EnabledForThem() {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	select {
+	case <-a.done:
+	default:
+		a.err = ErrAuthenticationRefused
+		close(a.done)
+	}
+}
+
+func (a *AuthenticationOption) HandleEvent(data any) {
+	event, ok := data.(UpdateOptionEvent)
+	if !ok || event.Option.Byte() != Authentication {
+		return
+	}
+
+	// RFC 2941 calls the side that sent DO AUTHENTICATION the server, and it
+	// is the server that starts things off by sending its list of mechanisms.
+	if event.TheyChanged && event.EnabledForThem() {
+		pairs := make([]byte, 0, 2*len(a.authenticators))
+		for _, auth := range a.authenticators {
+			t, m := auth.Type()
+			pairs = append(pairs, t, m)
+		}
+		a.current = nil
+		a.reset()
+		a.sendAuthentication(authenticationSend, pairs)
+	}
+}
+
+func (a *AuthenticationOption) Subnegotiation(buf []byte) {
+	if len(buf) == 0 {
+		logSubnegotiation(a.Conn(), logRecv, a.Byte(), nil, nil)
+		return
+	}
+
+	cmd, buf := buf[0], buf[1:]
+	logSubnegotiation(a.Conn(), logRecv, a.Byte(), authenticationByte(cmd), buf)
+
+	switch cmd {
+	case authenticationSend:
+		if a.EnabledForUs() {
+			a.receiveSend(buf)
+		}
+	case authenticationIs:
+		if a.EnabledForThem() && len(buf) >= 2 {
+			a.receiveIs(buf[0], buf[1], buf[2:])
+		}
+	case authenticationReply:
+		if a.EnabledForUs() && len(buf) >= 2 {
+			a.receiveReply(buf[0], buf[1], buf[2:])
+		}
+	case authenticationName:
+		if a.EnabledForThem() {
+			a.SetName(string(buf))
+		}
+	}
+}
+
+func (a *AuthenticationOption) find(authType, modifiers byte) Authenticator {
+	for _, auth := range a.authenticators {
+		if t, m := auth.Type(); t == authType && m == modifiers {
+			return auth
+		}
+	}
+	return nil
+}
+
+func (a *AuthenticationOption) receiveSend(pairs []byte) {
+	a.current = nil
+	a.reset()
+	for ; len(pairs) >= 2; pairs = pairs[2:] {
+		auth := a.find(pairs[0], pairs[1])
+		if auth == nil {
+			continue
+		}
+		data, err := auth.Start()
+		if err != nil {
+			logFailure(a.Conn(), a.Byte(), "authentication failed", err, slog.String("type", authTypeByte(pairs[0]).String()))
+			continue
+		}
+		a.current = auth
+		if name := a.Name(); name != "" {
+			a.sendAuthentication(authenticationName, []byte(name))
+		}
+		a.sendAuthentication(authenticationIs, append([]byte{pairs[0], pairs[1]}, data...))
+		return
+	}
+
+	// None of the offered mechanisms are acceptable, so we tell the server so
+	// with a NULL authentication-type-pair.
+	a.sendAuthentication(authenticationIs, []byte{AuthNull, 0})
+}
+
+func (a *AuthenticationOption) receiveIs(authType, modifiers byte, data []byte) {
+	if authType == AuthNull {
+		a.rejected(authType, nil)
+		return
+	}
+
+	// The client waits for a REPLY, so if we can't go on, we say so.
+	auth := a.find(authType, modifiers)
+	if auth == nil {
+		a.sendAuthentication(authenticationReply, []byte{authType, modifiers, authReject})
+		a.rejected(authType, fmt.Errorf("unsupported authentication type %s", authTypeByte(authType)))
+		return
+	}
+	a.current = auth
+
+	reply, status, err := auth.Is(a.Name(), data)
+	if err != nil {
+		a.sendAuthentication(authenticationReply, []byte{authType, modifiers, authReject})
+		a.rejected(authType, err)
+		return
+	}
+	if reply != nil {
+		a.sendAuthentication(authenticationReply, append([]byte{authType, modifiers}, reply...))
+	}
+	a.handleStatus(auth, status)
+}
+
+func (a *AuthenticationOption) receiveReply(authType, modifiers byte, data []byte) {
+	auth := a.current
+	if auth == nil {
+		return
+	}
+	if t, m := auth.Type(); t != authType || m != modifiers {
+		return
+	}
+
+	next, status, err := auth.Reply(data)
+	if err != nil {
+		a.rejected(authType, err)
+		return
+	}
+	if next != nil {
+		a.sendAuthentication(authenticationIs, append([]byte{authType, modifiers}, next...))
+	}
+	a.handleStatus(auth, status)
+}
+
+func (a *AuthenticationOption) handleStatus(auth Authenticator, status AuthStatus) {
+	authType, _ := auth.Type()
+	switch status {
+	case AuthAccepted:
+		principal := auth.Principal()
+		a.finish(principal, nil)
+		event := AuthenticatedEvent{
+			Type:      authType,
+			Principal: principal,
+		}
+		if keyer, ok := auth.(SessionKeyer); ok {
+			event.SessionKey = keyer.SessionKey()
+		}
+		a.Sink().SendEvent("authenticated", event)
+	case AuthRejected:
+		a.rejected(authType, nil)
+	}
+}
+
+func (a *AuthenticationOption) rejected(authType byte, err error) {
+	if err != nil {
+		logFailure(a.Conn(), a.Byte(), "authentication failed", err, slog.String("type", authTypeByte(authType).String()))
+	}
+	a.current = nil
+	if err != nil {
+		a.finish("", fmt.Errorf("%w: %w", ErrAuthenticationRejected, err))
+	} else {
+		a.finish("", ErrAuthenticationRejected)
+	}
+	a.Sink().SendEvent("authentication-rejected", AuthenticationRejectedEvent{
+		Type: authType,
+		Err:  err,
+	})
+}
+
+// reset starts a new exchange.
+func (a *AuthenticationOption) reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.principal, a.err = "", nil
+	select {
+	case <-a.done:
+		a.done = make(chan struct{})
+	default:
+	}
+}
+
+// finish ends the exchange, waking WaitAuthenticated.
+func (a *AuthenticationOption) finish(principal string, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.principal, a.err = principal, err
+	select {
+	case <-a.done:
+	default:
+		close(a.done)
+	}
+}
+
+func (a *AuthenticationOption) sendAuthentication(cmd byte, data []byte) {
+	logSubnegotiation(a.Conn(), logSend, a.Byte(), authenticationByte(cmd), data)
+	a.Conn().Send(subnegotiation(a.Byte(), append([]byte{cmd}, data...)))
+}
+
+type AuthenticatedEvent struct {
+	Type       byte
+	Principal  string
+	SessionKey []byte
+}
+
+type AuthenticationRejectedEvent struct {
+	Type byte
+	Err  error
+}
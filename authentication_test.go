@@ -0,0 +1,225 @@
+package telnet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticationServer(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, Authentication})
+	in.Write([]byte{IAC, SB, Authentication, authenticationName, 'b', 'o', 'b', IAC, SE})
+	in.Write([]byte{IAC, SB, Authentication, authenticationIs, AuthSRP, AuthMutual, 'h', 'i', IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	auth := NewMockAuthenticator(t)
+	auth.EXPECT().Type().Return(byte(AuthSRP), byte(AuthMutual))
+	auth.EXPECT().Is("bob", []byte("hi")).Return([]byte{IAC}, AuthAccepted, nil)
+	auth.EXPECT().Principal().Return("bob@example.com")
+
+	option := NewAuthenticationOption(auth)
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	listener := NewMockEventListener(t)
+	conn.AddListener("authenticated", listener)
+	listener.EXPECT().HandleEvent(AuthenticatedEvent{AuthSRP, "bob@example.com", nil})
+
+	// The handler waits for authentication before it does anything else.
+	type result struct {
+		principal string
+		err       error
+	}
+	waited := make(chan result)
+	go func() {
+		principal, err := option.WaitAuthenticated(context.Background())
+		waited <- result{principal, err}
+	}()
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		IAC, DO, Authentication,
+		IAC, SB, Authentication, authenticationSend, AuthSRP, AuthMutual, IAC, SE,
+		IAC, SB, Authentication, authenticationReply, AuthSRP, AuthMutual, IAC, IAC, IAC, SE,
+	}, out.Bytes())
+	assert.Equal(t, "bob", option.Name())
+	assert.Equal(t, "bob@example.com", option.Principal())
+	assert.Equal(t, result{"bob@example.com", nil}, <-waited)
+}
+
+func TestAuthenticationServerRejected(t *testing.T) {
+	var tests = []struct {
+		is  []byte
+		err bool
+	}{
+		{[]byte{AuthNull, 0}, false},
+		{[]byte{AuthKerberosV5, 0}, true},
+		{[]byte{AuthSRP, 0, 'n', 'o'}, false},
+	}
+	for _, test := range tests {
+		in := bytes.NewBuffer([]byte{IAC, WILL, Authentication})
+		in.Write(subnegotiation(Authentication, append([]byte{authenticationIs}, test.is...)))
+		var out bytes.Buffer
+		conn := newTestConn(in, &out)
+
+		auth := NewMockAuthenticator(t)
+		auth.EXPECT().Type().Return(byte(AuthSRP), byte(0))
+		auth.EXPECT().Is("", []byte("no")).Return(nil, AuthRejected, nil).Maybe()
+
+		option := NewAuthenticationOption(auth)
+		option.Allow(true, false)
+		conn.BindOption(option)
+
+		var events []AuthenticationRejectedEvent
+		conn.AddListener("authentication-rejected", FuncListener{func(data any) {
+			events = append(events, data.(AuthenticationRejectedEvent))
+		}})
+
+		_, err := io.ReadAll(conn)
+		assert.NoError(t, err)
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, test.is[0], events[0].Type)
+			assert.Equal(t, test.err, events[0].Err != nil)
+		}
+		assert.Empty(t, option.Principal())
+		_, err = option.WaitAuthenticated(context.Background())
+		assert.ErrorIs(t, err, ErrAuthenticationRejected)
+
+		// The client is told, unless it was the one to give up.
+		reject := subnegotiation(Authentication, []byte{authenticationReply, test.is[0], test.is[1], authReject})
+		assert.Equal(t, test.err, bytes.Contains(out.Bytes(), reject))
+	}
+}
+
+func TestAuthenticationServerRejectsOnError(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, Authentication})
+	in.Write(subnegotiation(Authentication, []byte{authenticationIs, AuthSRP, 0, 'h', 'i'}))
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	auth := NewMockAuthenticator(t)
+	auth.EXPECT().Type().Return(byte(AuthSRP), byte(0))
+	auth.EXPECT().Is("", []byte("hi")).Return(nil, AuthContinue, errors.New("bad proof"))
+
+	option := NewAuthenticationOption(auth)
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasSuffix(out.Bytes(), []byte{IAC, SB, Authentication, authenticationReply, AuthSRP, 0, authReject, IAC, SE}))
+	_, err = option.WaitAuthenticated(context.Background())
+	assert.ErrorIs(t, err, ErrAuthenticationRejected)
+	assert.ErrorContains(t, err, "bad proof")
+}
+
+func TestAuthenticationRefused(t *testing.T) {
+	var tests = []struct {
+		allow bool
+		cmd   byte
+	}{
+		{true, WONT},
+		{false, WILL},
+	}
+	for _, test := range tests {
+		conn := newTestConn(bytes.NewBuffer([]byte{IAC, test.cmd, Authentication}), io.Discard)
+
+		option := NewAuthenticationOption(NewMockAuthenticator(t))
+		option.Allow(test.allow, false)
+		conn.BindOption(option)
+		if test.allow {
+			assert.NoError(t, conn.EnableOptionForThem(Authentication, true))
+		}
+
+		_, err := io.ReadAll(conn)
+		assert.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err = option.WaitAuthenticated(ctx)
+		cancel()
+		assert.ErrorIs(t, err, ErrAuthenticationRefused)
+	}
+}
+
+func TestWaitAuthenticatedCanceled(t *testing.T) {
+	option := NewAuthenticationOption()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := option.WaitAuthenticated(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestAuthenticationClient(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, Authentication})
+	in.Write([]byte{IAC, SB, Authentication, authenticationSend, AuthKerberosV5, 0, AuthSRP, AuthMutual, IAC, SE})
+	in.Write([]byte{IAC, SB, Authentication, authenticationReply, AuthSRP, AuthMutual, 'c', IAC, SE})
+	in.Write([]byte{IAC, SB, Authentication, authenticationReply, AuthSRP, AuthMutual, 'o', 'k', IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	auth := NewMockAuthenticator(t)
+	auth.EXPECT().Type().Return(byte(AuthSRP), byte(AuthMutual))
+	auth.EXPECT().Start().Return([]byte{'s', IAC}, nil)
+	auth.EXPECT().Reply([]byte("c")).Return([]byte("r"), AuthContinue, nil).Once()
+	auth.EXPECT().Reply([]byte("ok")).Return(nil, AuthAccepted, nil).Once()
+	auth.EXPECT().Principal().Return("host/example.com")
+
+	option := NewAuthenticationOption(auth)
+	option.Allow(false, true)
+	option.SetName("bob")
+	conn.BindOption(option)
+
+	listener := NewMockEventListener(t)
+	conn.AddListener("authenticated", listener)
+	listener.EXPECT().HandleEvent(AuthenticatedEvent{AuthSRP, "host/example.com", nil})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		IAC, WILL, Authentication,
+		IAC, SB, Authentication, authenticationName, 'b', 'o', 'b', IAC, SE,
+		IAC, SB, Authentication, authenticationIs, AuthSRP, AuthMutual, 's', IAC, IAC, IAC, SE,
+		IAC, SB, Authentication, authenticationIs, AuthSRP, AuthMutual, 'r', IAC, SE,
+	}, out.Bytes())
+	assert.Equal(t, "host/example.com", option.Principal())
+}
+
+func TestAuthenticationClientNoMechanism(t *testing.T) {
+	var tests = []struct {
+		start error
+	}{
+		{nil},
+		{errors.New("boom")},
+	}
+	for _, test := range tests {
+		in := bytes.NewBuffer([]byte{IAC, DO, Authentication})
+		in.Write([]byte{IAC, SB, Authentication, authenticationSend, AuthKerberosV5, 0, AuthSRP, 0, IAC, SE})
+		var out bytes.Buffer
+		conn := newTestConn(in, &out)
+
+		auth := NewMockAuthenticator(t)
+		if test.start == nil {
+			auth.EXPECT().Type().Return(byte(AuthRSA), byte(0))
+		} else {
+			auth.EXPECT().Type().Return(byte(AuthSRP), byte(0))
+			auth.EXPECT().Start().Return(nil, test.start)
+		}
+
+		option := NewAuthenticationOption(auth)
+		option.Allow(false, true)
+		conn.BindOption(option)
+
+		_, err := io.ReadAll(conn)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{
+			IAC, WILL, Authentication,
+			IAC, SB, Authentication, authenticationIs, AuthNull, 0, IAC, SE,
+		}, out.Bytes())
+	}
+}
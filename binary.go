@@ -51,10 +51,14 @@ func (t *TransmitBinaryOption) Subnegotiation(_ []byte) {}
 
 func (t *TransmitBinaryOption) HandleEvent(data any) {
 	event, ok := data.(UpdateOptionEvent)
-	if !ok {
+	if !ok || event.Option.Byte() != TransmitBinary {
 		return
 	}
 
+	if c, ok := t.Conn().(binaryConn); ok && (event.TheyChanged || event.WeChanged) {
+		c.setBinary(event.Option.EnabledForThem(), event.Option.EnabledForUs())
+	}
+
 	if event.TheyChanged {
 		if event.Option.EnabledForThem() {
 			t.Conn().SetReadEncoding(Binary)
@@ -43,3 +43,14 @@ func TestTransmitBinaryOption(t *testing.T) {
 	conn.EXPECT().SetWriteEncoding(Binary)
 	h.HandleEvent(UpdateOptionEvent{opt, true, true})
 }
+
+func TestTransmitBinaryOptionIgnoresOtherOptions(t *testing.T) {
+	h := NewTransmitBinaryOption()
+	conn := NewMockConn(t)
+	conn.EXPECT().AddListener("update-option", h)
+	h.Bind(conn, nil)
+
+	opt := NewMockOption(t)
+	opt.EXPECT().Byte().Return(byte(SuppressGoAhead))
+	h.HandleEvent(UpdateOptionEvent{opt, true, true})
+}
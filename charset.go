@@ -31,12 +31,12 @@ func (c *CharsetOption) Bind(conn Conn, sink EventSink) {
 
 func (c *CharsetOption) Subnegotiation(buf []byte) {
 	if len(buf) == 0 {
-		c.log("RECV: IAC SB %s IAC SE", optionByte(c.Byte()))
+		logSubnegotiation(c.Conn(), logRecv, c.Byte(), nil, nil)
 		return
 	}
 
 	cmd, buf := buf[0], buf[1:]
-	c.logCharsetCommand("RECV: IAC SB %s %s %s IAC SE", charsetByte(cmd), string(buf))
+	logSubnegotiation(c.Conn(), logRecv, c.Byte(), charsetByte(cmd), buf)
 
 	switch cmd {
 	case charsetAccepted:
@@ -72,7 +72,7 @@ func (c *CharsetOption) Subnegotiation(buf []byte) {
 		} else {
 			c.enc = encoding
 		}
-		c.logCharsetCommand("SEND: IAC SB %s %s %s IAC SE", charsetAccepted, string(charset))
+		logSubnegotiation(c.Conn(), logSend, c.Byte(), charsetByte(charsetAccepted), []byte(charset))
 		out := []byte{IAC, SB, Charset, charsetAccepted}
 		out = append(out, charset...)
 		out = append(out, IAC, SE)
@@ -106,21 +106,20 @@ func (c *CharsetOption) HandleEvent(data any) {
 	}
 }
 
-var encodings = map[string]encoding.Encoding{
-	"US-ASCII": ASCII,
-}
-
-func (c *CharsetOption) log(fmt string, args ...any) {
-	c.Conn().Logf(fmt, args...)
+// Status reports the character set we agreed on, if any.
+func (c *CharsetOption) Status() []byte {
+	if c.enc == nil {
+		return nil
+	}
+	name, err := ianaindex.IANA.Name(c.enc)
+	if err != nil {
+		return nil
+	}
+	return append([]byte{charsetAccepted}, name...)
 }
 
-func (c *CharsetOption) logCharsetCommand(fmt string, cmd charsetByte, v ...any) {
-	args := []any{
-		optionByte(c.Byte()),
-		cmd,
-	}
-	args = append(args, v...)
-	c.log(fmt, args...)
+var encodings = map[string]encoding.Encoding{
+	"US-ASCII": ASCII,
 }
 
 func (c *CharsetOption) selectEncoding(names [][]byte) (charset []byte, enc encoding.Encoding) {
@@ -159,6 +158,6 @@ func (c *CharsetOption) send(p []byte) {
 }
 
 func (c *CharsetOption) sendCharsetRejected() {
-	c.logCharsetCommand("SEND: IAC SB %s %s IAC SE", charsetByte(charsetRejected))
+	logSubnegotiation(c.Conn(), logSend, c.Byte(), charsetByte(charsetRejected), nil)
 	c.send([]byte{IAC, SB, Charset, charsetRejected, IAC, SE})
 }
@@ -20,31 +20,17 @@ func withCharsetAndConn(t *testing.T, f func(*CharsetOption, *MockConn, *MockEve
 	f(h, conn, sink)
 }
 
-func expectRecvCharsetSubnegotiation(conn *MockConn, cmd charsetByte, v ...any) {
-	args := []any{optionByte(Charset), cmd}
-	args = append(args, v...)
-	conn.EXPECT().Logf(
-		"RECV: IAC SB %s %s %s IAC SE",
-		args,
-	)
+func expectRecvCharsetSubnegotiation(conn *MockConn, cmd charsetByte, data string) {
+	expectSubnegotiationLog(conn, logRecv, Charset, cmd, []byte(data))
 }
 
 func expectCharsetRejected(conn *MockConn) {
-	conn.EXPECT().Logf(
-		"SEND: IAC SB %s %s IAC SE",
-		[]any{
-			optionByte(Charset),
-			charsetByte(charsetRejected),
-		},
-	)
+	expectSubnegotiationLog(conn, logSend, Charset, charsetByte(charsetRejected), nil)
 }
 
 func TestEmptySubnegotiationData(t *testing.T) {
 	withCharsetAndConn(t, func(h *CharsetOption, conn *MockConn, sink *MockEventSink) {
-		conn.EXPECT().Logf(
-			"RECV: IAC SB %s IAC SE",
-			[]any{optionByte(Charset)},
-		)
+		expectSubnegotiationLog(conn, logRecv, Charset, nil, nil)
 		h.Subnegotiation([]byte{})
 	})
 }
@@ -146,14 +132,7 @@ func TestAcceptEncodingRequest(t *testing.T) {
 				conn.EXPECT().SetEncoding(ASCII)
 			}
 			expectRecvCharsetSubnegotiation(conn, charsetRequest, test.subnegotiationData)
-			conn.EXPECT().Logf(
-				"SEND: IAC SB %s %s %s IAC SE",
-				[]any{
-					optionByte(Charset),
-					charsetByte(charsetAccepted),
-					test.encodingName,
-				},
-			)
+			expectSubnegotiationLog(conn, logSend, Charset, charsetByte(charsetAccepted), []byte(test.encodingName))
 			data := []byte{charsetRequest}
 			data = append(data, test.subnegotiationData...)
 
@@ -173,14 +152,7 @@ func TestEncodingRequestAccepted(t *testing.T) {
 		mockOption.EXPECT().EnabledForUs().Return(true).Maybe()
 		h.Option = mockOption
 
-		conn.EXPECT().Logf(
-			"RECV: IAC SB %s %s %s IAC SE",
-			[]any{
-				optionByte(Charset),
-				charsetByte(charsetAccepted),
-				"UTF-8",
-			},
-		)
+		expectRecvCharsetSubnegotiation(conn, charsetAccepted, "UTF-8")
 
 		mockBinary := NewMockOption(t)
 		mockBinary.EXPECT().Byte().Return(byte(TransmitBinary)).Maybe()
@@ -244,14 +216,7 @@ func TestUpdateTransmitBinary(t *testing.T) {
 
 func TestRejectsTTable(t *testing.T) {
 	withCharsetAndConn(t, func(h *CharsetOption, conn *MockConn, sink *MockEventSink) {
-		conn.EXPECT().Logf(
-			"RECV: IAC SB %s %s %s IAC SE",
-			[]any{
-				optionByte(Charset),
-				charsetByte(charsetTTableIs),
-				"\x01bogus",
-			},
-		)
+		expectRecvCharsetSubnegotiation(conn, charsetTTableIs, "\x01bogus")
 		expected := []byte{IAC, SB, Charset, charsetTTableRejected, IAC, SE}
 		conn.EXPECT().Send(expected).Return(len(expected), nil)
 
@@ -263,14 +228,7 @@ func TestRejectsTTable(t *testing.T) {
 
 func TestCharsetRejected(t *testing.T) {
 	withCharsetAndConn(t, func(h *CharsetOption, conn *MockConn, sink *MockEventSink) {
-		conn.EXPECT().Logf(
-			"RECV: IAC SB %s %s %s IAC SE",
-			[]any{
-				optionByte(Charset),
-				charsetByte(charsetRejected),
-				"",
-			},
-		)
+		expectRecvCharsetSubnegotiation(conn, charsetRejected, "")
 		sink.EXPECT().SendEvent("charset-rejected", nil)
 
 		data := []byte{charsetRejected}
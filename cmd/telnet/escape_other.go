@@ -0,0 +1,7 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !zos
+
+package main
+
+// watchEscape does nothing, so in line mode the escape character only takes
+// effect once Enter is pressed.
+func watchEscape(fd, escape int) (restore func()) { return func() {} }
@@ -0,0 +1,22 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+
+package main
+
+import "golang.org/x/sys/unix"
+
+// watchEscape makes the terminal end a line at the escape character as well as
+// at Enter, the way the BSD client does, so that in line mode the escape
+// character reaches us as soon as it's typed rather than with the rest of the
+// line. It returns a function that puts the terminal back.
+func watchEscape(fd, escape int) (restore func()) {
+	t, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil || escape < 0 {
+		return func() {}
+	}
+	old := *t
+	t.Cc[unix.VEOL] = byte(escape)
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, t); err != nil {
+		return func() {}
+	}
+	return func() { unix.IoctlSetTermios(fd, ioctlWriteTermios, &old) }
+}
@@ -0,0 +1,481 @@
+// Telnet is a telnet client, in the manner of the BSD one.
+//
+// Usage:
+//
+//	telnet [-debug] [-e char] host [port]
+//
+// It works in line mode, letting the terminal do the editing and echoing,
+// unless the host offers to echo, in which case it puts the terminal in raw
+// mode and sends each key as it's pressed. That is, the terminal is only raw
+// while the host echoes, the way the BSD client behaves, rather than for the
+// whole session: a host that leaves the echoing to us is expecting lines,
+// and the terminal's own editing is the best we can offer it. Typing the escape
+// character (^] by default) brings up a "telnet>" prompt for commands, even in
+// the middle of a line; type "help" there for a list. In line mode, an
+// interrupt (^C) is sent to the host as IAC IP rather than killing the client.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/stesla/telnet"
+	"golang.org/x/term"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func main() {
+	debug := flag.Bool("debug", false, "print the option negotiation")
+	escape := flag.String("e", "^]", "the escape `char`acter, or \"none\"")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: telnet [-debug] [-e char] host [port]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	esc, err := parseEscape(*escape)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "telnet:", err)
+		os.Exit(2)
+	}
+
+	host, port := flag.Arg(0), "23"
+	if flag.NArg() == 2 {
+		port = flag.Arg(1)
+	}
+	fmt.Printf("Trying %s...\n", host)
+	conn, err := telnet.Dial(net.JoinHostPort(host, port))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "telnet:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Connected to %s.\n", host)
+	if esc >= 0 {
+		fmt.Printf("Escape character is '%s'.\n", escapeName(byte(esc)))
+	}
+
+	c := newClient(conn, os.Stdin, int(os.Stdin.Fd()), os.Stdout, esc)
+	if *debug {
+		conn.SetLogger(c)
+	}
+	err = c.run()
+	c.cooked()
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+		fmt.Fprintln(os.Stderr, "telnet:", err)
+		os.Exit(1)
+	}
+	fmt.Println("Connection closed.")
+}
+
+// parseEscape parses the -e flag: a character, a control character written as
+// ^X, or "none".
+func parseEscape(s string) (int, error) {
+	switch {
+	case s == "none":
+		return -1, nil
+	case len(s) == 1:
+		return int(s[0]), nil
+	case len(s) == 2 && s[0] == '^':
+		if s[1] == '?' {
+			return 0x7f, nil
+		}
+		return int(s[1] & 0x1f), nil
+	}
+	return 0, fmt.Errorf("bad escape character %q", s)
+}
+
+func escapeName(c byte) string {
+	switch {
+	case c == 0x7f:
+		return "^?"
+	case c < ' ':
+		return "^" + string(rune(c+'@'))
+	}
+	return string(rune(c))
+}
+
+type client struct {
+	conn   telnet.Conn
+	in     *bufio.Reader
+	fd     int // the terminal's, if stdin is one
+	escape int
+
+	mu            sync.Mutex
+	stdout        io.Writer
+	raw           *term.State // the terminal's state before we made it raw
+	naws          *telnet.NAWSOption
+	width, height int
+}
+
+func newClient(conn telnet.Conn, stdin io.Reader, fd int, stdout io.Writer, escape int) *client {
+	c := &client{
+		conn:   conn,
+		in:     bufio.NewReader(stdin),
+		fd:     fd,
+		escape: escape,
+		stdout: stdout,
+	}
+
+	for _, code := range []byte{telnet.Echo, telnet.EndOfRecord} {
+		opt := telnet.NewOption(code)
+		opt.Allow(true, false)
+		conn.BindOption(opt)
+	}
+	sga := telnet.NewSuppressGoAheadOption()
+	sga.Allow(true, true)
+	conn.BindOption(sga)
+	binary := telnet.NewTransmitBinaryOption()
+	binary.Allow(true, true)
+	conn.BindOption(binary)
+
+	c.naws = telnet.NewNAWSOption()
+	c.naws.Allow(false, true)
+	conn.BindOption(c.naws)
+	c.updateWindowSize()
+
+	ttype := telnet.NewTerminalTypeOption(terminalTypes()...)
+	ttype.Allow(false, true)
+	conn.BindOption(ttype)
+
+	charset := telnet.NewCharsetOption(false, false)
+	charset.Allow(true, true)
+	conn.BindOption(charset)
+
+	conn.AddListener("update-option", c)
+	return c
+}
+
+// terminalTypes returns the terminal types to report, based on $TERM.
+func terminalTypes() []string {
+	if t := os.Getenv("TERM"); t != "" {
+		return []string{strings.ToUpper(t)}
+	}
+	return []string{"NETWORK-VIRTUAL-TERMINAL"}
+}
+
+func (c *client) HandleEvent(data any) {
+	event, ok := data.(telnet.UpdateOptionEvent)
+	if !ok {
+		return
+	}
+	switch event.Option.Byte() {
+	case telnet.Charset:
+		if event.WeChanged && event.EnabledForUs() {
+			c.conn.RequestEncoding(unicode.UTF8)
+		}
+	case telnet.Echo:
+		if event.TheyChanged {
+			c.updateMode()
+		}
+	}
+}
+
+// Logf prints the negotiation log, for -debug.
+func (c *client) Logf(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(os.Stderr, format+c.newline(), args...)
+}
+
+func (c *client) run() error {
+	c.conn.EnableOptionForUs(telnet.Charset, true)
+	c.conn.EnableOptionForThem(telnet.SuppressGoAhead, true)
+	c.watchWindowSize()
+	stop := c.watchInterrupt()
+	defer stop()
+	if term.IsTerminal(c.fd) {
+		restore := watchEscape(c.fd, c.escape)
+		defer func() {
+			c.cooked()
+			restore()
+		}()
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- c.copyOutput() }()
+	go func() {
+		if err := c.copyInput(); err != nil {
+			errc <- err
+		}
+	}()
+	err := <-errc
+	c.conn.Close()
+	return err
+}
+
+// watchInterrupt sends IAC IP for each SIGINT, which is what the terminal
+// makes of ^C in line mode. In character mode, ^C is sent as it's typed. It
+// returns a function that stops it.
+func (c *client) watchInterrupt() (stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt)
+	go func() {
+		for range ch {
+			c.interrupt()
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(ch)
+	}
+}
+
+func (c *client) interrupt() {
+	c.conn.Send([]byte{telnet.IAC, telnet.IP})
+}
+
+func (c *client) copyOutput() error {
+	buf := make([]byte, 4096)
+	for {
+		n, err := c.conn.Read(buf)
+		if n > 0 {
+			c.mu.Lock()
+			c.stdout.Write([]byte(strings.ReplaceAll(string(buf[:n]), "\n", c.newline())))
+			c.mu.Unlock()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *client) copyInput() error {
+	for {
+		// We look at what's buffered rather than reading it, so that
+		// whatever follows the escape character is left for prompt.
+		if _, err := c.in.Peek(1); err != nil {
+			return err
+		}
+		data, _ := c.in.Peek(c.in.Buffered())
+		i := len(data)
+		if c.escape >= 0 {
+			if j := bytes.IndexByte(data, byte(c.escape)); j >= 0 {
+				i = j
+			}
+		}
+		if err := c.send(data[:i]); err != nil {
+			return err
+		}
+		c.in.Discard(i)
+		if i == len(data) {
+			continue
+		}
+		c.in.Discard(1)
+		if quit := c.prompt(); quit {
+			return io.EOF
+		}
+	}
+}
+
+// send sends what was typed. In raw mode Enter gives us CR, which we send as
+// a line ending.
+func (c *client) send(p []byte) error {
+	if len(p) == 0 {
+		return nil
+	}
+	if c.isRaw() {
+		p = []byte(strings.ReplaceAll(string(p), "\r", "\n"))
+	}
+	_, err := c.conn.Write(p)
+	return err
+}
+
+// prompt reads and runs a command, in line mode, and returns whether it was
+// to quit.
+func (c *client) prompt() bool {
+	wasRaw := c.isRaw()
+	c.cooked()
+	defer func() {
+		if wasRaw {
+			c.makeRaw()
+		}
+	}()
+	fmt.Fprint(c.stdout, "\ntelnet> ")
+	line, err := c.in.ReadString('\n')
+	if err != nil {
+		return true
+	}
+	return c.command(line)
+}
+
+func (c *client) command(line string) (quit bool) {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return false
+	}
+	switch words[0] {
+	case "close", "quit":
+		return true
+	case "send":
+		if len(words) != 2 {
+			fmt.Fprintln(c.stdout, "usage: send ao|ayt|brk|ec|el|ga|ip|nop|escape")
+			break
+		}
+		c.sendCommand(words[1])
+	case "toggle":
+		if len(words) != 2 || words[1] != "binary" {
+			fmt.Fprintln(c.stdout, "usage: toggle binary")
+			break
+		}
+		c.toggleBinary()
+	case "status":
+		c.status()
+	case "help", "?":
+		fmt.Fprint(c.stdout, help)
+	default:
+		fmt.Fprintf(c.stdout, "?Invalid command %q\n", words[0])
+	}
+	return false
+}
+
+const help = `Commands:
+close		close the connection and exit
+quit		the same
+send X		send a telnet command: ao, ayt, brk, ec, el, ga, ip, nop, or
+		escape to send the escape character itself
+status		print the state of the connection and its options
+toggle binary	ask for TRANSMIT-BINARY to be turned on or off
+help		print this
+`
+
+var sendCommands = map[string]byte{
+	"ao":  telnet.AO,
+	"ayt": telnet.AYT,
+	"brk": telnet.BRK,
+	"ec":  telnet.EC,
+	"el":  telnet.EL,
+	"ga":  telnet.GA,
+	"ip":  telnet.IP,
+	"nop": telnet.NOP,
+}
+
+func (c *client) sendCommand(name string) {
+	if name == "escape" && c.escape >= 0 {
+		c.conn.Write([]byte{byte(c.escape)})
+		return
+	}
+	cmd, ok := sendCommands[name]
+	if !ok {
+		fmt.Fprintf(c.stdout, "?Unknown command to send %q\n", name)
+		return
+	}
+	c.conn.Send([]byte{telnet.IAC, cmd})
+}
+
+func (c *client) toggleBinary() {
+	opt := c.conn.Option(telnet.TransmitBinary)
+	enable := !(opt.EnabledForUs() && opt.EnabledForThem())
+	c.conn.EnableOptionForUs(telnet.TransmitBinary, enable)
+	c.conn.EnableOptionForThem(telnet.TransmitBinary, enable)
+	if enable {
+		fmt.Fprintln(c.stdout, "Asked to turn on binary mode in both directions.")
+	} else {
+		fmt.Fprintln(c.stdout, "Asked to turn off binary mode in both directions.")
+	}
+}
+
+var statusOptions = []byte{
+	telnet.TransmitBinary,
+	telnet.Echo,
+	telnet.SuppressGoAhead,
+	telnet.TerminalType,
+	telnet.EndOfRecord,
+	telnet.NAWS,
+	telnet.Charset,
+}
+
+func (c *client) status() {
+	fmt.Fprintf(c.stdout, "Connected to %s.\n", c.conn.RemoteAddr())
+	if c.isRaw() {
+		fmt.Fprintln(c.stdout, "Operating in character mode.")
+	} else {
+		fmt.Fprintln(c.stdout, "Operating in line mode.")
+	}
+	if c.escape >= 0 {
+		fmt.Fprintf(c.stdout, "Escape character is '%s'.\n", escapeName(byte(c.escape)))
+	}
+	c.mu.Lock()
+	width, height := c.width, c.height
+	c.mu.Unlock()
+	fmt.Fprintf(c.stdout, "Window size is %dx%d.\n", width, height)
+	for _, code := range statusOptions {
+		opt := c.conn.Option(code)
+		fmt.Fprintf(c.stdout, "%-18s us: %-3s them: %s\n", telnet.OptionName(code), onOff(opt.EnabledForUs()), onOff(opt.EnabledForThem()))
+	}
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// updateWindowSize tells the host the size of the terminal.
+func (c *client) updateWindowSize() {
+	width, height, err := term.GetSize(c.fd)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	c.width, c.height = width, height
+	c.mu.Unlock()
+	c.naws.SetWindowSize(uint16(width), uint16(height))
+}
+
+// updateMode switches to character mode when the host echoes, and back to
+// line mode when it stops.
+func (c *client) updateMode() {
+	if c.conn.Option(telnet.Echo).EnabledForThem() {
+		c.makeRaw()
+	} else {
+		c.cooked()
+	}
+}
+
+func (c *client) isRaw() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.raw != nil
+}
+
+func (c *client) makeRaw() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.raw != nil || !term.IsTerminal(c.fd) {
+		return
+	}
+	if state, err := term.MakeRaw(c.fd); err == nil {
+		c.raw = state
+	}
+}
+
+func (c *client) cooked() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.raw != nil {
+		term.Restore(c.fd, c.raw)
+		c.raw = nil
+	}
+}
+
+// newline is the line ending for our output, which needs a CR in raw mode. It
+// must be called with mu held.
+func (c *client) newline() string {
+	if c.raw != nil {
+		return "\r\n"
+	}
+	return "\n"
+}
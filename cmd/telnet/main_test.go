@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stesla/telnet"
+	tt "github.com/stesla/telnet/telnettest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEscape(t *testing.T) {
+	var tests = []struct {
+		s        string
+		expected int
+	}{
+		{"^]", 0x1d},
+		{"^c", 0x03},
+		{"^?", 0x7f},
+		{"~", '~'},
+		{"none", -1},
+	}
+	for _, test := range tests {
+		esc, err := parseEscape(test.s)
+		assert.NoError(t, err, test.s)
+		assert.Equal(t, test.expected, esc, test.s)
+	}
+	_, err := parseEscape("abc")
+	assert.Error(t, err)
+	assert.Equal(t, "^]", escapeName(0x1d))
+	assert.Equal(t, "~", escapeName('~'))
+}
+
+func newTestClient(t *testing.T, stdout io.Writer) (*tt.Peer, *client, *io.PipeWriter) {
+	peer, c := tt.NewPeer(t)
+	stdin, w := io.Pipe()
+	t.Cleanup(func() { w.Close() })
+	return peer, newClient(telnet.New(c), stdin, -1, stdout, 0x1d), w
+}
+
+func TestSession(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	peer, c, stdin := newTestClient(t, io.Discard)
+	done := make(chan error)
+	go func() { done <- c.run() }()
+
+	peer.Run(
+		tt.Expect(tt.Will(telnet.Charset), tt.Do(telnet.SuppressGoAhead)),
+		tt.Send(tt.Do(telnet.Charset)),
+		tt.Expect(tt.Sub(telnet.Charset, append([]byte{1, ';'}, "UTF-8"...)...)),
+		tt.Send(tt.Will(telnet.SuppressGoAhead), tt.Do(telnet.SuppressGoAhead)),
+		tt.Expect(tt.Will(telnet.SuppressGoAhead)),
+		tt.Send(tt.Do(telnet.TerminalType)),
+		tt.Expect(tt.Will(telnet.TerminalType)),
+		// Nothing follows the reply, so we know the client has finished
+		// handling everything above.
+		tt.Send(tt.Sub(telnet.TerminalType, 1)),
+		tt.Expect(tt.Sub(telnet.TerminalType, append([]byte{0}, "XTERM-256COLOR"...)...)),
+	)
+
+	stdin.Write([]byte("hello\n"))
+	peer.Expect(tt.Text("hello\n"))
+
+	c.interrupt()
+	peer.Expect(tt.Command(telnet.IP))
+
+	stdin.Write([]byte("\x1dsend ayt\n"))
+	peer.Expect(tt.Command(telnet.AYT))
+
+	stdin.Write([]byte("\x1dtoggle binary\n"))
+	peer.Expect(tt.Will(telnet.TransmitBinary), tt.Do(telnet.TransmitBinary))
+
+	stdin.Write([]byte("\x1dquit\n"))
+	assert.ErrorIs(t, <-done, io.EOF)
+	peer.ExpectClosed()
+}
+
+func TestStatus(t *testing.T) {
+	var out bytes.Buffer
+	_, c, _ := newTestClient(t, &out)
+	c.width, c.height = 80, 24
+	assert.False(t, c.command("status"))
+	assert.Contains(t, out.String(), "Operating in line mode.\n")
+	assert.Contains(t, out.String(), "Escape character is '^]'.\n")
+	assert.Contains(t, out.String(), "Window size is 80x24.\n")
+	assert.Contains(t, out.String(), "ECHO               us: off them: off\n")
+
+	out.Reset()
+	assert.False(t, c.command("bogus"))
+	assert.Equal(t, "?Invalid command \"bogus\"\n", out.String())
+	assert.True(t, c.command("close"))
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package main
+
+// watchWindowSize does nothing, since there's no SIGWINCH to tell us the
+// terminal has been resized.
+func (c *client) watchWindowSize() {}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchWindowSize keeps NAWS up to date as the terminal is resized.
+func (c *client) watchWindowSize() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+	go func() {
+		for range ch {
+			c.updateWindowSize()
+		}
+	}()
+}
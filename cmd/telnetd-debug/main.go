@@ -0,0 +1,415 @@
+// Telnetd-debug is a telnet server for finding out what a client supports. It
+// asks each client to enable every option the telnet package knows about, and
+// prints what the client agrees to and sends as it happens. Pressing Enter on
+// the client shows it a summary; typing "quit" disconnects.
+//
+// Usage:
+//
+//	telnetd-debug [-addr :2323] [-v]
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stesla/telnet"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func main() {
+	addr := flag.String("addr", ":2323", "the `address` to listen on")
+	verbose := flag.Bool("v", false, "print the negotiation log as well")
+	flag.Parse()
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("listening on %s", l.Addr())
+	out := &lockedWriter{w: os.Stdout}
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			log.Fatal(err)
+		}
+		go newSession(telnet.New(c), out, *verbose).serve()
+	}
+}
+
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// reportOption prints the subnegotiations of an option whose events don't tell
+// us much, before handing them on to it.
+type reportOption struct {
+	telnet.Option
+	s *session
+}
+
+func (o *reportOption) Subnegotiation(buf []byte) {
+	o.s.printf("%s subnegotiation %q", telnet.OptionName(o.Byte()), buf)
+	o.Option.Subnegotiation(buf)
+}
+
+var errReportOnly = errors.New("telnetd-debug only reports what the client sends")
+
+// reportAuthenticator offers an authentication type so that we find out
+// whether the client supports it, and then rejects whatever the client sends.
+type reportAuthenticator struct {
+	authType, modifiers byte
+}
+
+func (a reportAuthenticator) Type() (byte, byte)     { return a.authType, a.modifiers }
+func (a reportAuthenticator) Start() ([]byte, error) { return nil, errReportOnly }
+func (a reportAuthenticator) Principal() string      { return "" }
+
+func (a reportAuthenticator) Is(string, []byte) ([]byte, telnet.AuthStatus, error) {
+	return nil, telnet.AuthRejected, errReportOnly
+}
+
+func (a reportAuthenticator) Reply([]byte) ([]byte, telnet.AuthStatus, error) {
+	return nil, telnet.AuthRejected, errReportOnly
+}
+
+// reportAuthenticators are the types we offer, most common first.
+func reportAuthenticators() []telnet.Authenticator {
+	var auths []telnet.Authenticator
+	for _, authType := range []byte{telnet.AuthKerberosV5, telnet.AuthSRP, telnet.AuthSSL, telnet.AuthNTLM} {
+		for _, how := range []byte{telnet.AuthMutual, telnet.AuthOneWay} {
+			auths = append(auths, reportAuthenticator{authType, telnet.AuthClientToServer | how})
+		}
+	}
+	return auths
+}
+
+// reportPort is a serial port that isn't there. It prints what the client asks
+// of it and agrees to all of it.
+type reportPort struct {
+	s *session
+}
+
+func (p reportPort) SetBaudRate(rate uint32) (uint32, error) {
+	p.s.printf("com port baud rate %d", rate)
+	return cmp.Or(rate, 9600), nil
+}
+
+func (p reportPort) SetDataSize(size byte) (byte, error) {
+	p.s.printf("com port data size %d", size)
+	return cmp.Or(size, 8), nil
+}
+
+func (p reportPort) SetParity(parity byte) (byte, error) {
+	p.s.printf("com port parity %d", parity)
+	return cmp.Or(parity, telnet.ComPortParityNone), nil
+}
+
+func (p reportPort) SetStopSize(size byte) (byte, error) {
+	p.s.printf("com port stop size %d", size)
+	return cmp.Or(size, telnet.ComPortStopSizeOne), nil
+}
+
+func (p reportPort) SetControl(control byte) (byte, error) {
+	p.s.printf("com port control %d", control)
+	switch control {
+	case telnet.ComPortControlRequestFlow:
+		return telnet.ComPortControlNoFlow, nil
+	case telnet.ComPortControlRequestBreak:
+		return telnet.ComPortControlBreakOff, nil
+	case telnet.ComPortControlRequestDTR:
+		return telnet.ComPortControlDTROn, nil
+	case telnet.ComPortControlRequestRTS:
+		return telnet.ComPortControlRTSOn, nil
+	case telnet.ComPortControlRequestInboundFlow:
+		return telnet.ComPortControlInboundNoFlow, nil
+	}
+	return control, nil
+}
+
+func (p reportPort) Purge(what byte) error {
+	p.s.printf("com port purge %d", what)
+	return nil
+}
+
+func (p reportPort) Suspend(suspended bool) {
+	p.s.printf("com port suspend %t", suspended)
+}
+
+// Options we ask the client to enable (DO) and offer to enable ourselves
+// (WILL), in the order we do it.
+var (
+	doOptions = []byte{
+		telnet.TransmitBinary,
+		telnet.SuppressGoAhead,
+		telnet.Status,
+		telnet.TerminalType,
+		telnet.EndOfRecord,
+		telnet.NAWS,
+		telnet.TerminalSpeed,
+		telnet.ToggleFlowControl,
+		telnet.Linemode,
+		telnet.XDisplayLocation,
+		telnet.Authentication,
+		telnet.Encrypt,
+		telnet.NewEnviron,
+		telnet.Charset,
+		telnet.ComPort,
+		telnet.GMCP,
+	}
+	willOptions = []byte{
+		telnet.TransmitBinary,
+		telnet.Echo,
+		telnet.SuppressGoAhead,
+		telnet.Status,
+		telnet.EndOfRecord,
+		telnet.Encrypt,
+		telnet.Charset,
+		telnet.GMCP,
+	}
+)
+
+type session struct {
+	conn    telnet.Conn
+	out     io.Writer
+	verbose bool
+	start   time.Time
+
+	ttype   *telnet.TerminalTypeOption
+	naws    *telnet.NAWSOption
+	tspeed  *telnet.TerminalSpeedOption
+	xdisp   *telnet.XDisplayLocationOption
+	environ *telnet.NewEnvironOption
+	charset string
+}
+
+func newSession(conn telnet.Conn, out io.Writer, verbose bool) *session {
+	s := &session{conn: conn, out: out, verbose: verbose, start: time.Now()}
+	conn.SetLogger(s)
+
+	s.ttype = telnet.NewTerminalTypeOption()
+	s.ttype.CycleTypes(true)
+	s.naws = telnet.NewNAWSOption()
+	s.tspeed = telnet.NewTerminalSpeedOption(0, 0)
+	s.xdisp = telnet.NewXDisplayLocationOption("")
+	s.environ = telnet.NewNewEnvironOption(nil)
+	options := []telnet.Option{
+		telnet.NewTransmitBinaryOption(),
+		telnet.NewOption(telnet.Echo),
+		telnet.NewSuppressGoAheadOption(),
+		telnet.NewStatusOption(),
+		telnet.NewTimingMarkOption(),
+		s.ttype,
+		telnet.NewOption(telnet.EndOfRecord),
+		s.naws,
+		s.tspeed,
+		telnet.NewToggleFlowControlOption(),
+		s.xdisp,
+		s.environ,
+		telnet.NewCharsetOption(false, true),
+		telnet.NewLogoutOption(true),
+		telnet.NewLinemodeOption(telnet.LinemodeEdit),
+	}
+	// We have no ciphers, so ENCRYPT answers the client's SUPPORT with IS
+	// NULL and sends it an empty SUPPORT, which it answers with IS NULL. What
+	// it sent is printed along the way.
+	for _, opt := range []telnet.Option{
+		telnet.NewAuthenticationOption(reportAuthenticators()...),
+		telnet.NewEncryptOption(),
+		telnet.NewComPortOption(reportPort{s}),
+		telnet.NewOption(telnet.GMCP),
+	} {
+		options = append(options, &reportOption{Option: opt, s: s})
+	}
+	for _, opt := range options {
+		code := opt.Byte()
+		opt.Allow(slices.Contains(doOptions, code), slices.Contains(willOptions, code) || code == telnet.TimingMark || code == telnet.Logout)
+		conn.BindOption(opt)
+	}
+
+	conn.AddListener("update-option", s)
+	conn.AddListener("command", s)
+	conn.AddListener("terminal-type", s)
+	conn.AddListener("window-size", s)
+	conn.AddListener("terminal-speed", s)
+	conn.AddListener("x-display-location", s)
+	conn.AddListener("environ", s)
+	conn.AddListener("status", s)
+	conn.AddListener("logout", s)
+	conn.AddListener("linemode", s)
+	conn.AddListener("authentication-rejected", s)
+	conn.AddListener("protocol-violation", s)
+	conn.AddListener("charset-accepted", telnet.FuncListener{Func: func(data any) {
+		if enc, ok := data.(encoding.Encoding); ok {
+			s.charset, _ = ianaindex.IANA.Name(enc)
+			s.printf("charset accepted: %s", s.charset)
+		}
+	}})
+	conn.AddListener("charset-rejected", telnet.FuncListener{Func: func(any) {
+		s.charset = "rejected"
+		s.printf("charset rejected")
+	}})
+	return s
+}
+
+func (s *session) printf(format string, args ...any) {
+	fmt.Fprintf(s.out, "%s %7.3fs %s\n", s.conn.RemoteAddr(), time.Since(s.start).Seconds(), fmt.Sprintf(format, args...))
+}
+
+// Logf prints the negotiation log, with -v.
+func (s *session) Logf(format string, args ...any) {
+	if s.verbose {
+		s.printf(format, args...)
+	}
+}
+
+func (s *session) serve() {
+	defer s.conn.Close()
+	s.printf("connected")
+	for _, code := range doOptions {
+		s.conn.EnableOptionForThem(code, true)
+	}
+	for _, code := range willOptions {
+		s.conn.EnableOptionForUs(code, true)
+	}
+	s.conn.Write([]byte("telnetd-debug: press Enter for a report of what your client supports, or type quit.\n"))
+
+	lines := telnet.NewLineReader(s.conn)
+	lines.Echo = true
+	lines.MaxLength = 80
+	for {
+		line, err := lines.ReadLine()
+		if err != nil {
+			s.printf("disconnected: %v", err)
+			s.printf("summary:\n%s", s.summary())
+			return
+		}
+		switch strings.TrimSpace(line) {
+		case "quit":
+			s.printf("quit")
+			s.printf("summary:\n%s", s.summary())
+			return
+		default:
+			s.conn.Write([]byte(s.summary()))
+		}
+	}
+}
+
+func (s *session) HandleEvent(data any) {
+	switch t := data.(type) {
+	case telnet.UpdateOptionEvent:
+		name := telnet.OptionName(t.Option.Byte())
+		if t.TheyChanged {
+			s.printf("client %s %s", choose(t.EnabledForThem(), "WILL", "WONT"), name)
+		}
+		if t.WeChanged {
+			s.printf("client %s %s", choose(t.EnabledForUs(), "DO", "DONT"), name)
+			if t.Option.Byte() == telnet.Charset && t.EnabledForUs() {
+				s.conn.RequestEncoding(unicode.UTF8)
+			}
+		}
+	case telnet.CommandEvent:
+		s.printf("command %s", telnet.CommandName(t.Command))
+	case telnet.TerminalTypeEvent:
+		s.printf("terminal type %q", t.Type)
+	case telnet.WindowSizeEvent:
+		s.printf("window size %dx%d", t.Width, t.Height)
+	case telnet.TerminalSpeedEvent:
+		s.printf("terminal speed %d,%d", t.Transmit, t.Receive)
+	case telnet.XDisplayLocationEvent:
+		s.printf("X display location %q", t.Display)
+	case telnet.EnvironEvent:
+		for _, name := range slices.Sorted(maps.Keys(t.Vars)) {
+			s.printf("environ %s=%q", name, t.Vars[name])
+		}
+	case telnet.StatusReport:
+		s.printf("status: will %s, do %s", optionNames(t.Will), optionNames(t.Do))
+	case telnet.LogoutEvent:
+		s.printf("logout")
+	case telnet.LinemodeEvent:
+		s.printf("linemode mode %d", t.Mode)
+	case telnet.AuthenticationRejectedEvent:
+		s.printf("authentication type %d rejected: %v", t.Type, t.Err)
+	case telnet.ProtocolViolation:
+		s.printf("%v", t)
+	}
+}
+
+// summary describes what the client has agreed to and told us.
+func (s *session) summary() string {
+	var b strings.Builder
+	var them, us []byte
+	for _, code := range slices.Concat(doOptions, willOptions) {
+		opt := s.conn.Option(code)
+		if opt.EnabledForThem() && !slices.Contains(them, code) {
+			them = append(them, code)
+		}
+		if opt.EnabledForUs() && !slices.Contains(us, code) {
+			us = append(us, code)
+		}
+	}
+	fmt.Fprintf(&b, "Client will: %s\n", optionNames(them))
+	fmt.Fprintf(&b, "Client lets us: %s\n", optionNames(us))
+	if types := s.ttype.TerminalTypes(); len(types) > 0 {
+		fmt.Fprintf(&b, "Terminal types: %s\n", strings.Join(types, ", "))
+	}
+	if flags, ok := s.ttype.MTTS(); ok {
+		fmt.Fprintf(&b, "MTTS: %d\n", flags)
+	}
+	if width, height := s.naws.WindowSize(); width > 0 || height > 0 {
+		fmt.Fprintf(&b, "Window size: %dx%d\n", width, height)
+	}
+	if tx, rx := s.tspeed.TerminalSpeed(); tx > 0 || rx > 0 {
+		fmt.Fprintf(&b, "Terminal speed: %d,%d\n", tx, rx)
+	}
+	if display := s.xdisp.DisplayLocation(); display != "" {
+		fmt.Fprintf(&b, "X display location: %s\n", display)
+	}
+	if env := s.environ.Environ(); len(env) > 0 {
+		fmt.Fprintf(&b, "Environment:\n")
+		for _, name := range slices.Sorted(maps.Keys(env)) {
+			fmt.Fprintf(&b, "  %s=%q\n", name, env[name])
+		}
+	}
+	if s.charset != "" {
+		fmt.Fprintf(&b, "Charset: %s\n", s.charset)
+	}
+	return b.String()
+}
+
+func optionNames(codes []byte) string {
+	if len(codes) == 0 {
+		return "nothing"
+	}
+	names := make([]string, len(codes))
+	for i, code := range codes {
+		names[i] = telnet.OptionName(code)
+	}
+	return strings.Join(names, ", ")
+}
+
+func choose(b bool, yes, no string) string {
+	if b {
+		return yes
+	}
+	return no
+}
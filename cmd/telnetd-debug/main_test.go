@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stesla/telnet"
+	tt "github.com/stesla/telnet/telnettest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	peer, c := tt.NewPeer(t)
+	var buf bytes.Buffer
+	s := newSession(telnet.New(c), &lockedWriter{w: &buf}, false)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.serve()
+	}()
+
+	for _, code := range doOptions {
+		peer.Expect(tt.Do(code))
+	}
+	for _, code := range willOptions {
+		peer.Expect(tt.Will(code))
+	}
+	peer.Run(
+		tt.Expect(tt.Text("telnetd-debug: press Enter for a report of what your client supports, or type quit.\n"), tt.Command(telnet.GA)),
+		tt.Send(tt.Will(telnet.TerminalType)),
+		tt.Expect(tt.Sub(telnet.TerminalType, 1)),
+		tt.Send(tt.Sub(telnet.TerminalType, append([]byte{0}, "XTERM"...)...)),
+		tt.Expect(tt.Sub(telnet.TerminalType, 1)),
+		tt.Send(tt.Sub(telnet.TerminalType, append([]byte{0}, "XTERM"...)...)),
+		tt.Send(tt.Will(telnet.NAWS), tt.WindowSize(80, 24)),
+		tt.Send(tt.Text("quit\n")),
+	)
+	<-done
+	peer.ExpectClosed()
+
+	out := buf.String()
+	assert.Contains(t, out, "client WILL TERMINAL-TYPE\n")
+	assert.Contains(t, out, "terminal type \"XTERM\"\n")
+	assert.Contains(t, out, "window size 80x24\n")
+	assert.Contains(t, out, "quit\n")
+	assert.Contains(t, out, "Client will: TERMINAL-TYPE, NAWS\n")
+	assert.Contains(t, out, "Terminal types: XTERM\n")
+	assert.Contains(t, out, "Window size: 80x24\n")
+}
+
+func TestSessionReportOptions(t *testing.T) {
+	peer, c := tt.NewPeer(t)
+	var buf bytes.Buffer
+	s := newSession(telnet.New(c), &lockedWriter{w: &buf}, false)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.serve()
+	}()
+
+	for _, code := range doOptions {
+		peer.Expect(tt.Do(code))
+	}
+	for _, code := range willOptions {
+		peer.Expect(tt.Will(code))
+	}
+	peer.Run(
+		tt.Expect(tt.Text("telnetd-debug: press Enter for a report of what your client supports, or type quit.\n"), tt.Command(telnet.GA)),
+
+		// AUTHENTICATION SEND lists what we offer, and IS is rejected.
+		tt.Send(tt.Will(telnet.Authentication)),
+		tt.Expect(tt.Sub(telnet.Authentication, 1,
+			telnet.AuthKerberosV5, telnet.AuthMutual, telnet.AuthKerberosV5, telnet.AuthOneWay,
+			telnet.AuthSRP, telnet.AuthMutual, telnet.AuthSRP, telnet.AuthOneWay,
+			telnet.AuthSSL, telnet.AuthMutual, telnet.AuthSSL, telnet.AuthOneWay,
+			telnet.AuthNTLM, telnet.AuthMutual, telnet.AuthNTLM, telnet.AuthOneWay)),
+		tt.Send(tt.Sub(telnet.Authentication, 0, telnet.AuthSRP, telnet.AuthMutual, 'x')),
+		tt.Expect(tt.Sub(telnet.Authentication, 2, telnet.AuthSRP, telnet.AuthMutual, 1)),
+
+		// ENCRYPT SUPPORT is empty, since we have no ciphers.
+		tt.Send(tt.Will(telnet.Encrypt)),
+		tt.Expect(tt.Sub(telnet.Encrypt, 1)),
+
+		// COM-PORT-OPTION requests are answered.
+		tt.Send(tt.Will(telnet.ComPort), tt.Sub(telnet.ComPort, 1, 0, 0, 0x4b, 0)),
+		tt.Expect(tt.Sub(telnet.ComPort, 101, 0, 0, 0x4b, 0)),
+
+		tt.Send(tt.Text("quit\n")),
+	)
+	<-done
+	peer.ExpectClosed()
+
+	out := buf.String()
+	assert.Contains(t, out, "AUTHENTICATION subnegotiation \"\\x00\\x05\\x02x\"\n")
+	assert.Contains(t, out, "authentication type 5 rejected: ")
+	assert.Contains(t, out, "com port baud rate 19200\n")
+}
@@ -26,6 +26,12 @@ const (
 	IAC  // ff
 )
 
+// CommandName returns the name of a command, e.g. "AYT", or its number in hex
+// if it isn't one we know.
+func CommandName(cmd byte) string {
+	return commandByte(cmd).String()
+}
+
 func (c commandByte) String() string {
 	str, ok := map[commandByte]string{
 		AO:   "AO",
@@ -38,6 +44,7 @@ func (c commandByte) String() string {
 		SE:   "SE",
 		EC:   "EC",
 		EL:   "EL",
+		EOR:  "EOR",
 		GA:   "GA",
 		IAC:  "IAC",
 		IP:   "IP",
@@ -54,24 +61,54 @@ func (c commandByte) String() string {
 type optionByte byte
 
 const (
-	TransmitBinary  = 0  // RFC 856
-	Echo            = 1  // RFC 857
-	SuppressGoAhead = 3  // RFC 858
-	Charset         = 42 // RFC 2066
-	TerminalType    = 24 // RFC 930
-	NAWS            = 31 // RFC 1073
-	EndOfRecord     = 25 // RFC 885
+	TransmitBinary    = 0  // RFC 856
+	Echo              = 1  // RFC 857
+	SuppressGoAhead   = 3  // RFC 858
+	Status            = 5  // RFC 859
+	TimingMark        = 6  // RFC 860
+	Logout            = 18 // RFC 727
+	TerminalType      = 24 // RFC 930
+	EndOfRecord       = 25 // RFC 885
+	NAWS              = 31 // RFC 1073
+	TerminalSpeed     = 32 // RFC 1079
+	ToggleFlowControl = 33 // RFC 1372
+	Linemode          = 34 // RFC 1184
+	XDisplayLocation  = 35 // RFC 1096
+	Authentication    = 37 // RFC 2941
+	Encrypt           = 38 // RFC 2946
+	NewEnviron        = 39 // RFC 1572
+	Charset           = 42 // RFC 2066
+	ComPort           = 44 // RFC 2217
+	GMCP              = 201
 )
 
+// OptionName returns the name of an option as the RFCs write it, e.g.
+// "TERMINAL-TYPE", or its number in hex if it isn't one we know.
+func OptionName(opt byte) string {
+	return optionByte(opt).String()
+}
+
 func (c optionByte) String() string {
 	str, ok := map[optionByte]string{
-		Charset:         "CHARSET",
-		Echo:            "ECHO",
-		EndOfRecord:     "END-OF-RECORD",
-		NAWS:            "NAWS",
-		SuppressGoAhead: "SUPPRESS-GO-AHEAD",
-		TerminalType:    "TERMINAL-TYPE",
-		TransmitBinary:  "TRANSMIT-BINARY",
+		Authentication:    "AUTHENTICATION",
+		Charset:           "CHARSET",
+		ComPort:           "COM-PORT-OPTION",
+		Echo:              "ECHO",
+		Encrypt:           "ENCRYPT",
+		EndOfRecord:       "END-OF-RECORD",
+		GMCP:              "GMCP",
+		Linemode:          "LINEMODE",
+		Logout:            "LOGOUT",
+		NAWS:              "NAWS",
+		NewEnviron:        "NEW-ENVIRON",
+		Status:            "STATUS",
+		SuppressGoAhead:   "SUPPRESS-GO-AHEAD",
+		TerminalSpeed:     "TERMINAL-SPEED",
+		TerminalType:      "TERMINAL-TYPE",
+		TimingMark:        "TIMING-MARK",
+		ToggleFlowControl: "TOGGLE-FLOW-CONTROL",
+		TransmitBinary:    "TRANSMIT-BINARY",
+		XDisplayLocation:  "X-DISPLAY-LOCATION",
 	}[c]
 	if ok {
 		return str
@@ -107,12 +144,327 @@ func (c charsetByte) String() string {
 	return fmt.Sprintf("%X", uint8(c))
 }
 
+type authenticationByte byte
+
+const (
+	authenticationIs = 0 + iota
+	authenticationSend
+	authenticationReply
+	authenticationName
+)
+
+func (c authenticationByte) String() string {
+	str, ok := map[authenticationByte]string{
+		authenticationIs:    "IS",
+		authenticationSend:  "SEND",
+		authenticationReply: "REPLY",
+		authenticationName:  "NAME",
+	}[c]
+	if ok {
+		return str
+	}
+	return fmt.Sprintf("%X", uint8(c))
+}
+
+type authTypeByte byte
+
+// RFC 2941 authentication types and modifiers
+const (
+	AuthNull = 0 + iota
+	AuthKerberosV4
+	AuthKerberosV5
+	AuthSPX
+	AuthMINK
+	AuthSRP
+	AuthRSA
+	AuthSSL
+	_
+	_
+	AuthLOKI
+	AuthSSA
+	AuthKEASJ
+	AuthKEASJIntegrity
+	AuthDSS
+	AuthNTLM
+)
+
+const (
+	AuthWhoMask              = 1
+	AuthClientToServer       = 0
+	AuthServerToClient       = 1
+	AuthHowMask              = 2
+	AuthOneWay               = 0
+	AuthMutual               = 2
+	AuthEncryptMask          = 20
+	AuthEncryptOff           = 0
+	AuthEncryptUsingTelopt   = 4
+	AuthEncryptAfterExchange = 16
+	AuthCredentialsFwdMask   = 8
+	AuthCredentialsFwdOff    = 0
+	AuthCredentialsFwdOn     = 8
+)
+
+func (c authTypeByte) String() string {
+	str, ok := map[authTypeByte]string{
+		AuthNull:           "NULL",
+		AuthKerberosV4:     "KERBEROS_V4",
+		AuthKerberosV5:     "KERBEROS_V5",
+		AuthSPX:            "SPX",
+		AuthMINK:           "MINK",
+		AuthSRP:            "SRP",
+		AuthRSA:            "RSA",
+		AuthSSL:            "SSL",
+		AuthLOKI:           "LOKI",
+		AuthSSA:            "SSA",
+		AuthKEASJ:          "KEA_SJ",
+		AuthKEASJIntegrity: "KEA_SJ_INTEG",
+		AuthDSS:            "DSS",
+		AuthNTLM:           "NTLM",
+	}[c]
+	if ok {
+		return str
+	}
+	return fmt.Sprintf("%X", uint8(c))
+}
+
+type encryptByte byte
+
+const (
+	encryptIs = 0 + iota
+	encryptSupport
+	encryptReply
+	encryptStart
+	encryptEnd
+	encryptRequestStart
+	encryptRequestEnd
+	encryptEncKeyID
+	encryptDecKeyID
+)
+
+func (c encryptByte) String() string {
+	str, ok := map[encryptByte]string{
+		encryptIs:           "IS",
+		encryptSupport:      "SUPPORT",
+		encryptReply:        "REPLY",
+		encryptStart:        "START",
+		encryptEnd:          "END",
+		encryptRequestStart: "REQUEST-START",
+		encryptRequestEnd:   "REQUEST-END",
+		encryptEncKeyID:     "ENC_KEYID",
+		encryptDecKeyID:     "DEC_KEYID",
+	}[c]
+	if ok {
+		return str
+	}
+	return fmt.Sprintf("%X", uint8(c))
+}
+
+type encryptTypeByte byte
+
+// RFC 2946 encryption types
+const (
+	EncryptNull = 0 + iota
+	EncryptDESCFB64
+	EncryptDESOFB64
+	EncryptDES3CFB64
+	EncryptDES3OFB64
+	_
+	_
+	_
+	EncryptCAST5_40CFB64
+	EncryptCAST5_40OFB64
+	EncryptCAST128CFB64
+	EncryptCAST128OFB64
+)
+
+func (c encryptTypeByte) String() string {
+	str, ok := map[encryptTypeByte]string{
+		EncryptNull:          "NULL",
+		EncryptDESCFB64:      "DES_CFB64",
+		EncryptDESOFB64:      "DES_OFB64",
+		EncryptDES3CFB64:     "DES3_CFB64",
+		EncryptDES3OFB64:     "DES3_OFB64",
+		EncryptCAST5_40CFB64: "CAST5_40_CFB64",
+		EncryptCAST5_40OFB64: "CAST5_40_OFB64",
+		EncryptCAST128CFB64:  "CAST128_CFB64",
+		EncryptCAST128OFB64:  "CAST128_OFB64",
+	}[c]
+	if ok {
+		return str
+	}
+	return fmt.Sprintf("%X", uint8(c))
+}
+
+// isSendByte is for the options whose subnegotiations are just a SEND asking
+// for a value and an IS carrying it (e.g. TERMINAL-SPEED).
+type isSendByte byte
+
+const (
+	optionIs = 0 + iota
+	optionSend
+)
+
+func (c isSendByte) String() string {
+	str, ok := map[isSendByte]string{
+		optionIs:   "IS",
+		optionSend: "SEND",
+	}[c]
+	if ok {
+		return str
+	}
+	return fmt.Sprintf("%X", uint8(c))
+}
+
+type linemodeByte byte
+
+const (
+	linemodeMode = 1 + iota
+	linemodeForwardMask
+	linemodeSLC
+)
+
+func (c linemodeByte) String() string {
+	str, ok := map[linemodeByte]string{
+		linemodeMode:        "MODE",
+		linemodeForwardMask: "FORWARDMASK",
+		linemodeSLC:         "SLC",
+	}[c]
+	if ok {
+		return str
+	}
+	return fmt.Sprintf("%X", uint8(c))
+}
+
+type environByte byte
+
+const (
+	environIs = 0 + iota
+	environSend
+	environInfo
+)
+
+// The bytes that mark up the variables in a NEW-ENVIRON subnegotiation.
+const (
+	environVar = 0 + iota
+	environValue
+	environEsc
+	environUservar
+)
+
+func (c environByte) String() string {
+	str, ok := map[environByte]string{
+		environIs:   "IS",
+		environSend: "SEND",
+		environInfo: "INFO",
+	}[c]
+	if ok {
+		return str
+	}
+	return fmt.Sprintf("%X", uint8(c))
+}
+
+type flowControlByte byte
+
+const (
+	flowControlOff = 0 + iota
+	flowControlOn
+	flowControlRestartAny
+	flowControlRestartXON
+)
+
+func (c flowControlByte) String() string {
+	str, ok := map[flowControlByte]string{
+		flowControlOff:        "OFF",
+		flowControlOn:         "ON",
+		flowControlRestartAny: "RESTART-ANY",
+		flowControlRestartXON: "RESTART-XON",
+	}[c]
+	if ok {
+		return str
+	}
+	return fmt.Sprintf("%X", uint8(c))
+}
+
+type statusByte byte
+
+const (
+	statusIs = 0 + iota
+	statusSend
+)
+
+func (c statusByte) String() string {
+	str, ok := map[statusByte]string{
+		statusIs:   "IS",
+		statusSend: "SEND",
+	}[c]
+	if ok {
+		return str
+	}
+	return fmt.Sprintf("%X", uint8(c))
+}
+
+type comPortByte byte
+
+// RFC 2217 commands, as sent by the client. The server adds 100 to these in its
+// responses.
+const (
+	ComPortSignature = 0 + iota
+	ComPortSetBaudRate
+	ComPortSetDataSize
+	ComPortSetParity
+	ComPortSetStopSize
+	ComPortSetControl
+	ComPortNotifyLineState
+	ComPortNotifyModemState
+	ComPortFlowControlSuspend
+	ComPortFlowControlResume
+	ComPortSetLineStateMask
+	ComPortSetModemStateMask
+	ComPortPurgeData
+
+	comPortServerOffset = 100
+)
+
+func (c comPortByte) String() string {
+	cmd, prefix := c, ""
+	if c >= comPortServerOffset {
+		cmd, prefix = c-comPortServerOffset, "SERVER-"
+	}
+	str, ok := map[comPortByte]string{
+		ComPortSignature:          "SIGNATURE",
+		ComPortSetBaudRate:        "SET-BAUDRATE",
+		ComPortSetDataSize:        "SET-DATASIZE",
+		ComPortSetParity:          "SET-PARITY",
+		ComPortSetStopSize:        "SET-STOPSIZE",
+		ComPortSetControl:         "SET-CONTROL",
+		ComPortNotifyLineState:    "NOTIFY-LINESTATE",
+		ComPortNotifyModemState:   "NOTIFY-MODEMSTATE",
+		ComPortFlowControlSuspend: "FLOWCONTROL-SUSPEND",
+		ComPortFlowControlResume:  "FLOWCONTROL-RESUME",
+		ComPortSetLineStateMask:   "SET-LINESTATE-MASK",
+		ComPortSetModemStateMask:  "SET-MODEMSTATE-MASK",
+		ComPortPurgeData:          "PURGE-DATA",
+	}[cmd]
+	if ok {
+		return prefix + str
+	}
+	return fmt.Sprintf("%X", uint8(c))
+}
+
 type telnetGoAhead struct{}
 
 func (t telnetGoAhead) String() string {
 	return "IAC GA"
 }
 
+type telnetCommand struct {
+	cmd byte
+}
+
+func (t telnetCommand) String() string {
+	return fmt.Sprintf("IAC %s", commandByte(t.cmd))
+}
+
 type telnetOptionCommand struct {
 	cmd, opt byte
 }
@@ -125,3 +477,21 @@ type telnetSubnegotiation struct {
 	opt   byte
 	bytes []byte
 }
+
+func (t telnetSubnegotiation) String() string {
+	return fmt.Sprintf("IAC SB %s %q IAC SE", optionByte(t.opt), t.bytes)
+}
+
+// subnegotiation frames data as IAC SB opt ... IAC SE, doubling any IAC bytes
+// in the payload.
+func subnegotiation(opt byte, data []byte) []byte {
+	out := make([]byte, 0, len(data)+5)
+	out = append(out, IAC, SB, opt)
+	for _, c := range data {
+		if c == IAC {
+			out = append(out, IAC)
+		}
+		out = append(out, c)
+	}
+	return append(out, IAC, SE)
+}
@@ -0,0 +1,401 @@
+package telnet
+
+import (
+	"encoding/binary"
+	"log/slog"
+	"sync"
+)
+
+// RFC 2217 values for SET-PARITY, SET-STOPSIZE, SET-CONTROL and PURGE-DATA.
+// For every Set command, a value of 0 asks for the current setting.
+const (
+	ComPortParityNone  = 1
+	ComPortParityOdd   = 2
+	ComPortParityEven  = 3
+	ComPortParityMark  = 4
+	ComPortParitySpace = 5
+
+	ComPortStopSizeOne         = 1
+	ComPortStopSizeTwo         = 2
+	ComPortStopSizeOneAndAHalf = 3
+
+	ComPortControlRequestFlow         = 0
+	ComPortControlNoFlow              = 1
+	ComPortControlXonXoffFlow         = 2
+	ComPortControlHardwareFlow        = 3
+	ComPortControlRequestBreak        = 4
+	ComPortControlBreakOn             = 5
+	ComPortControlBreakOff            = 6
+	ComPortControlRequestDTR          = 7
+	ComPortControlDTROn               = 8
+	ComPortControlDTROff              = 9
+	ComPortControlRequestRTS          = 10
+	ComPortControlRTSOn               = 11
+	ComPortControlRTSOff              = 12
+	ComPortControlRequestInboundFlow  = 13
+	ComPortControlInboundNoFlow       = 14
+	ComPortControlInboundXonXoffFlow  = 15
+	ComPortControlInboundHardwareFlow = 16
+	ComPortControlDCDFlow             = 17
+	ComPortControlDTRFlow             = 18
+	ComPortControlDSRFlow             = 19
+
+	ComPortPurgeReceive  = 1
+	ComPortPurgeTransmit = 2
+	ComPortPurgeBoth     = 3
+)
+
+// RFC 2217 bits for NOTIFY-LINESTATE and NOTIFY-MODEMSTATE.
+const (
+	ComPortLineDataReady          = 1
+	ComPortLineOverrunError       = 2
+	ComPortLineParityError        = 4
+	ComPortLineFramingError       = 8
+	ComPortLineBreakDetect        = 16
+	ComPortLineHoldingEmpty       = 32
+	ComPortLineShiftRegisterEmpty = 64
+	ComPortLineTimeout            = 128
+
+	ComPortModemDeltaCTS         = 1
+	ComPortModemDeltaDSR         = 2
+	ComPortModemTrailingEdgeRing = 4
+	ComPortModemDeltaCD          = 8
+	ComPortModemCTS              = 16
+	ComPortModemDSR              = 32
+	ComPortModemRing             = 64
+	ComPortModemCD               = 128
+)
+
+const (
+	comPortBaudRateLength        = 4
+	comPortDefaultLineStateMask  = 0
+	comPortDefaultModemStateMask = 255
+)
+
+// ComPortBackend is the serial port behind the server side of the
+// COM-PORT-OPTION. Each Set method is passed 0 when the client is only asking
+// for the current value, and returns the value now in effect.
+type ComPortBackend interface {
+	SetBaudRate(rate uint32) (uint32, error)
+	SetDataSize(size byte) (byte, error)
+	SetParity(parity byte) (byte, error)
+	SetStopSize(size byte) (byte, error)
+	SetControl(control byte) (byte, error)
+	Purge(what byte) error
+
+	// Suspend is called when the client asks us to stop (or resume) sending
+	// it the data we receive from the port.
+	Suspend(suspended bool)
+}
+
+// ComPortOption implements RFC 2217. The side that sent WILL COM-PORT-OPTION is
+// the client and the side that sent DO is the server, so the same option can
+// play either role. The server side needs a ComPortBackend.
+type ComPortOption struct {
+	Option
+	backend ComPortBackend
+
+	// mu guards the rest, which the reader goroutine changes as the peer
+	// reports its settings.
+	mu        sync.Mutex
+	signature string
+
+	// server
+	lineStateMask, modemStateMask byte
+	lineState, modemState         byte
+
+	// client
+	peerSignature string
+	baudRate      uint32
+	dataSize      byte
+	parity        byte
+	stopSize      byte
+	control       byte
+}
+
+func NewComPortOption(backend ComPortBackend) *ComPortOption {
+	return &ComPortOption{
+		Option:         NewOption(ComPort),
+		backend:        backend,
+		lineStateMask:  comPortDefaultLineStateMask,
+		modemStateMask: comPortDefaultModemStateMask,
+	}
+}
+
+func (c *ComPortOption) BaudRate() uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.baudRate
+}
+
+func (c *ComPortOption) Control() byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.control
+}
+
+func (c *ComPortOption) DataSize() byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dataSize
+}
+
+func (c *ComPortOption) Parity() byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.parity
+}
+
+func (c *ComPortOption) PeerSignature() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.peerSignature
+}
+
+func (c *ComPortOption) SetSignature(s string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.signature = s
+}
+
+func (c *ComPortOption) StopSize() byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stopSize
+}
+
+func (c *ComPortOption) LineStateMask() byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lineStateMask
+}
+
+func (c *ComPortOption) ModemStateMask() byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.modemStateMask
+}
+
+func (c *ComPortOption) Subnegotiation(buf []byte) {
+	if len(buf) == 0 {
+		logSubnegotiation(c.Conn(), logRecv, c.Byte(), nil, nil)
+		return
+	}
+
+	cmd, buf := buf[0], buf[1:]
+	logSubnegotiation(c.Conn(), logRecv, c.Byte(), comPortByte(cmd), buf)
+
+	if cmd >= comPortServerOffset {
+		if c.EnabledForUs() {
+			c.receiveServerCommand(cmd-comPortServerOffset, buf)
+		}
+	} else if c.EnabledForThem() && c.backend != nil {
+		c.receiveClientCommand(cmd, buf)
+	}
+}
+
+// The client side.
+
+func (c *ComPortOption) FlowControlResume() error {
+	return c.sendComPort(ComPortFlowControlResume, nil)
+}
+
+func (c *ComPortOption) FlowControlSuspend() error {
+	return c.sendComPort(ComPortFlowControlSuspend, nil)
+}
+
+func (c *ComPortOption) PurgeData(what byte) error {
+	return c.sendComPort(ComPortPurgeData, []byte{what})
+}
+
+func (c *ComPortOption) RequestSignature() error {
+	return c.sendComPort(ComPortSignature, nil)
+}
+
+func (c *ComPortOption) SetBaudRate(rate uint32) error {
+	return c.sendComPort(ComPortSetBaudRate, binary.BigEndian.AppendUint32(nil, rate))
+}
+
+func (c *ComPortOption) SetControl(control byte) error {
+	return c.sendComPort(ComPortSetControl, []byte{control})
+}
+
+func (c *ComPortOption) SetDataSize(size byte) error {
+	return c.sendComPort(ComPortSetDataSize, []byte{size})
+}
+
+func (c *ComPortOption) SetLineStateMask(mask byte) error {
+	return c.sendComPort(ComPortSetLineStateMask, []byte{mask})
+}
+
+func (c *ComPortOption) SetModemStateMask(mask byte) error {
+	return c.sendComPort(ComPortSetModemStateMask, []byte{mask})
+}
+
+func (c *ComPortOption) SetParity(parity byte) error {
+	return c.sendComPort(ComPortSetParity, []byte{parity})
+}
+
+func (c *ComPortOption) SetStopSize(size byte) error {
+	return c.sendComPort(ComPortSetStopSize, []byte{size})
+}
+
+func (c *ComPortOption) receiveServerCommand(cmd byte, buf []byte) {
+	event := ComPortEvent{Command: cmd}
+	c.mu.Lock()
+	switch cmd {
+	case ComPortSignature:
+		c.peerSignature = string(buf)
+		event.Signature = c.peerSignature
+	case ComPortSetBaudRate:
+		if len(buf) != comPortBaudRateLength {
+			c.mu.Unlock()
+			return
+		}
+		c.baudRate = binary.BigEndian.Uint32(buf)
+		event.Value = c.baudRate
+	case ComPortSetDataSize, ComPortSetParity, ComPortSetStopSize, ComPortSetControl,
+		ComPortNotifyLineState, ComPortNotifyModemState,
+		ComPortSetLineStateMask, ComPortSetModemStateMask, ComPortPurgeData:
+		if len(buf) != 1 {
+			c.mu.Unlock()
+			return
+		}
+		switch cmd {
+		case ComPortSetDataSize:
+			c.dataSize = buf[0]
+		case ComPortSetParity:
+			c.parity = buf[0]
+		case ComPortSetStopSize:
+			c.stopSize = buf[0]
+		case ComPortSetControl:
+			c.control = buf[0]
+		case ComPortSetLineStateMask:
+			c.lineStateMask = buf[0]
+		case ComPortSetModemStateMask:
+			c.modemStateMask = buf[0]
+		}
+		event.Value = uint32(buf[0])
+	case ComPortFlowControlSuspend, ComPortFlowControlResume:
+		// There's no value, the event itself is the message.
+	default:
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+	c.Sink().SendEvent("com-port", event)
+}
+
+// The server side.
+
+// NotifyLineState tells the client about a change in the line state, subject
+// to the mask the client has set.
+func (c *ComPortOption) NotifyLineState(state byte) error {
+	return c.notify(ComPortNotifyLineState, &c.lineState, &c.lineStateMask, state)
+}
+
+// NotifyModemState tells the client about a change in the modem state, subject
+// to the mask the client has set.
+func (c *ComPortOption) NotifyModemState(state byte) error {
+	return c.notify(ComPortNotifyModemState, &c.modemState, &c.modemStateMask, state)
+}
+
+func (c *ComPortOption) notify(cmd byte, last, mask *byte, state byte) error {
+	if !c.EnabledForThem() {
+		return nil
+	}
+	// We hold mu while we send, so that the client hears about the states in
+	// the order we recorded them.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if state &= *mask; state == *last {
+		return nil
+	}
+	*last = state
+	return c.sendComPort(cmd+comPortServerOffset, []byte{state})
+}
+
+func (c *ComPortOption) receiveClientCommand(cmd byte, buf []byte) {
+	var reply []byte
+	var err error
+
+	switch cmd {
+	case ComPortSignature:
+		c.mu.Lock()
+		if len(buf) > 0 {
+			c.peerSignature = string(buf)
+			c.mu.Unlock()
+			return
+		}
+		reply = []byte(c.signature)
+		c.mu.Unlock()
+	case ComPortSetBaudRate:
+		if len(buf) != comPortBaudRateLength {
+			return
+		}
+		var rate uint32
+		rate, err = c.backend.SetBaudRate(binary.BigEndian.Uint32(buf))
+		reply = binary.BigEndian.AppendUint32(nil, rate)
+	case ComPortSetDataSize, ComPortSetParity, ComPortSetStopSize, ComPortSetControl:
+		if len(buf) != 1 {
+			return
+		}
+		var fn func(byte) (byte, error)
+		switch cmd {
+		case ComPortSetDataSize:
+			fn = c.backend.SetDataSize
+		case ComPortSetParity:
+			fn = c.backend.SetParity
+		case ComPortSetStopSize:
+			fn = c.backend.SetStopSize
+		case ComPortSetControl:
+			fn = c.backend.SetControl
+		}
+		var value byte
+		value, err = fn(buf[0])
+		reply = []byte{value}
+	case ComPortFlowControlSuspend, ComPortFlowControlResume:
+		c.backend.Suspend(cmd == ComPortFlowControlSuspend)
+		return
+	case ComPortSetLineStateMask, ComPortSetModemStateMask:
+		if len(buf) != 1 {
+			return
+		}
+		c.mu.Lock()
+		if cmd == ComPortSetLineStateMask {
+			c.lineStateMask = buf[0]
+		} else {
+			c.modemStateMask = buf[0]
+		}
+		c.mu.Unlock()
+		reply = buf
+	case ComPortPurgeData:
+		if len(buf) != 1 {
+			return
+		}
+		err = c.backend.Purge(buf[0])
+		reply = buf
+	default:
+		return
+	}
+
+	if err != nil {
+		logFailure(c.Conn(), c.Byte(), "com-port command failed", err, slog.String("command", comPortByte(cmd).String()))
+	}
+	c.sendComPort(cmd+comPortServerOffset, reply)
+}
+
+func (c *ComPortOption) sendComPort(cmd byte, data []byte) error {
+	logSubnegotiation(c.Conn(), logSend, c.Byte(), comPortByte(cmd), data)
+	_, err := c.Conn().Send(subnegotiation(c.Byte(), append([]byte{cmd}, data...)))
+	return err
+}
+
+// ComPortEvent is sent with "com-port" whenever the server tells the client
+// about a setting or a state change. Command is the client's command number
+// (e.g. ComPortSetBaudRate).
+type ComPortEvent struct {
+	Command   byte
+	Value     uint32
+	Signature string
+}
@@ -0,0 +1,149 @@
+package telnet
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSerialPort struct {
+	baudRate                   uint32
+	dataSize, parity, stopSize byte
+	control                    byte
+	purged                     []byte
+	suspended                  bool
+}
+
+func (f *fakeSerialPort) SetBaudRate(rate uint32) (uint32, error) {
+	if rate > 115200 {
+		return f.baudRate, errors.New("unsupported baud rate")
+	}
+	if rate != 0 {
+		f.baudRate = rate
+	}
+	return f.baudRate, nil
+}
+
+func (f *fakeSerialPort) SetDataSize(size byte) (byte, error)   { return f.set(&f.dataSize, size) }
+func (f *fakeSerialPort) SetParity(parity byte) (byte, error)   { return f.set(&f.parity, parity) }
+func (f *fakeSerialPort) SetStopSize(size byte) (byte, error)   { return f.set(&f.stopSize, size) }
+func (f *fakeSerialPort) SetControl(control byte) (byte, error) { return f.set(&f.control, control) }
+func (f *fakeSerialPort) Suspend(suspended bool)                { f.suspended = suspended }
+
+func (f *fakeSerialPort) Purge(what byte) error {
+	f.purged = append(f.purged, what)
+	return nil
+}
+
+func (f *fakeSerialPort) set(field *byte, value byte) (byte, error) {
+	if value != 0 {
+		*field = value
+	}
+	return *field, nil
+}
+
+func TestComPortServer(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, ComPort})
+	in.Write([]byte{IAC, SB, ComPort, ComPortSignature, IAC, SE})
+	in.Write([]byte{IAC, SB, ComPort, ComPortSetBaudRate, 0, 0, 0x25, 0x80, IAC, SE})
+	in.Write([]byte{IAC, SB, ComPort, ComPortSetBaudRate, 0, 0x0e, 0x10, 0, IAC, SE})
+	in.Write([]byte{IAC, SB, ComPort, ComPortSetParity, 0, IAC, SE})
+	in.Write([]byte{IAC, SB, ComPort, ComPortSetDataSize, 7, IAC, SE})
+	in.Write([]byte{IAC, SB, ComPort, ComPortSetModemStateMask, IAC, IAC, IAC, SE})
+	in.Write([]byte{IAC, SB, ComPort, ComPortFlowControlSuspend, IAC, SE})
+	in.Write([]byte{IAC, SB, ComPort, ComPortPurgeData, ComPortPurgeBoth, IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	port := &fakeSerialPort{parity: ComPortParityEven}
+	option := NewComPortOption(port)
+	option.SetSignature("fake")
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		IAC, DO, ComPort,
+		IAC, SB, ComPort, 100 + ComPortSignature, 'f', 'a', 'k', 'e', IAC, SE,
+		IAC, SB, ComPort, 100 + ComPortSetBaudRate, 0, 0, 0x25, 0x80, IAC, SE,
+		IAC, SB, ComPort, 100 + ComPortSetBaudRate, 0, 0, 0x25, 0x80, IAC, SE,
+		IAC, SB, ComPort, 100 + ComPortSetParity, ComPortParityEven, IAC, SE,
+		IAC, SB, ComPort, 100 + ComPortSetDataSize, 7, IAC, SE,
+		IAC, SB, ComPort, 100 + ComPortSetModemStateMask, IAC, IAC, IAC, SE,
+		IAC, SB, ComPort, 100 + ComPortPurgeData, ComPortPurgeBoth, IAC, SE,
+	}, out.Bytes())
+	assert.Equal(t, uint32(9600), port.baudRate)
+	assert.Equal(t, byte(7), port.dataSize)
+	assert.Equal(t, []byte{ComPortPurgeBoth}, port.purged)
+	assert.True(t, port.suspended)
+
+	out.Reset()
+	assert.NoError(t, option.NotifyModemState(ComPortModemCD|ComPortModemDeltaCD))
+	assert.NoError(t, option.NotifyModemState(ComPortModemCD|ComPortModemDeltaCD))
+	assert.NoError(t, option.NotifyLineState(ComPortLineBreakDetect))
+	assert.Equal(t, []byte{
+		IAC, SB, ComPort, 100 + ComPortNotifyModemState, ComPortModemCD | ComPortModemDeltaCD, IAC, SE,
+	}, out.Bytes())
+}
+
+func TestComPortServerWithoutBackend(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, ComPort})
+	in.Write([]byte{IAC, SB, ComPort, ComPortSetBaudRate, 0, 0, 0x25, 0x80, IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewComPortOption(nil)
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{IAC, DO, ComPort}, out.Bytes())
+}
+
+func TestComPortClient(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, ComPort})
+	in.Write([]byte{IAC, SB, ComPort, 100 + ComPortSignature, 'f', 'a', 'k', 'e', IAC, SE})
+	in.Write([]byte{IAC, SB, ComPort, 100 + ComPortSetBaudRate, 0, 0x01, 0xc2, 0, IAC, SE})
+	in.Write([]byte{IAC, SB, ComPort, 100 + ComPortSetStopSize, ComPortStopSizeTwo, IAC, SE})
+	in.Write([]byte{IAC, SB, ComPort, 100 + ComPortNotifyLineState, ComPortLineBreakDetect, IAC, SE})
+	in.Write([]byte{IAC, SB, ComPort, 100 + ComPortFlowControlSuspend, IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewComPortOption(nil)
+	option.Allow(false, true)
+	conn.BindOption(option)
+
+	var events []ComPortEvent
+	conn.AddListener("com-port", FuncListener{func(data any) {
+		events = append(events, data.(ComPortEvent))
+	}})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []ComPortEvent{
+		{Command: ComPortSignature, Signature: "fake"},
+		{Command: ComPortSetBaudRate, Value: 115200},
+		{Command: ComPortSetStopSize, Value: ComPortStopSizeTwo},
+		{Command: ComPortNotifyLineState, Value: ComPortLineBreakDetect},
+		{Command: ComPortFlowControlSuspend},
+	}, events)
+	assert.Equal(t, "fake", option.PeerSignature())
+	assert.Equal(t, uint32(115200), option.BaudRate())
+	assert.Equal(t, byte(ComPortStopSizeTwo), option.StopSize())
+
+	out.Reset()
+	assert.NoError(t, option.SetBaudRate(0xffff))
+	assert.NoError(t, option.SetControl(ComPortControlDTROn))
+	assert.NoError(t, option.FlowControlSuspend())
+	assert.Equal(t, []byte{
+		IAC, SB, ComPort, ComPortSetBaudRate, 0, 0, IAC, IAC, IAC, IAC, IAC, SE,
+		IAC, SB, ComPort, ComPortSetControl, ComPortControlDTROn, IAC, SE,
+		IAC, SB, ComPort, ComPortFlowControlSuspend, IAC, SE,
+	}, out.Bytes())
+}
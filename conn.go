@@ -1,10 +1,15 @@
 package telnet
 
 import (
+	"context"
+	"crypto/cipher"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
+	"sync"
+	"time"
 
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/ianaindex"
@@ -22,12 +27,26 @@ type Conn interface {
 	EnableOptionForUs(option byte, enable bool) error
 	Option(option byte) Option
 
+	LogAttrs(level slog.Level, msg string, attrs ...slog.Attr)
 	RequestEncoding(encoding.Encoding) error
 	Send(p []byte) (n int, err error)
 	SetEncoding(encoding.Encoding)
+	SetFlowControl(enabled, restartAny bool)
+	SetIdleTimeout(time.Duration)
+	SetKeepAlive(interval time.Duration, probe KeepAliveProbe) error
+	SetLimits(Limits)
 	SetLogger(Logger)
+	SetSlogLogger(*slog.Logger)
+	SetNewline(NewlineMode)
+	SetReadCipher(cipher.Stream)
 	SetReadEncoding(encoding.Encoding)
+	SetReadNewline(NewlineMode)
+	SetWriteCipher(cipher.Stream)
 	SetWriteEncoding(encoding.Encoding)
+	SetWriteNewline(NewlineMode)
+	Shutdown(context.Context) error
+	SplitAtEdits(enabled bool)
+	SplitAtPrompts(enabled bool)
 	SuppressGoAhead(enabled bool)
 }
 
@@ -41,28 +60,64 @@ func Dial(addr string) (Conn, error) {
 
 type connection struct {
 	net.Conn
-	Logger
 
+	logger          *slog.Logger
+	listenersMu     sync.RWMutex
 	listeners       map[string][]EventListener
 	opts            *optionMap
+	recv            *recvTracker
+	reader          *reader
+	writer          *cipherWriter
+	nvt             *writer
 	in              io.Reader
+	inEncoding      encoding.Encoding
 	out             io.Writer
 	suppressGoAhead bool
+	flow            flowControl
+
+	readNewline, writeNewline NewlineMode
+	readBinary, writeBinary   bool
+
+	limits           Limits
+	negotiationStart time.Time
+	negotiations     int
+
+	// While the reader hands the peer's option command to its option,
+	// receiving is set and refused counts the requests checkPending turns
+	// down, so that only those are blamed on the peer.
+	receiveMu sync.Mutex
+	receiving bool
+	refused   int
+
+	done      chan struct{}
+	closeOnce sync.Once
+	idle      timerLoop
+	keepAlive timerLoop
 }
 
 func New(upstream net.Conn) *connection {
 	conn := &connection{
 		Conn:      upstream,
-		Logger:    NullLogger{},
 		listeners: map[string][]EventListener{},
 		opts:      newOptionMap(),
+		recv:      newRecvTracker(upstream),
+		writer:    &cipherWriter{out: upstream},
+		done:      make(chan struct{}),
 	}
+	conn.SetLogger(NullLogger{})
+	conn.reader = newReader(conn.recv, conn.handleCommand)
+	conn.nvt = &writer{out: conn.writer}
+	conn.SetReadNewline(NewlineBareLF)
+	conn.reader.overflow = conn.subnegotiationOverflow
+	conn.SetLimits(Limits{MaxSubnegotiation: DefaultMaxSubnegotiation})
 	conn.opts.each(func(o Option) { o.Bind(conn, conn) })
 	conn.SetEncoding(ASCII)
 	return conn
 }
 
 func (c *connection) AddListener(event string, l EventListener) {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
 	c.listeners[event] = append(c.listeners[event], l)
 }
 
@@ -71,9 +126,17 @@ func (c *connection) BindOption(o Option) {
 	c.opts.put(o)
 }
 
+func (c *connection) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return c.Conn.Close()
+}
+
 func (c *connection) EnableOptionForThem(option byte, enable bool) error {
 	opt := c.opts.get(option)
 	var fn func() error
+	if err := c.checkPending(opt); err != nil {
+		return err
+	}
 	if enable {
 		fn = opt.enableThem
 	} else {
@@ -85,6 +148,9 @@ func (c *connection) EnableOptionForThem(option byte, enable bool) error {
 func (c *connection) EnableOptionForUs(option byte, enable bool) error {
 	opt := c.opts.get(option)
 	var fn func() error
+	if err := c.checkPending(opt); err != nil {
+		return err
+	}
 	if enable {
 		fn = opt.enableUs
 	} else {
@@ -93,15 +159,42 @@ func (c *connection) EnableOptionForUs(option byte, enable bool) error {
 	return fn()
 }
 
+func (c *connection) LogAttrs(level slog.Level, msg string, attrs ...slog.Attr) {
+	c.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// Logf logs a message at debug level.
+func (c *connection) Logf(format string, v ...any) {
+	if c.logger.Enabled(context.Background(), slog.LevelDebug) {
+		c.LogAttrs(slog.LevelDebug, fmt.Sprintf(format, v...))
+	}
+}
+
 func (c *connection) Option(option byte) Option {
 	return c.opts.get(option)
 }
 
 func (c *connection) Read(p []byte) (n int, err error) {
-	return c.in.Read(p)
+	for {
+		var read int
+		read, err = c.in.Read(p)
+		var ne net.Error
+		if errors.As(err, &ne) && ne.Timeout() {
+			// The decoder hangs on to errors, so we need a new one for the
+			// connection to be usable once the deadline is moved.
+			c.SetReadEncoding(c.inEncoding)
+		}
+		n = len(c.flow.filter(p[:read]))
+		// If all we got was XON or XOFF, there's nothing to return yet.
+		if n > 0 || read == 0 || err != nil {
+			return
+		}
+	}
 }
 
 func (c *connection) RemoveListener(event string, l EventListener) {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
 	var i int
 	listeners := c.listeners[event]
 	for i = range listeners {
@@ -124,7 +217,7 @@ func (c *connection) RequestEncoding(enc encoding.Encoding) error {
 	msg = append(msg, str...)
 	msg = append(msg, IAC, SE)
 
-	c.Logf("SEND: IAC SB %s %s ;%s IAC SE", optionByte(Charset), charsetByte(charsetRequest), str)
+	logSubnegotiation(c, logSend, Charset, charsetByte(charsetRequest), append([]byte{';'}, str...))
 	_, err = c.Send(msg)
 	if err == nil {
 		c.SendEvent("charset-requested", CharsetRequestedEvent{enc})
@@ -133,11 +226,16 @@ func (c *connection) RequestEncoding(enc encoding.Encoding) error {
 }
 
 func (c *connection) Send(p []byte) (int, error) {
-	return c.Conn.Write(p)
+	return c.writer.Write(p)
 }
 
 func (c *connection) SendEvent(event string, data any) {
-	for _, l := range c.listeners[event] {
+	// Events can come from the keepalive goroutines as well as the reader, and
+	// listeners are free to add or remove listeners, so we work from a copy.
+	c.listenersMu.RLock()
+	listeners := append([]EventListener(nil), c.listeners[event]...)
+	c.listenersMu.RUnlock()
+	for _, l := range listeners {
 		l.HandleEvent(data)
 	}
 }
@@ -147,17 +245,55 @@ func (c *connection) SetEncoding(enc encoding.Encoding) {
 	c.SetWriteEncoding(enc)
 }
 
+// SetLogger logs to a printf-style Logger, by way of NewLoggerHandler.
 func (c *connection) SetLogger(logger Logger) {
-	c.Logger = logger
+	c.logger = slog.New(NewLoggerHandler(logger))
+}
+
+// SetSlogLogger logs to logger, with the connection's local and remote
+// addresses as attributes.
+func (c *connection) SetSlogLogger(logger *slog.Logger) {
+	if addr := c.LocalAddr(); addr != nil {
+		logger = logger.With(slog.String("local_addr", addr.String()))
+	}
+	if addr := c.RemoteAddr(); addr != nil {
+		logger = logger.With(slog.String("remote_addr", addr.String()))
+	}
+	c.logger = logger
+}
+
+func (c *connection) SetReadCipher(stream cipher.Stream) {
+	c.reader.stream = stream
 }
 
 func (c *connection) SetReadEncoding(enc encoding.Encoding) {
-	c.in = enc.NewDecoder().Reader(NewReader(c.Conn, c.handleCommand))
+	c.in, c.inEncoding = enc.NewDecoder().Reader(c.reader), enc
+}
+
+func (c *connection) SetWriteCipher(stream cipher.Stream) {
+	c.writer.setStream(stream)
+}
+
+// sendThenSetWriteCipher sends p and then switches ciphers, so that nothing
+// else can be written in between.
+func (c *connection) sendThenSetWriteCipher(p []byte, stream cipher.Stream) error {
+	return c.writer.writeThenSetStream(p, stream)
 }
 
 func (c *connection) SetWriteEncoding(enc encoding.Encoding) {
+	c.out = enc.NewEncoder().Writer(c.nvt)
+}
+
+// SplitAtEdits makes Read stop at each EC or EL, so that the "command" event for
+// it is only sent once the data before it has been returned.
+func (c *connection) SplitAtEdits(enabled bool) {
+	c.reader.splitEdits = enabled
+}
 
-	c.out = enc.NewEncoder().Writer(NewWriter(c.Conn))
+// SplitAtPrompts makes Read stop at each GA or EOR, so that the "command"
+// event for it is only sent once the data before it has been returned.
+func (c *connection) SplitAtPrompts(enabled bool) {
+	c.reader.splitPrompts = enabled
 }
 
 func (c *connection) SuppressGoAhead(enabled bool) {
@@ -165,6 +301,9 @@ func (c *connection) SuppressGoAhead(enabled bool) {
 }
 
 func (c *connection) Write(p []byte) (n int, err error) {
+	if err = c.flow.wait(c.done); err != nil {
+		return
+	}
 	n, err = c.out.Write(p)
 	if err == nil && !c.suppressGoAhead {
 		_, err = c.Send([]byte{IAC, GA})
@@ -173,20 +312,29 @@ func (c *connection) Write(p []byte) (n int, err error) {
 }
 
 func (c *connection) handleCommand(cmd any) (err error) {
-	if s, ok := cmd.(fmt.Stringer); ok {
-		c.Logf("RECV: %s", s)
-	}
-
 	switch t := cmd.(type) {
 	case *telnetGoAhead:
-		// do nothing
+		logCommand(c, logRecv, GA)
+		c.SendEvent("command", CommandEvent{GA})
+	case *telnetCommand:
+		logCommand(c, logRecv, t.cmd)
+		c.SendEvent("command", CommandEvent{t.cmd})
 	case *telnetOptionCommand:
-		opt := c.opts.get(byte(t.opt))
-		err = opt.receive(t.cmd)
+		logOptionCommand(c, logRecv, t.cmd, t.opt)
+		var ok bool
+		if ok, err = c.allowNegotiation(t.opt); !ok {
+			if err == nil {
+				err = c.opts.get(t.opt).refuse(t.cmd)
+			}
+			return
+		}
+		err = c.receiveOptionCommand(c.opts.get(t.opt), t.cmd)
 		if err != nil {
 			return
 		}
 	case *telnetSubnegotiation:
+		// Options log their own subnegotiations, since they know what's in
+		// them.
 		option := c.opts.get(t.opt)
 		option.Subnegotiation(t.bytes)
 	}
@@ -219,14 +367,12 @@ func (o *SuppressGoAheadOption) HandleEvent(data any) {
 	}
 }
 
-type Logger interface {
-	Logf(fmt string, v ...any)
+// CommandEvent is sent with "command" for each of the simple commands (IP, AYT,
+// GA, etc.) we receive.
+type CommandEvent struct {
+	Command byte
 }
 
-type NullLogger struct{}
-
-func (NullLogger) Logf(string, ...any) {}
-
 type CharsetRequestedEvent struct {
 	enc encoding.Encoding
 }
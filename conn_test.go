@@ -2,8 +2,12 @@ package telnet
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"net"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/text/encoding/unicode"
@@ -16,7 +20,7 @@ func TestReadGoAhead(t *testing.T) {
 	logger := NewMockLogger(t)
 	conn.SetLogger(logger)
 
-	logger.EXPECT().Logf("RECV: %s", []any{&telnetGoAhead{}})
+	logger.EXPECT().Logf("%s", []any{"RECV: IAC GA"})
 
 	buf := make([]byte, 8)
 	n, err := conn.Read(buf)
@@ -41,20 +45,11 @@ func TestWriteGoAhead(t *testing.T) {
 }
 
 func expectReceiveOptionCommand(logger *MockLogger, cmd, opt byte) {
-	logger.EXPECT().Logf(
-		"RECV: %s",
-		[]any{&telnetOptionCommand{cmd, opt}},
-	)
+	logger.EXPECT().Logf("%s", []any{fmt.Sprintf("RECV: IAC %s %s", commandByte(cmd), optionByte(opt))})
 }
 
 func expectSendOptionCommand(logger *MockLogger, cmd, opt byte) {
-	logger.EXPECT().Logf(
-		"SEND: IAC %s %s",
-		[]any{
-			commandByte(cmd),
-			optionByte(opt),
-		},
-	)
+	logger.EXPECT().Logf("%s", []any{fmt.Sprintf("SEND: IAC %s %s", commandByte(cmd), optionByte(opt))})
 }
 
 func TestOption(t *testing.T) {
@@ -224,13 +219,7 @@ func TestSubnegotiationForUnsupportedOption(t *testing.T) {
 	conn := newTestConn(in, nil)
 
 	logger := NewMockLogger(t)
-	logger.EXPECT().Logf(
-		"RECV: IAC SB %s %q IAC SE",
-		[]any{
-			optionByte(Echo),
-			[]byte("hi"),
-		},
-	)
+	logger.EXPECT().Logf("%s", []any{`RECV: IAC SB ECHO "hi" IAC SE`})
 
 	conn.SetLogger(logger)
 
@@ -316,3 +305,46 @@ func TestDifferentEvents(t *testing.T) {
 	conn.SendEvent("foo", "bar")
 	assert.Equal(t, 1, count)
 }
+
+func TestReadAfterDeadline(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+	conn := New(local)
+
+	conn.SetReadDeadline(time.Now())
+	_, err := conn.Read(make([]byte, 8))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	conn.SetReadDeadline(time.Time{})
+	go remote.Write([]byte("hi"))
+	buf := make([]byte, 8)
+	n, err := conn.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "hi", string(buf[:n]))
+}
+
+func TestEnableOptionWhileReading(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+	conn := New(local)
+	conn.Option(Echo).Allow(true, true)
+	go io.Copy(io.Discard, remote)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(io.Discard, conn)
+	}()
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			remote.Write([]byte{IAC, WILL, Echo, IAC, WONT, Echo})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, conn.EnableOptionForThem(Echo, i%2 == 0))
+		conn.Option(Echo).EnabledForThem()
+	}
+	remote.Close()
+	<-done
+}
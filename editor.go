@@ -0,0 +1,421 @@
+package telnet
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Editor reads lines from a character-mode client, letting the user edit them
+// as they type: the arrow keys, Home, End and Delete do what you'd expect, as
+// do the usual Emacs control keys (^A ^B ^D ^E ^F ^K ^L ^N ^P ^U ^W), and Tab
+// asks Complete for completions. It draws with ANSI escape sequences and
+// assumes each character takes up one column.
+//
+// The editor needs to do the echoing, and to see every key as it is pressed,
+// so it's only active while ECHO and SUPPRESS-GO-AHEAD are enabled for us (the
+// latter through a SuppressGoAheadOption, so that GA isn't sent after
+// everything we draw). Otherwise ReadLine writes the prompt and reads the line
+// with a LineReader, leaving the client to edit and echo it.
+type Editor struct {
+	// Prompt is written at the start of each line.
+	Prompt string
+
+	// Complete returns the candidates for completing line. If there's only
+	// one, it replaces the line. If there are more, the line is extended as
+	// far as they agree, or else they're listed.
+	Complete func(line string) []string
+
+	// MaxHistory is the most lines kept in the history. Zero means no limit.
+	MaxHistory int
+
+	conn  Conn
+	lines *LineReader // shares its buffered input and CR state with us
+	esc   []byte
+
+	editing bool
+	width   int
+	line    []rune
+	pos     int
+	row     int // the row the cursor is on, counting from the prompt's
+
+	history []string
+	histPos int
+	saved   []rune
+}
+
+const defaultEditorWidth = 80
+
+func NewEditor(conn Conn) *Editor {
+	e := &Editor{
+		conn:  conn,
+		lines: NewLineReader(conn),
+		width: defaultEditorWidth,
+	}
+	if naws, ok := conn.Option(NAWS).(*NAWSOption); ok {
+		if width, _ := naws.WindowSize(); width > 0 {
+			e.width = int(width)
+		}
+	}
+	conn.AddListener("command", e)
+	conn.AddListener("window-size", e)
+	return e
+}
+
+func (e *Editor) Close() error {
+	e.conn.RemoveListener("command", e)
+	e.conn.RemoveListener("window-size", e)
+	return e.lines.Close()
+}
+
+// AddHistory adds a line to the history. ReadLine adds each line it returns
+// that isn't blank, or the same as the last one.
+func (e *Editor) AddHistory(line string) {
+	if strings.TrimSpace(line) == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+	if e.MaxHistory > 0 && len(e.history) > e.MaxHistory {
+		e.history = e.history[len(e.history)-e.MaxHistory:]
+	}
+}
+
+func (e *Editor) History() []string {
+	return append([]string(nil), e.history...)
+}
+
+func (e *Editor) HandleEvent(data any) {
+	switch t := data.(type) {
+	case CommandEvent:
+		if !e.editing {
+			return
+		}
+		switch t.Command {
+		case EC:
+			e.backspace()
+		case EL:
+			e.setLine(nil)
+		}
+	case WindowSizeEvent:
+		if t.Width == 0 {
+			return
+		}
+		e.width = int(t.Width)
+		if e.editing {
+			// The terminal has rewrapped what we drew, so the cursor is
+			// on whichever row it now falls on.
+			e.row = (e.promptWidth() + e.pos) / e.width
+			e.refresh()
+		}
+	}
+}
+
+// ReadLine writes the prompt and returns the line the user enters, without its
+// line ending. ^D on an empty line returns io.EOF.
+func (e *Editor) ReadLine() (string, error) {
+	if !e.active() {
+		if e.Prompt != "" {
+			if _, err := e.conn.Write([]byte(e.Prompt)); err != nil {
+				return "", err
+			}
+		}
+		line, err := e.lines.ReadLine()
+		if err == nil {
+			e.AddHistory(line)
+		}
+		return line, err
+	}
+
+	e.editing = true
+	defer func() { e.editing = false }()
+	e.line, e.pos, e.row = e.line[:0], 0, 0
+	e.histPos, e.saved = len(e.history), nil
+	e.esc = nil
+	e.write(e.Prompt)
+
+	l := e.lines
+	for {
+		for len(l.pending) > 0 && utf8.FullRune(l.pending) {
+			r, size := utf8.DecodeRune(l.pending)
+			l.pending = l.pending[size:]
+			if line, done, err := e.key(r); done {
+				return line, err
+			}
+		}
+		if len(e.esc) == 0 {
+			// An ESC at the end of what the client sent is the Escape
+			// key, not the start of a sequence, so it mustn't eat the next
+			// key.
+			e.esc = nil
+		}
+		n, err := e.conn.Read(l.buf)
+		l.pending = append(l.pending, l.buf[:n]...)
+		if err != nil && n == 0 {
+			return string(e.line), err
+		}
+	}
+}
+
+func (e *Editor) active() bool {
+	return e.conn.Option(Echo).EnabledForUs() && e.conn.Option(SuppressGoAhead).EnabledForUs()
+}
+
+// key handles a key, and returns the line if that ended it.
+func (e *Editor) key(r rune) (line string, done bool, err error) {
+	if e.esc != nil {
+		if len(e.esc) > 0 || r == '[' || r == 'O' {
+			e.escape(byte(r))
+			return
+		}
+		// Not a sequence after all, so it's just a key.
+		e.esc = nil
+	}
+
+	sawCR := e.lines.sawCR
+	e.lines.sawCR = false
+	switch r {
+	case '\r', '\n':
+		if r == '\n' && sawCR {
+			return
+		}
+		e.lines.sawCR = r == '\r'
+		e.moveTo(len(e.line))
+		e.write("\n")
+		line = string(e.line)
+		e.AddHistory(line)
+		return line, true, nil
+	case 0x1b:
+		e.esc = []byte{}
+	case 0x01: // ^A
+		e.moveTo(0)
+	case 0x02: // ^B
+		e.moveTo(e.pos - 1)
+	case 0x04: // ^D
+		if len(e.line) == 0 {
+			e.write("\n")
+			return "", true, io.EOF
+		}
+		e.delete()
+	case 0x05: // ^E
+		e.moveTo(len(e.line))
+	case 0x06: // ^F
+		e.moveTo(e.pos + 1)
+	case '\b', 0x7f:
+		e.backspace()
+	case '\t':
+		e.complete()
+	case 0x0b: // ^K
+		e.setLine(e.line[:e.pos])
+	case 0x0c: // ^L
+		e.write("\x1b[H\x1b[2J")
+		e.row = 0
+		e.refresh()
+	case 0x0e: // ^N
+		e.historyNext()
+	case 0x10: // ^P
+		e.historyPrev()
+	case 0x15: // ^U
+		e.line = append(e.line[:0], e.line[e.pos:]...)
+		e.pos = 0
+		e.refresh()
+	case 0x17: // ^W
+		e.deleteWord()
+	default:
+		if r >= ' ' {
+			e.insert(r)
+		}
+	}
+	return
+}
+
+// escape collects an escape sequence (ESC [ ... or ESC O ...) and acts on it
+// once it's complete.
+func (e *Editor) escape(c byte) {
+	e.esc = append(e.esc, c)
+	if len(e.esc) == 1 {
+		return
+	}
+	if c < 0x40 || c > 0x7e {
+		return
+	}
+	seq := string(e.esc[1:])
+	e.esc = nil
+	switch seq {
+	case "A":
+		e.historyPrev()
+	case "B":
+		e.historyNext()
+	case "C":
+		e.moveTo(e.pos + 1)
+	case "D":
+		e.moveTo(e.pos - 1)
+	case "H", "1~", "7~":
+		e.moveTo(0)
+	case "F", "4~", "8~":
+		e.moveTo(len(e.line))
+	case "3~":
+		e.delete()
+	}
+}
+
+func (e *Editor) insert(r rune) {
+	e.line = append(e.line, 0)
+	copy(e.line[e.pos+1:], e.line[e.pos:])
+	e.line[e.pos] = r
+	e.pos++
+	if e.pos == len(e.line) && (e.promptWidth()+e.pos)%e.width != 0 {
+		// The easy case: typing at the end of the line, and not at the
+		// end of a row.
+		e.write(string(r))
+		return
+	}
+	e.refresh()
+}
+
+func (e *Editor) backspace() {
+	if e.pos == 0 {
+		return
+	}
+	e.line = append(e.line[:e.pos-1], e.line[e.pos:]...)
+	e.pos--
+	e.refresh()
+}
+
+func (e *Editor) delete() {
+	if e.pos == len(e.line) {
+		return
+	}
+	e.line = append(e.line[:e.pos], e.line[e.pos+1:]...)
+	e.refresh()
+}
+
+func (e *Editor) deleteWord() {
+	start := e.pos
+	for start > 0 && e.line[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && e.line[start-1] != ' ' {
+		start--
+	}
+	e.line = append(e.line[:start], e.line[e.pos:]...)
+	e.pos = start
+	e.refresh()
+}
+
+func (e *Editor) historyPrev() {
+	if e.histPos == 0 {
+		return
+	}
+	if e.histPos == len(e.history) {
+		e.saved = append([]rune(nil), e.line...)
+	}
+	e.histPos--
+	e.setLine([]rune(e.history[e.histPos]))
+}
+
+func (e *Editor) historyNext() {
+	if e.histPos == len(e.history) {
+		return
+	}
+	e.histPos++
+	if e.histPos == len(e.history) {
+		e.setLine(e.saved)
+	} else {
+		e.setLine([]rune(e.history[e.histPos]))
+	}
+}
+
+func (e *Editor) complete() {
+	if e.Complete == nil {
+		return
+	}
+	candidates := e.Complete(string(e.line))
+	switch len(candidates) {
+	case 0:
+		e.write("\a")
+		return
+	case 1:
+		e.setLine([]rune(candidates[0]))
+		return
+	}
+	prefix := []rune(candidates[0])
+	for _, c := range candidates[1:] {
+		rs := []rune(c)
+		n := 0
+		for n < len(prefix) && n < len(rs) && prefix[n] == rs[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	if len(prefix) > len(e.line) {
+		e.setLine(prefix)
+		return
+	}
+	e.moveTo(len(e.line))
+	e.write("\n" + strings.Join(candidates, "  ") + "\n")
+	e.row = 0
+	e.refresh()
+}
+
+// setLine replaces the line, leaving the cursor at the end.
+func (e *Editor) setLine(line []rune) {
+	e.line = append(e.line[:0], line...)
+	e.pos = len(e.line)
+	e.refresh()
+}
+
+func (e *Editor) promptWidth() int {
+	return utf8.RuneCountInString(e.Prompt)
+}
+
+// moveTo moves the cursor within the line.
+func (e *Editor) moveTo(pos int) {
+	if pos < 0 || pos > len(e.line) || pos == e.pos {
+		return
+	}
+	var b strings.Builder
+	e.pos = pos
+	e.cursorTo(&b, e.promptWidth()+pos)
+	e.write(b.String())
+}
+
+// cursorTo moves the cursor to a column, counting from the start of the
+// prompt and wrapping at the width of the terminal.
+func (e *Editor) cursorTo(b *strings.Builder, col int) {
+	row := col / e.width
+	if row < e.row {
+		fmt.Fprintf(b, "\x1b[%dA", e.row-row)
+	} else if row > e.row {
+		fmt.Fprintf(b, "\x1b[%dB", row-e.row)
+	}
+	b.WriteString("\r")
+	if col%e.width > 0 {
+		fmt.Fprintf(b, "\x1b[%dC", col%e.width)
+	}
+	e.row = row
+}
+
+// refresh redraws the prompt and the line, and puts the cursor back.
+func (e *Editor) refresh() {
+	var b strings.Builder
+	if e.row > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", e.row)
+	}
+	b.WriteString("\r\x1b[J")
+	b.WriteString(e.Prompt)
+	b.WriteString(string(e.line))
+	end := e.promptWidth() + len(e.line)
+	if end > 0 && end%e.width == 0 {
+		// The cursor stays at the end of a full row until something is
+		// written, so we start the next row ourselves.
+		b.WriteString("\n")
+	}
+	e.row = end / e.width
+	e.cursorTo(&b, e.promptWidth()+e.pos)
+	e.write(b.String())
+}
+
+func (e *Editor) write(s string) {
+	e.conn.Write([]byte(s))
+}
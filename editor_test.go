@@ -0,0 +1,160 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEditor(in string, out *bytes.Buffer) *Editor {
+	if out == nil {
+		out = &bytes.Buffer{}
+	}
+	conn := newTestConn(bytes.NewBufferString(in), out)
+	conn.Option(Echo).(*option).us = telnetQYes
+	conn.Option(SuppressGoAhead).(*option).us = telnetQYes
+	conn.SuppressGoAhead(true)
+	return NewEditor(conn)
+}
+
+func readEditorLines(e *Editor) (lines []string, err error) {
+	for {
+		var line string
+		if line, err = e.ReadLine(); err != nil {
+			return
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestEditorEditing(t *testing.T) {
+	e := newTestEditor(strings.Join([]string{
+		"helo\x1b[Dl\r\n",
+		"abc\x01X\x05Y\r\x00",
+		"one two\x17three\n",
+		"abc\x02\x02\x0b\r\x00",
+		"abc\x02\x15\r\x00",
+		"ab\x7f\x7fc\x1b[3~\x1bOH\x1b[3~\r\x00",
+		"ab\x1b[D\x1b[Dc\x1b[F\x04d\r\x00",
+		"\x04",
+	}, ""), nil)
+	lines, err := readEditorLines(e)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, []string{"hello", "XabcY", "one three", "a", "c", "", "cabd"}, lines)
+}
+
+func TestEditorCommands(t *testing.T) {
+	in := "abc" + string([]byte{IAC, EC}) + "d\r\x00xyz" + string([]byte{IAC, EL}) + "ok\r\x00"
+	lines, err := readEditorLines(newTestEditor(in, nil))
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, []string{"abd", "ok"}, lines)
+}
+
+func TestEditorHistory(t *testing.T) {
+	e := newTestEditor("one\r\x00two\r\x00\x1b[A\x1b[A\r\x00\x10\x10\x0e\r\x00new\x10\x0e\r\x00", nil)
+	e.MaxHistory = 3
+	lines, err := readEditorLines(e)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, []string{"one", "two", "one", "one", "new"}, lines)
+	assert.Equal(t, []string{"two", "one", "new"}, e.History())
+}
+
+func TestEditorComplete(t *testing.T) {
+	var out bytes.Buffer
+	e := newTestEditor("s\t\r\x00l\to\t\r\x00x\t\r\x00", &out)
+	e.Complete = func(line string) (candidates []string) {
+		for _, c := range []string{"look", "listen", "say"} {
+			if strings.HasPrefix(c, line) {
+				candidates = append(candidates, c)
+			}
+		}
+		return
+	}
+	lines, err := readEditorLines(e)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, []string{"say", "look", "x"}, lines)
+	assert.Contains(t, out.String(), "\r\nlook  listen\r\n")
+	assert.Contains(t, out.String(), "x\a")
+}
+
+func TestEditorOutput(t *testing.T) {
+	var out bytes.Buffer
+	e := newTestEditor("ab\x02\r\x00", &out)
+	e.Prompt = "> "
+	line, err := e.ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "ab", line)
+	assert.Equal(t, "> ab\r\x00\x1b[3C\r\x00\x1b[4C\r\n", out.String())
+}
+
+func TestEditorWrap(t *testing.T) {
+	var out bytes.Buffer
+	e := newTestEditor("abc\x01\r\x00", &out)
+	e.Prompt = "> "
+	e.HandleEvent(WindowSizeEvent{5, 24})
+	line, err := e.ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", line)
+	assert.Equal(t, strings.Join([]string{
+		"> ab",
+		"\r\x00\x1b[J> abc\r\n\r\x00", // the row is full, so we start the next
+		"\x1b[1A\r\x00\x1b[2C",        // ^A
+		"\x1b[1B\r\x00\r\n",           // back to the end, then the newline
+	}, ""), out.String())
+
+	// A narrower window moves the cursor down a row when it rewraps.
+	out.Reset()
+	e.editing = true
+	e.line, e.pos, e.row = []rune("abcdef"), 6, 1
+	e.HandleEvent(WindowSizeEvent{4, 24})
+	assert.Equal(t, "\x1b[2A\r\x00\x1b[J> abcdef\r\n\r\x00", out.String())
+}
+
+func TestEditorFallback(t *testing.T) {
+	var out bytes.Buffer
+	conn := newTestConn(bytes.NewBufferString("hi\r\n"), &out)
+	e := NewEditor(conn)
+	e.Prompt = "> "
+	line, err := e.ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "hi", line)
+	assert.Equal(t, []byte{'>', ' ', IAC, GA}, out.Bytes())
+	assert.Equal(t, []string{"hi"}, e.History())
+}
+
+func TestEditorSwitchingModes(t *testing.T) {
+	e := newTestEditor("one\r\x00two\r\x00", nil)
+	line, err := e.ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "one", line)
+
+	// What was read along with the first line is still there once the
+	// client is doing its own editing.
+	e.conn.Option(Echo).(*option).us = telnetQNo
+	line, err = e.ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "two", line)
+}
+
+func TestEditorLoneEscape(t *testing.T) {
+	var out bytes.Buffer
+	conn := newTestConn(&chunkReader{[]byte("ab\x1b"), []byte("c\x1bd\r\x00")}, &out)
+	conn.Option(Echo).(*option).us = telnetQYes
+	conn.Option(SuppressGoAhead).(*option).us = telnetQYes
+	conn.SuppressGoAhead(true)
+	line, err := NewEditor(conn).ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "abcd", line)
+}
+
+func TestEditorForgetsUnfinishedEscape(t *testing.T) {
+	e := newTestEditor("A\r\x00", nil)
+	// What was left of a sequence when the last ReadLine gave up.
+	e.esc = []byte("[")
+	line, err := e.ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "A", line)
+}
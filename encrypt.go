@@ -0,0 +1,402 @@
+package telnet
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"log/slog"
+	"sync"
+)
+
+// Cipher is a single encryption type for the ENCRYPT option. RFC 2946
+// negotiates each direction separately, so the same Cipher handles both our
+// output (Start, Reply and Encrypter) and the peer's (Is and Decrypter). As
+// with Authenticator, each connection needs its own.
+type Cipher interface {
+	Type() byte
+
+	// SetKey is called with the session key agreed by the AUTHENTICATION
+	// option, if the authenticator provided one.
+	SetKey(key []byte)
+
+	// Start returns the data for the first IS we send.
+	Start() ([]byte, error)
+
+	// Is handles the data from an IS sent by the peer and returns the data
+	// for our REPLY. Once ready is true, Decrypter must return a stream.
+	Is(data []byte) (reply []byte, ready bool, err error)
+
+	// Reply handles the data from a REPLY sent by the peer and returns the
+	// data for our next IS, if any. Once ready is true, Encrypter must return
+	// a stream.
+	Reply(data []byte) (next []byte, ready bool, err error)
+
+	Encrypter() cipher.Stream
+	Decrypter() cipher.Stream
+}
+
+// We only ever use a single key, so this is the only key-id we send.
+var defaultKeyID = []byte{0}
+
+type EncryptOption struct {
+	Option
+	ciphers []Cipher
+
+	// mu guards the state below, which the reader goroutine changes while
+	// the application calls Start, End, Encrypting and Decrypting. sendMu is
+	// taken before mu is released so that our START and END go out in the
+	// order we changed encrypting, without holding mu while we write.
+	mu     sync.Mutex
+	sendMu sync.Mutex
+
+	encrypter      Cipher
+	encrypterReady bool
+	encrypterKeyID []byte
+	encrypting     bool
+
+	decrypter      Cipher
+	decrypterReady bool
+	decrypterKeyID []byte
+	decrypting     bool
+}
+
+func NewEncryptOption(ciphers ...Cipher) *EncryptOption {
+	return &EncryptOption{
+		Option:  NewOption(Encrypt),
+		ciphers: ciphers,
+	}
+}
+
+func (e *EncryptOption) Bind(conn Conn, sink EventSink) {
+	e.Option.Bind(conn, sink)
+	conn.AddListener("update-option", e)
+	conn.AddListener("authenticated", e)
+}
+
+// Decrypting reports whether the data we receive is currently encrypted.
+func (e *EncryptOption) Decrypting() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.decrypting
+}
+
+// Encrypting reports whether the data we send is currently encrypted.
+func (e *EncryptOption) Encrypting() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.encrypting
+}
+
+// End stops encrypting the data we send.
+func (e *EncryptOption) End() {
+	e.mu.Lock()
+	if !e.encrypting {
+		e.mu.Unlock()
+		return
+	}
+	e.encrypting = false
+	encType := e.typeOf(e.encrypter)
+	e.sendMu.Lock()
+	e.mu.Unlock()
+
+	e.sendEncryptThenSetCipher(encryptEnd, nil, nil)
+	e.sendMu.Unlock()
+	e.Sink().SendEvent("encryption-ended", EncryptionEvent{encType, true})
+}
+
+// RequestEnd asks the peer to stop encrypting the data it sends.
+func (e *EncryptOption) RequestEnd() {
+	if e.EnabledForThem() {
+		e.sendEncrypt(encryptRequestEnd, nil)
+	}
+}
+
+// RequestStart asks the peer to start encrypting the data it sends.
+func (e *EncryptOption) RequestStart() {
+	if e.EnabledForThem() {
+		e.sendEncrypt(encryptRequestStart, defaultKeyID)
+	}
+}
+
+// Start starts encrypting the data we send. It does nothing until the peer has
+// verified our key, at which point we start encrypting automatically.
+func (e *EncryptOption) Start() {
+	if !e.EnabledForUs() {
+		return
+	}
+	e.mu.Lock()
+	if e.encrypting || e.encrypterKeyID == nil {
+		e.mu.Unlock()
+		return
+	}
+	e.encrypting = true
+	keyID, stream, encType := e.encrypterKeyID, e.encrypter.Encrypter(), e.typeOf(e.encrypter)
+	e.sendMu.Lock()
+	e.mu.Unlock()
+
+	e.sendEncryptThenSetCipher(encryptStart, keyID, stream)
+	e.sendMu.Unlock()
+	e.Sink().SendEvent("encryption-started", EncryptionEvent{encType, true})
+}
+
+func (e *EncryptOption) HandleEvent(data any) {
+	switch t := data.(type) {
+	case AuthenticatedEvent:
+		if len(t.SessionKey) > 0 {
+			for _, c := range e.ciphers {
+				c.SetKey(t.SessionKey)
+			}
+		}
+	case UpdateOptionEvent:
+		if t.Option.Byte() != Encrypt {
+			return
+		}
+		if t.TheyChanged {
+			e.mu.Lock()
+			e.resetDecrypter()
+			e.mu.Unlock()
+			if t.EnabledForThem() {
+				types := make([]byte, 0, len(e.ciphers))
+				for _, c := range e.ciphers {
+					types = append(types, c.Type())
+				}
+				e.sendEncrypt(encryptSupport, types)
+			}
+		}
+		if t.WeChanged {
+			e.mu.Lock()
+			e.resetEncrypter()
+			e.mu.Unlock()
+		}
+	}
+}
+
+func (e *EncryptOption) Subnegotiation(buf []byte) {
+	if len(buf) == 0 {
+		logSubnegotiation(e.Conn(), logRecv, e.Byte(), nil, nil)
+		return
+	}
+
+	cmd, buf := buf[0], buf[1:]
+	logSubnegotiation(e.Conn(), logRecv, e.Byte(), encryptByte(cmd), buf)
+
+	if e.EnabledForUs() {
+		switch cmd {
+		case encryptSupport:
+			e.receiveSupport(buf)
+		case encryptReply:
+			if len(buf) > 0 {
+				e.receiveReply(buf[0], buf[1:])
+			}
+		case encryptDecKeyID:
+			e.receiveDecKeyID(buf)
+		case encryptRequestStart:
+			e.Start()
+		case encryptRequestEnd:
+			e.End()
+		}
+	}
+
+	if e.EnabledForThem() {
+		switch cmd {
+		case encryptIs:
+			if len(buf) > 0 {
+				e.receiveIs(buf[0], buf[1:])
+			}
+		case encryptEncKeyID:
+			e.receiveEncKeyID(buf)
+		case encryptStart:
+			e.receiveStart(buf)
+		case encryptEnd:
+			e.receiveEnd()
+		}
+	}
+}
+
+func (e *EncryptOption) find(encType byte) Cipher {
+	for _, c := range e.ciphers {
+		if c.Type() == encType {
+			return c
+		}
+	}
+	return nil
+}
+
+func (e *EncryptOption) receiveSupport(types []byte) {
+	e.mu.Lock()
+	e.resetEncrypter()
+	is := []byte{EncryptNull}
+	for _, t := range types {
+		c := e.find(t)
+		if c == nil {
+			continue
+		}
+		data, err := c.Start()
+		if err != nil {
+			logFailure(e.Conn(), e.Byte(), "encryption failed", err, slog.String("type", encryptTypeByte(t).String()))
+			continue
+		}
+		e.encrypter = c
+		is = append([]byte{t}, data...)
+		break
+	}
+	e.mu.Unlock()
+	e.sendEncrypt(encryptIs, is)
+}
+
+func (e *EncryptOption) receiveReply(encType byte, data []byte) {
+	e.mu.Lock()
+	if e.encrypter == nil || e.encrypter.Type() != encType {
+		e.mu.Unlock()
+		return
+	}
+	next, ready, err := e.encrypter.Reply(data)
+	if err != nil {
+		e.resetEncrypter()
+		e.mu.Unlock()
+		logFailure(e.Conn(), e.Byte(), "encryption failed", err, slog.String("type", encryptTypeByte(encType).String()))
+		return
+	}
+	sendKeyID := ready && !e.encrypterReady
+	if sendKeyID {
+		e.encrypterReady = true
+	}
+	e.mu.Unlock()
+
+	if next != nil {
+		e.sendEncrypt(encryptIs, append([]byte{encType}, next...))
+	}
+	if sendKeyID {
+		e.sendEncrypt(encryptEncKeyID, defaultKeyID)
+	}
+}
+
+func (e *EncryptOption) receiveDecKeyID(keyID []byte) {
+	e.mu.Lock()
+	if !e.encrypterReady || !bytes.Equal(keyID, defaultKeyID) {
+		// An empty DEC_KEYID means that the peer doesn't know our key, so we
+		// can't start encrypting.
+		e.encrypterKeyID = nil
+		e.mu.Unlock()
+		return
+	}
+	e.encrypterKeyID = keyID
+	e.mu.Unlock()
+	e.Start()
+}
+
+func (e *EncryptOption) receiveIs(encType byte, data []byte) {
+	e.mu.Lock()
+	e.resetDecrypter()
+	c := e.find(encType)
+	if c == nil {
+		e.mu.Unlock()
+		return
+	}
+	reply, ready, err := c.Is(data)
+	if err != nil {
+		e.mu.Unlock()
+		logFailure(e.Conn(), e.Byte(), "encryption failed", err, slog.String("type", encryptTypeByte(encType).String()))
+		return
+	}
+	e.decrypter, e.decrypterReady = c, ready
+	e.mu.Unlock()
+
+	if reply != nil {
+		e.sendEncrypt(encryptReply, append([]byte{encType}, reply...))
+	}
+}
+
+func (e *EncryptOption) receiveEncKeyID(keyID []byte) {
+	e.mu.Lock()
+	if e.decrypterReady && bytes.Equal(keyID, defaultKeyID) {
+		e.decrypterKeyID = keyID
+	} else {
+		e.decrypterKeyID, keyID = nil, nil
+	}
+	e.mu.Unlock()
+	e.sendEncrypt(encryptDecKeyID, keyID)
+}
+
+func (e *EncryptOption) receiveStart(keyID []byte) {
+	e.mu.Lock()
+	if e.decrypting || !e.decrypterReady {
+		e.mu.Unlock()
+		return
+	}
+	if e.decrypterKeyID == nil || !bytes.Equal(e.decrypterKeyID, keyID) {
+		e.mu.Unlock()
+		logFailure(e.Conn(), e.Byte(), "START with unknown key-id", nil, slog.String("key_id", string(keyID)))
+		return
+	}
+	e.Conn().SetReadCipher(e.decrypter.Decrypter())
+	e.decrypting = true
+	encType := e.typeOf(e.decrypter)
+	e.mu.Unlock()
+	e.Sink().SendEvent("encryption-started", EncryptionEvent{encType, false})
+}
+
+func (e *EncryptOption) receiveEnd() {
+	e.mu.Lock()
+	if !e.decrypting {
+		e.mu.Unlock()
+		return
+	}
+	e.Conn().SetReadCipher(nil)
+	e.decrypting = false
+	encType := e.typeOf(e.decrypter)
+	e.mu.Unlock()
+	e.Sink().SendEvent("encryption-ended", EncryptionEvent{encType, false})
+}
+
+// resetDecrypter and resetEncrypter must be called with mu held.
+func (e *EncryptOption) resetDecrypter() {
+	if e.decrypting {
+		e.Conn().SetReadCipher(nil)
+	}
+	e.decrypter, e.decrypterReady, e.decrypterKeyID, e.decrypting = nil, false, nil, false
+}
+
+func (e *EncryptOption) resetEncrypter() {
+	if e.encrypting {
+		// Wait for a START that is on its way out, so that we don't switch
+		// the cipher off before it is switched on.
+		e.sendMu.Lock()
+		e.Conn().SetWriteCipher(nil)
+		e.sendMu.Unlock()
+	}
+	e.encrypter, e.encrypterReady, e.encrypterKeyID, e.encrypting = nil, false, nil, false
+}
+
+func (e *EncryptOption) typeOf(c Cipher) byte {
+	if c == nil {
+		return EncryptNull
+	}
+	return c.Type()
+}
+
+func (e *EncryptOption) sendEncrypt(cmd byte, data []byte) {
+	logSubnegotiation(e.Conn(), logSend, e.Byte(), encryptByte(cmd), data)
+	e.Conn().Send(subnegotiation(e.Byte(), append([]byte{cmd}, data...)))
+}
+
+// sendEncryptThenSetCipher sends cmd and switches our output to stream, with
+// nothing written in between where the connection allows it.
+func (e *EncryptOption) sendEncryptThenSetCipher(cmd byte, data []byte, stream cipher.Stream) {
+	conn, ok := e.Conn().(interface {
+		sendThenSetWriteCipher([]byte, cipher.Stream) error
+	})
+	if !ok {
+		e.sendEncrypt(cmd, data)
+		e.Conn().SetWriteCipher(stream)
+		return
+	}
+	logSubnegotiation(e.Conn(), logSend, e.Byte(), encryptByte(cmd), data)
+	conn.sendThenSetWriteCipher(subnegotiation(e.Byte(), append([]byte{cmd}, data...)), stream)
+}
+
+// EncryptionEvent is sent with "encryption-started" and "encryption-ended".
+// Us is true when it is our output that is affected.
+type EncryptionEvent struct {
+	Type byte
+	Us   bool
+}
@@ -0,0 +1,173 @@
+package telnet
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type xorStream byte
+
+func (x xorStream) XORKeyStream(dst, src []byte) {
+	for i, c := range src {
+		dst[i] = c ^ byte(x)
+	}
+}
+
+type xorCipher struct {
+	key byte
+}
+
+func (x *xorCipher) Type() byte               { return EncryptDESCFB64 }
+func (x *xorCipher) SetKey(key []byte)        { x.key = key[0] }
+func (x *xorCipher) Start() ([]byte, error)   { return []byte("iv"), nil }
+func (x *xorCipher) Encrypter() cipher.Stream { return xorStream(x.key) }
+func (x *xorCipher) Decrypter() cipher.Stream { return xorStream(x.key) }
+func (x *xorCipher) Is([]byte) ([]byte, bool, error) {
+	return []byte("ok"), true, nil
+}
+func (x *xorCipher) Reply([]byte) ([]byte, bool, error) {
+	return nil, true, nil
+}
+
+func xorBytes(key byte, p []byte) []byte {
+	out := make([]byte, len(p))
+	xorStream(key).XORKeyStream(out, p)
+	return out
+}
+
+func TestEncryptOptionDecrypts(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, Encrypt})
+	in.Write([]byte{IAC, SB, Encrypt, encryptIs, EncryptDESCFB64, 'i', 'v', IAC, SE})
+	in.Write([]byte{IAC, SB, Encrypt, encryptEncKeyID, 0, IAC, SE})
+	in.Write([]byte{IAC, SB, Encrypt, encryptStart, 0, IAC, SE})
+	in.Write(xorBytes(0x55, []byte{'h', 'i', IAC, SB, Encrypt, encryptEnd, IAC, SE}))
+	in.Write([]byte("!"))
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewEncryptOption(&xorCipher{})
+	option.Allow(true, false)
+	conn.BindOption(option)
+	conn.SendEvent("authenticated", AuthenticatedEvent{SessionKey: []byte{0x55}})
+
+	var events []string
+	conn.AddListener("encryption-started", FuncListener{func(data any) {
+		assert.Equal(t, EncryptionEvent{EncryptDESCFB64, false}, data)
+		events = append(events, "started")
+	}})
+	conn.AddListener("encryption-ended", FuncListener{func(data any) {
+		assert.Equal(t, EncryptionEvent{EncryptDESCFB64, false}, data)
+		events = append(events, "ended")
+	}})
+
+	buf, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hi!"), buf)
+	assert.Equal(t, []string{"started", "ended"}, events)
+	assert.False(t, option.Decrypting())
+	assert.Equal(t, []byte{
+		IAC, DO, Encrypt,
+		IAC, SB, Encrypt, encryptSupport, EncryptDESCFB64, IAC, SE,
+		IAC, SB, Encrypt, encryptReply, EncryptDESCFB64, 'o', 'k', IAC, SE,
+		IAC, SB, Encrypt, encryptDecKeyID, 0, IAC, SE,
+	}, out.Bytes())
+}
+
+func TestEncryptOptionRejectsUnknownKeyID(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, Encrypt})
+	in.Write([]byte{IAC, SB, Encrypt, encryptIs, EncryptDESCFB64, 'i', 'v', IAC, SE})
+	in.Write([]byte{IAC, SB, Encrypt, encryptEncKeyID, 1, IAC, SE})
+	in.Write([]byte{IAC, SB, Encrypt, encryptStart, 1, IAC, SE})
+	in.Write([]byte("hi"))
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewEncryptOption(&xorCipher{0x55})
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	buf, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hi"), buf)
+	assert.False(t, option.Decrypting())
+	assert.True(t, bytes.HasSuffix(out.Bytes(), []byte{IAC, SB, Encrypt, encryptDecKeyID, IAC, SE}))
+}
+
+func TestEncryptOptionEncrypts(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, Encrypt})
+	in.Write([]byte{IAC, SB, Encrypt, encryptSupport, EncryptDES3CFB64, EncryptDESCFB64, IAC, SE})
+	in.Write([]byte{IAC, SB, Encrypt, encryptReply, EncryptDESCFB64, 'o', 'k', IAC, SE})
+	in.Write([]byte{IAC, SB, Encrypt, encryptDecKeyID, 0, IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+	conn.SuppressGoAhead(true)
+
+	option := NewEncryptOption(&xorCipher{0x55})
+	option.Allow(false, true)
+	conn.BindOption(option)
+
+	var started bool
+	conn.AddListener("encryption-started", FuncListener{func(data any) {
+		assert.Equal(t, EncryptionEvent{EncryptDESCFB64, true}, data)
+		started = true
+	}})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.True(t, started)
+	assert.True(t, option.Encrypting())
+	assert.Equal(t, []byte{
+		IAC, WILL, Encrypt,
+		IAC, SB, Encrypt, encryptIs, EncryptDESCFB64, 'i', 'v', IAC, SE,
+		IAC, SB, Encrypt, encryptEncKeyID, 0, IAC, SE,
+		IAC, SB, Encrypt, encryptStart, 0, IAC, SE,
+	}, out.Bytes())
+
+	out.Reset()
+	_, err = conn.Write([]byte("hi"))
+	assert.NoError(t, err)
+	option.End()
+	_, err = conn.Write([]byte("!"))
+	assert.NoError(t, err)
+	expected := xorBytes(0x55, []byte{'h', 'i', IAC, SB, Encrypt, encryptEnd, IAC, SE})
+	expected = append(expected, '!')
+	assert.Equal(t, expected, out.Bytes())
+	assert.False(t, option.Encrypting())
+}
+
+func TestEncryptOptionWhileReading(t *testing.T) {
+	r, w := io.Pipe()
+	conn := newTestConn(r, io.Discard)
+	conn.SuppressGoAhead(true)
+
+	option := NewEncryptOption(&xorCipher{0x55})
+	option.Allow(false, true)
+	conn.BindOption(option)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(io.Discard, conn)
+	}()
+
+	w.Write([]byte{IAC, DO, Encrypt})
+	w.Write([]byte{IAC, SB, Encrypt, encryptSupport, EncryptDESCFB64, IAC, SE})
+	w.Write([]byte{IAC, SB, Encrypt, encryptReply, EncryptDESCFB64, 'o', 'k', IAC, SE})
+	go func() {
+		for i := 0; i < 100; i++ {
+			w.Write([]byte{IAC, SB, Encrypt, encryptDecKeyID, 0, IAC, SE})
+			w.Write([]byte{IAC, SB, Encrypt, encryptRequestEnd, IAC, SE})
+		}
+		w.Close()
+	}()
+	for i := 0; i < 100; i++ {
+		option.Start()
+		option.Encrypting()
+		option.End()
+		option.Decrypting()
+	}
+	<-done
+}
@@ -0,0 +1,240 @@
+package telnet
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// NewEnvironOption implements RFC 1572. As a client it answers SEND with the
+// variables it was created with, and sends INFO when one of them is changed
+// with SetVar. As a server it asks for all of the client's variables as soon as
+// the client agrees to send them.
+type NewEnvironOption struct {
+	Option
+
+	mu       sync.Mutex
+	vars     map[string]string
+	peerVars map[string]string
+}
+
+// wellKnownVars are the variables RFC 1572 defines, which are sent as VAR
+// rather than USERVAR.
+var wellKnownVars = []string{"USER", "JOB", "ACCT", "PRINTER", "SYSTEMTYPE", "DISPLAY"}
+
+func NewNewEnvironOption(vars map[string]string) *NewEnvironOption {
+	return &NewEnvironOption{
+		Option:   NewOption(NewEnviron),
+		vars:     maps.Clone(vars),
+		peerVars: map[string]string{},
+	}
+}
+
+func (e *NewEnvironOption) Bind(conn Conn, sink EventSink) {
+	e.Option.Bind(conn, sink)
+	conn.AddListener("update-option", e)
+}
+
+// Environ returns the variables the peer has sent us.
+func (e *NewEnvironOption) Environ() map[string]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return maps.Clone(e.peerVars)
+}
+
+// Lookup returns the value of a variable the peer has sent us, and whether it
+// has sent it.
+func (e *NewEnvironOption) Lookup(name string) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	value, ok := e.peerVars[name]
+	return value, ok
+}
+
+// RequestEnviron asks the client for the named variables, or for all of them
+// if there are no names.
+func (e *NewEnvironOption) RequestEnviron(names ...string) {
+	if !e.EnabledForThem() {
+		return
+	}
+	var data []byte
+	for _, name := range names {
+		data = append(data, environVarType(name))
+		data = appendEnvironEscaped(data, name)
+	}
+	e.send(environSend, data)
+}
+
+// SetVar sets one of our variables, telling the server about it if it's
+// already asked for them.
+func (e *NewEnvironOption) SetVar(name, value string) {
+	e.mu.Lock()
+	if e.vars == nil {
+		e.vars = map[string]string{}
+	}
+	e.vars[name] = value
+	e.mu.Unlock()
+	if e.EnabledForUs() {
+		e.send(environInfo, appendEnvironVar(nil, name, value, true))
+	}
+}
+
+func (e *NewEnvironOption) HandleEvent(data any) {
+	event, ok := data.(UpdateOptionEvent)
+	if !ok || event.Option.Byte() != NewEnviron {
+		return
+	}
+	if event.TheyChanged && event.EnabledForThem() {
+		e.RequestEnviron()
+	}
+}
+
+func (e *NewEnvironOption) Subnegotiation(buf []byte) {
+	if len(buf) == 0 {
+		logSubnegotiation(e.Conn(), logRecv, e.Byte(), nil, nil)
+		return
+	}
+
+	cmd, buf := buf[0], buf[1:]
+	logSubnegotiation(e.Conn(), logRecv, e.Byte(), environByte(cmd), buf)
+
+	switch cmd {
+	case environSend:
+		if e.EnabledForUs() {
+			e.mu.Lock()
+			data := e.reply(parseEnviron(buf))
+			e.mu.Unlock()
+			e.send(environIs, data)
+		}
+	case environIs, environInfo:
+		if !e.EnabledForThem() {
+			return
+		}
+		vars := map[string]string{}
+		e.mu.Lock()
+		for _, v := range parseEnviron(buf) {
+			if v.defined {
+				vars[v.name] = v.value
+				e.peerVars[v.name] = v.value
+			} else {
+				delete(e.peerVars, v.name)
+			}
+		}
+		e.mu.Unlock()
+		e.Sink().SendEvent("environ", EnvironEvent{Vars: vars})
+	}
+}
+
+// reply builds the answer to a SEND. Variables we don't have are sent without
+// a value, which says they aren't defined. A VAR or USERVAR without a name asks
+// for all of the variables of that type, and an empty SEND for all of them. It
+// must be called with mu held.
+func (e *NewEnvironOption) reply(requested []environVariable) (data []byte) {
+	if len(requested) == 0 {
+		for _, name := range slices.Sorted(maps.Keys(e.vars)) {
+			data = appendEnvironVar(data, name, e.vars[name], true)
+		}
+		return
+	}
+	for _, v := range requested {
+		if v.name == "" {
+			for _, name := range slices.Sorted(maps.Keys(e.vars)) {
+				if environVarType(name) == v.kind {
+					data = appendEnvironVar(data, name, e.vars[name], true)
+				}
+			}
+			continue
+		}
+		value, ok := e.vars[v.name]
+		data = appendEnvironVar(data, v.name, value, ok)
+	}
+	return
+}
+
+func (e *NewEnvironOption) send(cmd byte, data []byte) {
+	logSubnegotiation(e.Conn(), logSend, e.Byte(), environByte(cmd), data)
+	e.Conn().Send(subnegotiation(e.Byte(), append([]byte{cmd}, data...)))
+}
+
+type environVariable struct {
+	kind        byte // VAR or USERVAR
+	name, value string
+	defined     bool
+}
+
+// parseEnviron parses the list of variables in a SEND, IS or INFO. In a SEND
+// none of them have values.
+func parseEnviron(buf []byte) (vars []environVariable) {
+	var (
+		field   []byte
+		inValue bool
+		current *environVariable
+	)
+	finish := func() {
+		if current == nil {
+			return
+		}
+		if inValue {
+			current.value = string(field)
+		} else {
+			current.name = string(field)
+		}
+		vars = append(vars, *current)
+	}
+	for i := 0; i < len(buf); i++ {
+		switch c := buf[i]; c {
+		case environVar, environUservar:
+			finish()
+			current, field, inValue = &environVariable{kind: c}, nil, false
+		case environValue:
+			if current == nil || inValue {
+				continue
+			}
+			current.name, current.defined = string(field), true
+			field, inValue = nil, true
+		case environEsc:
+			if i+1 < len(buf) {
+				i++
+				field = append(field, buf[i])
+			}
+		default:
+			field = append(field, c)
+		}
+	}
+	finish()
+	return
+}
+
+func environVarType(name string) byte {
+	if slices.Contains(wellKnownVars, name) {
+		return environVar
+	}
+	return environUservar
+}
+
+func appendEnvironVar(data []byte, name, value string, defined bool) []byte {
+	data = append(data, environVarType(name))
+	data = appendEnvironEscaped(data, name)
+	if defined {
+		data = append(data, environValue)
+		data = appendEnvironEscaped(data, value)
+	}
+	return data
+}
+
+func appendEnvironEscaped(data []byte, s string) []byte {
+	for _, c := range []byte(s) {
+		switch c {
+		case environVar, environValue, environEsc, environUservar:
+			data = append(data, environEsc)
+		}
+		data = append(data, c)
+	}
+	return data
+}
+
+// EnvironEvent is sent when the peer sends us variables. Vars holds the ones
+// it sent with values; any it sent without are no longer defined.
+type EnvironEvent struct {
+	Vars map[string]string
+}
@@ -0,0 +1,120 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEnvironServer(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, NewEnviron})
+	in.Write([]byte{IAC, SB, NewEnviron, environIs, environVar})
+	in.WriteString("USER")
+	in.WriteByte(environValue)
+	in.WriteString("guest")
+	in.WriteByte(environUservar)
+	in.WriteString("A")
+	in.Write([]byte{environEsc, environValue})
+	in.WriteByte(environValue)
+	in.Write([]byte{environEsc, environVar, IAC, IAC})
+	in.WriteByte(environUservar)
+	in.WriteString("EMPTY")
+	in.WriteByte(environValue)
+	in.Write([]byte{IAC, SE})
+	in.Write([]byte{IAC, SB, NewEnviron, environInfo, environUservar})
+	in.WriteString("EMPTY")
+	in.WriteByte(environUservar)
+	in.WriteString("COLORTERM")
+	in.WriteByte(environValue)
+	in.WriteString("truecolor")
+	in.Write([]byte{IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewNewEnvironOption(nil)
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	var events []any
+	conn.AddListener("environ", FuncListener{func(data any) { events = append(events, data) }})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{
+		EnvironEvent{map[string]string{"USER": "guest", "A\x01": "\x00\xff", "EMPTY": ""}},
+		EnvironEvent{map[string]string{"COLORTERM": "truecolor"}},
+	}, events)
+	assert.Equal(t, map[string]string{"USER": "guest", "A\x01": "\x00\xff", "COLORTERM": "truecolor"}, option.Environ())
+	value, ok := option.Lookup("COLORTERM")
+	assert.True(t, ok)
+	assert.Equal(t, "truecolor", value)
+	_, ok = option.Lookup("EMPTY")
+	assert.False(t, ok)
+	assert.Equal(t, []byte{
+		IAC, DO, NewEnviron,
+		IAC, SB, NewEnviron, environSend, IAC, SE,
+	}, out.Bytes())
+
+	out.Reset()
+	option.RequestEnviron("USER", "TERM")
+	expected := []byte{IAC, SB, NewEnviron, environSend, environVar}
+	expected = append(expected, "USER"...)
+	expected = append(expected, environUservar)
+	expected = append(expected, "TERM"...)
+	expected = append(expected, IAC, SE)
+	assert.Equal(t, expected, out.Bytes())
+}
+
+func TestNewEnvironClient(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, NewEnviron})
+	in.Write([]byte{IAC, SB, NewEnviron, environSend, IAC, SE})
+	in.Write([]byte{IAC, SB, NewEnviron, environSend, environVar})
+	in.WriteString("USER")
+	in.WriteByte(environUservar)
+	in.WriteString("HOME")
+	in.Write([]byte{IAC, SE})
+	in.Write([]byte{IAC, SB, NewEnviron, environSend, environUservar, IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewNewEnvironOption(map[string]string{"USER": "me", "TERM": "xterm"})
+	option.Allow(false, true)
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	expected := []byte{IAC, WILL, NewEnviron}
+	expected = append(expected, IAC, SB, NewEnviron, environIs, environUservar)
+	expected = append(expected, "TERM"...)
+	expected = append(expected, environValue)
+	expected = append(expected, "xterm"...)
+	expected = append(expected, environVar)
+	expected = append(expected, "USER"...)
+	expected = append(expected, environValue)
+	expected = append(expected, "me"...)
+	expected = append(expected, IAC, SE)
+	expected = append(expected, IAC, SB, NewEnviron, environIs, environVar)
+	expected = append(expected, "USER"...)
+	expected = append(expected, environValue)
+	expected = append(expected, "me"...)
+	expected = append(expected, environUservar)
+	expected = append(expected, "HOME"...)
+	expected = append(expected, IAC, SE)
+	expected = append(expected, IAC, SB, NewEnviron, environIs, environUservar)
+	expected = append(expected, "TERM"...)
+	expected = append(expected, environValue)
+	expected = append(expected, "xterm"...)
+	expected = append(expected, IAC, SE)
+	assert.Equal(t, expected, out.Bytes())
+
+	out.Reset()
+	option.SetVar("TERM", "xterm-256color")
+	expected = []byte{IAC, SB, NewEnviron, environInfo, environUservar}
+	expected = append(expected, "TERM"...)
+	expected = append(expected, environValue)
+	expected = append(expected, "xterm-256color"...)
+	expected = append(expected, IAC, SE)
+	assert.Equal(t, expected, out.Bytes())
+}
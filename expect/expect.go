@@ -0,0 +1,297 @@
+// Package expect automates interactive telnet hosts, such as network devices,
+// in the manner of expect(1): wait for some output, send a response, and so
+// on.
+package expect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/stesla/telnet"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+var ErrLoginFailed = errors.New("expect: login failed")
+
+var (
+	loginPattern    = regexp.MustCompile(`(?i)(login|user ?name)\s*:\s*$`)
+	passwordPattern = regexp.MustCompile(`(?i)password\s*:\s*$`)
+	failurePattern  = regexp.MustCompile(`(?i)(login incorrect|login invalid|authentication failed|access denied|bad password)`)
+)
+
+// Session reads everything the host sends in the background, buffering it
+// until it's matched by one of the Expect methods.
+//
+// The host's prompts are found by the GA or EOR that follows them, when the
+// host sends those. Otherwise, set Prompt to match them.
+type Session struct {
+	// Timeout bounds how long each Expect waits, on top of any deadline its
+	// context has. Zero means no limit.
+	Timeout time.Duration
+
+	// Prompt matches the host's prompt, for hosts that don't mark it with GA
+	// or EOR.
+	Prompt *regexp.Regexp
+
+	conn telnet.Conn
+	done chan struct{}
+
+	mu         sync.Mutex
+	buf        []byte
+	marks      []int // where the prompts end in buf
+	transcript bytes.Buffer
+	err        error
+	notify     chan struct{}
+}
+
+// Match is what an Expect matched.
+type Match struct {
+	// Before is the data that came before the match.
+	Before string
+
+	// Groups are the match and its submatches, as from
+	// regexp.FindStringSubmatch.
+	Groups []string
+}
+
+// Dial connects to a host, allowing it to enable ECHO and END-OF-RECORD.
+func Dial(addr string) (*Session, error) {
+	conn, err := telnet.Dial(addr)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range []byte{telnet.Echo, telnet.EndOfRecord} {
+		opt := telnet.NewOption(code)
+		opt.Allow(true, false)
+		conn.BindOption(opt)
+	}
+	return New(conn), nil
+}
+
+// New starts a session on conn. Nothing else should read from conn while it's
+// in use.
+func New(conn telnet.Conn) *Session {
+	s := &Session{
+		Timeout: DefaultTimeout,
+		conn:    conn,
+		done:    make(chan struct{}),
+		notify:  make(chan struct{}),
+	}
+	conn.SplitAtPrompts(true)
+	conn.AddListener("command", s)
+	go s.read()
+	return s
+}
+
+// Close closes the connection.
+func (s *Session) Close() error {
+	err := s.conn.Close()
+	<-s.done
+	return err
+}
+
+func (s *Session) Conn() telnet.Conn {
+	return s.conn
+}
+
+func (s *Session) HandleEvent(data any) {
+	event, ok := data.(telnet.CommandEvent)
+	if !ok || (event.Command != telnet.GA && event.Command != telnet.EOR) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// If there's nothing buffered, the prompt has already been consumed
+	// (the GA or EOR arrives after the data before it).
+	if len(s.buf) == 0 || (len(s.marks) > 0 && s.marks[len(s.marks)-1] == len(s.buf)) {
+		return
+	}
+	s.marks = append(s.marks, len(s.buf))
+	s.broadcast()
+}
+
+// Expect waits for re to match what the host has sent, and consumes everything
+// up to the end of the match.
+func (s *Session) Expect(ctx context.Context, re *regexp.Regexp) (*Match, error) {
+	var groups []string
+	data, start, err := s.expect(ctx, re.String(), func(buf []byte, _ []int) (int, int, bool) {
+		loc := re.FindSubmatchIndex(buf)
+		if loc == nil {
+			return 0, 0, false
+		}
+		groups = submatches(buf, loc)
+		return loc[0], loc[1], true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Match{Before: string(data[:start]), Groups: groups}, nil
+}
+
+// ExpectString waits for the host to send s.
+func (s *Session) ExpectString(ctx context.Context, str string) (*Match, error) {
+	return s.Expect(ctx, regexp.MustCompile(regexp.QuoteMeta(str)))
+}
+
+// ExpectPrompt waits for the host's prompt, and returns everything up to the
+// end of it: typically the output of the last command, and then the prompt.
+func (s *Session) ExpectPrompt(ctx context.Context) (string, error) {
+	data, _, err := s.expect(ctx, "prompt", s.matchPrompt)
+	return string(data), err
+}
+
+func (s *Session) matchPrompt(buf []byte, marks []int) (start, end int, ok bool) {
+	end = -1
+	if len(marks) > 0 {
+		end = marks[0]
+	}
+	if s.Prompt != nil {
+		if loc := s.Prompt.FindIndex(buf); loc != nil && (end < 0 || loc[1] < end) {
+			return loc[0], loc[1], true
+		}
+	}
+	return end, end, end >= 0
+}
+
+// Login answers the host's login and password prompts, and then waits for its
+// prompt. It returns ErrLoginFailed if the host turns it away.
+func (s *Session) Login(ctx context.Context, user, password string) error {
+	if _, err := s.Expect(ctx, loginPattern); err != nil {
+		return err
+	}
+	if err := s.SendLine(user); err != nil {
+		return err
+	}
+	if _, err := s.Expect(ctx, passwordPattern); err != nil {
+		return err
+	}
+	if err := s.SendLine(password); err != nil {
+		return err
+	}
+
+	// Whatever the host sends next, we need to see it all before deciding,
+	// since a failure message is usually followed by another login prompt.
+	var failed bool
+	_, _, err := s.expect(ctx, "prompt after login", func(buf []byte, marks []int) (int, int, bool) {
+		if loc := failurePattern.FindIndex(buf); loc != nil {
+			failed = true
+			return loc[0], loc[1], true
+		}
+		if loc := loginPattern.FindIndex(buf); loc != nil {
+			failed = true
+			return loc[0], loc[1], true
+		}
+		return s.matchPrompt(buf, marks)
+	})
+	if err != nil {
+		return err
+	}
+	if failed {
+		return ErrLoginFailed
+	}
+	return nil
+}
+
+// Send sends s to the host.
+func (s *Session) Send(str string) error {
+	_, err := s.conn.Write([]byte(str))
+	return err
+}
+
+// SendLine sends s to the host, followed by a line ending.
+func (s *Session) SendLine(str string) error {
+	return s.Send(str + "\n")
+}
+
+// Transcript returns everything the host has sent.
+func (s *Session) Transcript() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transcript.String()
+}
+
+func (s *Session) read() {
+	defer close(s.done)
+	defer s.conn.RemoveListener("command", s)
+	buf := make([]byte, 4096)
+	for {
+		n, err := s.conn.Read(buf)
+		s.mu.Lock()
+		s.buf = append(s.buf, buf[:n]...)
+		s.transcript.Write(buf[:n])
+		if err != nil {
+			s.err = err
+		}
+		s.broadcast()
+		s.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// broadcast wakes everything waiting for data. It must be called with mu held.
+func (s *Session) broadcast() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// expect waits for match to find something in the buffer, and then consumes
+// and returns the buffer up to the end of it, along with where it started.
+func (s *Session) expect(ctx context.Context, what string, match func(buf []byte, marks []int) (start, end int, ok bool)) ([]byte, int, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	for {
+		s.mu.Lock()
+		if start, end, ok := match(s.buf, s.marks); ok {
+			data := s.consume(end)
+			s.mu.Unlock()
+			return data, start, nil
+		}
+		err, notify := s.err, s.notify
+		s.mu.Unlock()
+
+		if err != nil {
+			return nil, 0, fmt.Errorf("expect: connection closed waiting for %s: %w", what, err)
+		}
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return nil, 0, fmt.Errorf("expect: timed out waiting for %s: %w", what, ctx.Err())
+		}
+	}
+}
+
+// consume removes the first n bytes of the buffer, and the prompts in them. It
+// must be called with mu held.
+func (s *Session) consume(n int) []byte {
+	data := bytes.Clone(s.buf[:n])
+	s.buf = append(s.buf[:0], s.buf[n:]...)
+	marks := s.marks[:0]
+	for _, mark := range s.marks {
+		if mark > n {
+			marks = append(marks, mark-n)
+		}
+	}
+	s.marks = marks
+	return data
+}
+
+func submatches(buf []byte, loc []int) []string {
+	groups := make([]string, len(loc)/2)
+	for i := range groups {
+		if loc[2*i] >= 0 {
+			groups[i] = string(buf[loc[2*i]:loc[2*i+1]])
+		}
+	}
+	return groups
+}
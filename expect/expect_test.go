@@ -0,0 +1,153 @@
+package expect
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stesla/telnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// device is a fake network device: it asks for a username and password, and
+// then runs commands until it's told to exit.
+type device struct {
+	user, password string
+	noGoAhead      bool
+}
+
+func (d device) serve(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go d.session(telnet.New(c))
+		}
+	}()
+	return l.Addr().String()
+}
+
+func (d device) session(conn telnet.Conn) {
+	defer conn.Close()
+	conn.SuppressGoAhead(d.noGoAhead)
+	lines := telnet.NewLineReader(conn)
+	write := func(s string) { conn.Write([]byte(s)) }
+
+	write("\nUser Access Verification\n\nUsername: ")
+	for {
+		user, err := lines.ReadLine()
+		if err != nil {
+			return
+		}
+		write("Password: ")
+		password, err := lines.ReadLine()
+		if err != nil {
+			return
+		}
+		if user == d.user && password == d.password {
+			break
+		}
+		write("% Login invalid\n\nUsername: ")
+	}
+
+	write("router>")
+	for {
+		cmd, err := lines.ReadLine()
+		if err != nil {
+			return
+		}
+		switch cmd {
+		case "show version":
+			write("Version 1.0\nUptime 5 days\nrouter>")
+		case "exit":
+			return
+		default:
+			write("% Unknown command\nrouter>")
+		}
+	}
+}
+
+func dial(t *testing.T, d device) *Session {
+	s, err := Dial(d.serve(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	s.Timeout = time.Second
+	return s
+}
+
+func TestLoginAndCommands(t *testing.T) {
+	s := dial(t, device{user: "admin", password: "secret"})
+	ctx := context.Background()
+
+	require.NoError(t, s.Login(ctx, "admin", "secret"))
+	require.NoError(t, s.SendLine("show version"))
+	output, err := s.ExpectPrompt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Version 1.0\nUptime 5 days\nrouter>", output)
+
+	require.NoError(t, s.SendLine("show uptime"))
+	m, err := s.Expect(ctx, regexp.MustCompile(`% (\w+) command`))
+	require.NoError(t, err)
+	assert.Equal(t, "", m.Before)
+	assert.Equal(t, []string{"% Unknown command", "Unknown"}, m.Groups)
+	output, err = s.ExpectPrompt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "\nrouter>", output)
+
+	assert.True(t, strings.HasPrefix(s.Transcript(), "\nUser Access Verification\n\nUsername: Password: router>"))
+}
+
+func TestLoginFailed(t *testing.T) {
+	s := dial(t, device{user: "admin", password: "secret"})
+	err := s.Login(context.Background(), "admin", "wrong")
+	assert.ErrorIs(t, err, ErrLoginFailed)
+}
+
+func TestPromptPattern(t *testing.T) {
+	s := dial(t, device{user: "admin", password: "secret", noGoAhead: true})
+	s.Prompt = regexp.MustCompile(`router>$`)
+	ctx := context.Background()
+
+	require.NoError(t, s.Login(ctx, "admin", "secret"))
+	require.NoError(t, s.SendLine("show version"))
+	output, err := s.ExpectPrompt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Version 1.0\nUptime 5 days\nrouter>", output)
+}
+
+func TestTimeout(t *testing.T) {
+	s := dial(t, device{})
+	s.Timeout = 50 * time.Millisecond
+	_, err := s.ExpectString(context.Background(), "never")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Timeout = 0
+	_, err = s.ExpectString(ctx, "never")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// What was there is still there to be matched.
+	_, err = s.ExpectString(context.Background(), "Username: ")
+	assert.NoError(t, err)
+}
+
+func TestClosed(t *testing.T) {
+	s := dial(t, device{user: "admin", password: "secret"})
+	ctx := context.Background()
+	require.NoError(t, s.Login(ctx, "admin", "secret"))
+	require.NoError(t, s.SendLine("exit"))
+	_, err := s.ExpectPrompt(ctx)
+	assert.True(t, errors.Is(err, io.EOF), "%v", err)
+}
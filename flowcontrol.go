@@ -0,0 +1,198 @@
+package telnet
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+const (
+	xon  = 0x11 // ^Q
+	xoff = 0x13 // ^S
+)
+
+// ToggleFlowControlOption implements RFC 1372. The server tells the client
+// whether to handle XON/XOFF itself (ON) or to pass them along (OFF), and which
+// characters restart output once it has been stopped. While the client passes
+// them along, the server does the flow control itself by pausing Conn.Write.
+type ToggleFlowControlOption struct {
+	Option
+	on, restartAny bool
+}
+
+func NewToggleFlowControlOption() *ToggleFlowControlOption {
+	return &ToggleFlowControlOption{Option: NewOption(ToggleFlowControl), on: true}
+}
+
+func (t *ToggleFlowControlOption) Bind(conn Conn, sink EventSink) {
+	t.Option.Bind(conn, sink)
+	conn.AddListener("update-option", t)
+}
+
+// FlowControl reports whether the client is doing flow control itself.
+func (t *ToggleFlowControlOption) FlowControl() bool { return t.on }
+
+// RestartAny reports whether any character restarts output, rather than just
+// XON.
+func (t *ToggleFlowControlOption) RestartAny() bool { return t.restartAny }
+
+// SetFlowControl tells the client whether to do flow control itself. When it
+// doesn't, we stop writing when we receive XOFF.
+func (t *ToggleFlowControlOption) SetFlowControl(on bool) error {
+	cmd := byte(flowControlOff)
+	if on {
+		cmd = flowControlOn
+	}
+	if err := t.send(cmd); err != nil {
+		return err
+	}
+	t.on = on
+	t.update()
+	return nil
+}
+
+// SetRestartAny tells the client which characters restart output.
+func (t *ToggleFlowControlOption) SetRestartAny(restartAny bool) error {
+	cmd := byte(flowControlRestartXON)
+	if restartAny {
+		cmd = flowControlRestartAny
+	}
+	if err := t.send(cmd); err != nil {
+		return err
+	}
+	t.restartAny = restartAny
+	t.update()
+	return nil
+}
+
+func (t *ToggleFlowControlOption) HandleEvent(data any) {
+	event, ok := data.(UpdateOptionEvent)
+	if !ok || event.Option.Byte() != ToggleFlowControl {
+		return
+	}
+	if event.TheyChanged {
+		// The client starts out doing flow control itself.
+		t.on, t.restartAny = true, false
+		t.update()
+	}
+	if event.WeChanged {
+		t.on, t.restartAny = true, false
+	}
+}
+
+func (t *ToggleFlowControlOption) Subnegotiation(buf []byte) {
+	if len(buf) != 1 {
+		logSubnegotiation(t.Conn(), logRecv, t.Byte(), nil, buf)
+		return
+	}
+
+	cmd := buf[0]
+	logSubnegotiation(t.Conn(), logRecv, t.Byte(), flowControlByte(cmd), nil)
+	if !t.EnabledForUs() {
+		return
+	}
+
+	switch cmd {
+	case flowControlOff:
+		t.on = false
+	case flowControlOn:
+		t.on = true
+	case flowControlRestartAny:
+		t.restartAny = true
+	case flowControlRestartXON:
+		t.restartAny = false
+	default:
+		return
+	}
+	t.Sink().SendEvent("flow-control", FlowControlEvent{t.on, t.restartAny})
+}
+
+func (t *ToggleFlowControlOption) send(cmd byte) error {
+	if !t.EnabledForThem() {
+		return errors.New("toggle-flow-control option not enabled")
+	}
+	logSubnegotiation(t.Conn(), logSend, t.Byte(), flowControlByte(cmd), nil)
+	_, err := t.Conn().Send([]byte{IAC, SB, t.Byte(), cmd, IAC, SE})
+	return err
+}
+
+func (t *ToggleFlowControlOption) update() {
+	t.Conn().SetFlowControl(t.EnabledForThem() && !t.on, t.restartAny)
+}
+
+// FlowControlEvent is sent with "flow-control" when the server tells us how to
+// do flow control.
+type FlowControlEvent struct {
+	On, RestartAny bool
+}
+
+// SetFlowControl makes the connection obey XON and XOFF from the peer: XOFF
+// pauses Write until XON (or, with restartAny, any character) arrives. Neither
+// is passed on to Read, although any other character that restarts output is.
+func (c *connection) SetFlowControl(enabled, restartAny bool) {
+	c.flow.set(enabled, restartAny)
+}
+
+type flowControl struct {
+	mu                  sync.Mutex
+	enabled, restartAny bool
+	resume              chan struct{}
+}
+
+func (f *flowControl) set(enabled, restartAny bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.enabled, f.restartAny = enabled, restartAny
+	if !enabled {
+		f.unpause()
+	}
+}
+
+// filter handles any XON and XOFF in p and returns what is left.
+func (f *flowControl) filter(p []byte) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.enabled {
+		return p
+	}
+	out := p[:0]
+	for _, c := range p {
+		switch {
+		case c == xoff:
+			if f.resume == nil {
+				f.resume = make(chan struct{})
+			}
+		case c == xon:
+			f.unpause()
+		default:
+			if f.restartAny {
+				f.unpause()
+			}
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (f *flowControl) unpause() {
+	if f.resume != nil {
+		close(f.resume)
+		f.resume = nil
+	}
+}
+
+// wait blocks while output is paused, or until done is closed.
+func (f *flowControl) wait(done <-chan struct{}) error {
+	f.mu.Lock()
+	resume := f.resume
+	f.mu.Unlock()
+	if resume == nil {
+		return nil
+	}
+	select {
+	case <-resume:
+		return nil
+	case <-done:
+		return net.ErrClosed
+	}
+}
@@ -0,0 +1,132 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToggleFlowControlServer(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, ToggleFlowControl})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+	conn.SuppressGoAhead(true)
+
+	option := NewToggleFlowControlOption()
+	assert.Error(t, option.SetFlowControl(false))
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.True(t, option.FlowControl())
+
+	assert.NoError(t, option.SetFlowControl(false))
+	assert.NoError(t, option.SetRestartAny(true))
+	assert.False(t, option.FlowControl())
+	assert.True(t, option.RestartAny())
+	assert.Equal(t, []byte{
+		IAC, DO, ToggleFlowControl,
+		IAC, SB, ToggleFlowControl, flowControlOff, IAC, SE,
+		IAC, SB, ToggleFlowControl, flowControlRestartAny, IAC, SE,
+	}, out.Bytes())
+	assert.True(t, conn.flow.enabled)
+	assert.True(t, conn.flow.restartAny)
+}
+
+func TestToggleFlowControlClient(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, ToggleFlowControl})
+	in.Write([]byte{IAC, SB, ToggleFlowControl, flowControlOff, IAC, SE})
+	in.Write([]byte{IAC, SB, ToggleFlowControl, flowControlRestartAny, IAC, SE})
+	in.Write([]byte{IAC, SB, ToggleFlowControl, flowControlOn, IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewToggleFlowControlOption()
+	option.Allow(false, true)
+	conn.BindOption(option)
+
+	var events []any
+	conn.AddListener("flow-control", FuncListener{func(data any) { events = append(events, data) }})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{
+		FlowControlEvent{false, false},
+		FlowControlEvent{false, true},
+		FlowControlEvent{true, true},
+	}, events)
+	assert.Equal(t, []byte{IAC, WILL, ToggleFlowControl}, out.Bytes())
+	assert.False(t, conn.flow.enabled)
+}
+
+func TestFlowControlPausesWrite(t *testing.T) {
+	for _, restartAny := range []bool{false, true} {
+		local, remote := net.Pipe()
+		conn := New(local)
+		conn.SuppressGoAhead(true)
+		conn.SetFlowControl(true, restartAny)
+
+		go remote.Write([]byte{'a', xoff, 'b'})
+		buf := make([]byte, 2)
+		_, err := io.ReadFull(conn, buf)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("ab"), buf)
+
+		written := make(chan struct{})
+		go func() {
+			conn.Write([]byte("x"))
+			close(written)
+		}()
+		select {
+		case <-written:
+			t.Fatal("write wasn't paused")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		restart := byte(xon)
+		if restartAny {
+			restart = 'c'
+		}
+		go remote.Write([]byte{restart})
+		go conn.Read(make([]byte, 1))
+
+		_, err = io.ReadFull(remote, buf[:1])
+		assert.NoError(t, err)
+		assert.Equal(t, byte('x'), buf[0])
+		<-written
+
+		conn.Close()
+		remote.Close()
+	}
+}
+
+// chunkReader returns one chunk per Read, and then EOF.
+type chunkReader [][]byte
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(*c) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, (*c)[0])
+	*c = (*c)[1:]
+	return n, nil
+}
+
+func TestReadSkipsFlowControl(t *testing.T) {
+	in := &chunkReader{{IAC, NOP}, {xoff, xon}, []byte("hi"), {xon}}
+	conn := newTestConn(in, nil)
+	conn.SetFlowControl(true, false)
+	buf := make([]byte, 8)
+	n, err := conn.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "hi", string(buf[:n]))
+
+	n, err = conn.Read(buf)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 0, n)
+}
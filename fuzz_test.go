@@ -0,0 +1,295 @@
+package telnet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+)
+
+// sessionSeeds are written by hand, not captured from real clients. Between
+// them they open a session, negotiate options, send subnegotiations with IAC
+// doubled in them, and type with each kind of line ending.
+var sessionSeeds = []string{
+	"\xff\xfd\x03\xff\xfb\x18\xff\xfb\x1f\xff\xfb \xff\xfb!\xff\xfb\"\xff\xfb'\xff\xfd\x05\xff\xfb#" +
+		"\xff\xfa\x1f\x00P\x00\x18\xff\xf0\xff\xfa \x0038400,38400\xff\xf0\xff\xfa#\x00myhost:0\xff\xf0" +
+		"\xff\xfa'\x00\x00DISPLAY\x01myhost:0\xff\xf0\xff\xfa\x18\x00XTERM\xff\xf0\xff\xfd\x01" +
+		"root\r\x00\xff\xf4\xff\xfd\x06\xff\xfa\x1f\x00\x84\x002\xff\xf0",
+	"\xff\xfb\x1f\xff\xfb \xff\xfb\x18\xff\xfb'\xff\xfd\x01\xff\xfb\x03\xff\xfd\x03" +
+		"\xff\xfa\x1f\x00\xff\xff\x000\xff\xf0\xff\xfa\x18\x00XTERM\xff\xf0" +
+		"echo hi\r\n\xff\xf5\xff\xf6\xff\xf1exit\r\n",
+	"\xff\xfd\xc9\xff\xfd\x19\xff\xfb\x18\xff\xfd*" +
+		"\xff\xfa\xc9Core.Hello {\"client\":\"test\"}\xff\xf0\xff\xfa\xc9Core.Supports.Set [\"Char 1\"]\xff\xf0" +
+		"\xff\xfa\x18\x00XTERM-256COLOR\xff\xf0\xff\xfa\x18\x00MTTS 2825\xff\xf0\xff\xfa*\x02UTF-8\xff\xf0" +
+		"look\nsay caf\xc3\xa9\r\n\xff\xf1",
+}
+
+// corpusSeeds returns the session seeds, along with what the clients sent in
+// any transcripts in testdata/transcripts, both chunk by chunk and all
+// together.
+func corpusSeeds(f *testing.F) (seeds [][]byte) {
+	for _, seed := range sessionSeeds {
+		seeds = append(seeds, []byte(seed))
+	}
+	names, err := filepath.Glob("testdata/transcripts/*.jsonl")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, name := range names {
+		entries, err := ReadTranscriptFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		var all []byte
+		for _, e := range entries {
+			if e.Direction == RecordReceived && len(e.Data) > 0 {
+				seeds = append(seeds, e.Data)
+				all = append(all, e.Data...)
+			}
+		}
+		seeds = append(seeds, all)
+	}
+	return
+}
+
+// decodeFrames decodes telnet traffic into frames, joining up data that the
+// reader returned in more than one piece.
+func decodeFrames(r io.Reader, newline NewlineMode) (frames []Frame) {
+	var cmd any
+	reader := newReader(r, func(c any) error {
+		cmd = c
+		return errProxyCommand
+	})
+	reader.newline = newline
+	buf := make([]byte, 4096)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if last := len(frames) - 1; last >= 0 && frames[last].Type == DataFrame {
+				frames[last].Data = append(frames[last].Data, buf[:n]...)
+			} else {
+				frames = append(frames, Frame{Type: DataFrame, Data: bytes.Clone(buf[:n])})
+			}
+		}
+		if err == errProxyCommand {
+			f, err := newFrame(cmd)
+			if err != nil {
+				panic(err)
+			}
+			frames = append(frames, f)
+		} else if err != nil {
+			return
+		}
+	}
+}
+
+func encodeFrames(frames []Frame) []byte {
+	var buf bytes.Buffer
+	for _, f := range frames {
+		buf.Write(f.Bytes())
+	}
+	return buf.Bytes()
+}
+
+func FuzzReader(f *testing.F) {
+	for _, seed := range corpusSeeds(f) {
+		f.Add(seed)
+	}
+	f.Add([]byte{'h', '\r', IAC, GA, '\r'})
+	f.Add([]byte{IAC, SB, NAWS, 0, 80, IAC})
+	f.Add([]byte{IAC, SB, IAC, SE, IAC, IAC, SE})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// How the input is split up mustn't change how it is decoded.
+		for _, newline := range []NewlineMode{NewlineBareLF, NewlineRaw} {
+			frames := decodeFrames(bytes.NewReader(data), newline)
+			if split := decodeFrames(iotest.OneByteReader(bytes.NewReader(data)), newline); fmt.Sprint(split) != fmt.Sprint(frames) {
+				t.Fatalf("decoding one byte at a time (%s): got %v, expected %v", newline, split, frames)
+			}
+		}
+
+		// Whatever the proxy decodes, it must encode so that it decodes the
+		// same.
+		frames := decodeFrames(bytes.NewReader(data), NewlineRaw)
+		if again := decodeFrames(bytes.NewReader(encodeFrames(frames)), NewlineRaw); fmt.Sprint(again) != fmt.Sprint(frames) {
+			t.Fatalf("decoding encoded frames: got %v, expected %v", again, frames)
+		}
+	})
+}
+
+// qPeer is one end of a negotiation. Whatever its option sends piles up in out
+// until it is delivered to the other end.
+type qPeer struct {
+	opt *option
+	out bytes.Buffer
+}
+
+func newQPeer() *qPeer {
+	p := &qPeer{opt: NewOption(Echo)}
+	conn := newTestConn(nil, &p.out)
+	p.opt.Bind(conn, conn)
+	return p
+}
+
+func (p *qPeer) settled() bool {
+	return (p.opt.us == telnetQNo || p.opt.us == telnetQYes) &&
+		(p.opt.them == telnetQNo || p.opt.them == telnetQYes)
+}
+
+// deliver delivers the next command from p to q, checking that q follows the
+// rules RFC 854 and RFC 1143 lay down for answering it.
+func (p *qPeer) deliver(t *testing.T, q *qPeer) {
+	if p.out.Len() == 0 {
+		return
+	}
+	msg := p.out.Next(3)
+	if len(msg) != 3 || msg[0] != IAC || msg[2] != Echo {
+		t.Fatalf("sent %v", msg)
+	}
+	cmd := msg[1]
+	us, them, sent := q.opt.us, q.opt.them, q.out.Len()
+	if err := q.opt.receive(cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	// Never acknowledge a request to enter a mode we're already in.
+	if (cmd == DO && us == telnetQYes) || (cmd == DONT && us == telnetQNo) ||
+		(cmd == WILL && them == telnetQYes) || (cmd == WONT && them == telnetQNo) {
+		if q.out.Len() != sent {
+			t.Fatalf("answered IAC %s in state us=%d them=%d", commandByte(cmd), us, them)
+		}
+	}
+
+	// Never enable an option we didn't ask for unless it's allowed.
+	if us == telnetQNo && q.opt.us == telnetQYes && !q.opt.allowUs {
+		t.Fatal("enabled for us without being allowed")
+	}
+	if them == telnetQNo && q.opt.them == telnetQYes && !q.opt.allowThem {
+		t.Fatal("enabled for them without being allowed")
+	}
+}
+
+// FuzzQMethod runs two ends of a negotiation through a script of requests,
+// changes of policy and deliveries, then checks that once everything in flight
+// has been delivered they settle, and agree.
+func FuzzQMethod(f *testing.F) {
+	// Each byte is an operation on one end: the low bit picks the end, and
+	// the rest picks the operation (see below).
+	f.Add([]byte{10, 11, 0, 9})
+	f.Add([]byte{10, 11, 0, 2, 9, 8, 8})
+	f.Add([]byte{12, 13, 4, 9, 6, 9, 8, 8})
+	f.Add([]byte{10, 0, 2, 0, 2, 9, 8, 9, 8})
+
+	f.Fuzz(func(t *testing.T, script []byte) {
+		peers := [2]*qPeer{newQPeer(), newQPeer()}
+		for _, op := range script {
+			p, q := peers[op&1], peers[1-op&1]
+			var err error
+			switch (op >> 1) % 7 {
+			case 0:
+				err = p.opt.enableUs()
+			case 1:
+				err = p.opt.disableUs()
+			case 2:
+				err = p.opt.enableThem()
+			case 3:
+				err = p.opt.disableThem()
+			case 4:
+				q.deliver(t, p)
+			case 5:
+				p.opt.allowUs = !p.opt.allowUs
+			case 6:
+				p.opt.allowThem = !p.opt.allowThem
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		a, b := peers[0], peers[1]
+		for i := 0; a.out.Len() > 0 || b.out.Len() > 0; i++ {
+			if i > 2*len(script)+2 {
+				t.Fatal("negotiation loop")
+			}
+			a.deliver(t, b)
+			b.deliver(t, a)
+		}
+		if !a.settled() || !b.settled() {
+			t.Fatalf("unsettled: a.us=%d a.them=%d b.us=%d b.them=%d", a.opt.us, a.opt.them, b.opt.us, b.opt.them)
+		}
+		if a.opt.us != b.opt.them || a.opt.them != b.opt.us {
+			t.Fatalf("disagree: a.us=%d a.them=%d b.us=%d b.them=%d", a.opt.us, a.opt.them, b.opt.us, b.opt.them)
+		}
+	})
+}
+
+type fuzzAuthenticator struct{}
+
+func (fuzzAuthenticator) Type() (byte, byte)     { return AuthSRP, AuthMutual }
+func (fuzzAuthenticator) Start() ([]byte, error) { return []byte("start"), nil }
+func (fuzzAuthenticator) Principal() string      { return "fuzz" }
+
+func (fuzzAuthenticator) Is(name string, data []byte) ([]byte, AuthStatus, error) {
+	return data, AuthAccepted, nil
+}
+
+func (fuzzAuthenticator) Reply(data []byte) ([]byte, AuthStatus, error) {
+	return nil, AuthAccepted, nil
+}
+
+// subnegotiationOptions are the options with subnegotiations to parse, in both
+// roles where the role matters.
+var subnegotiationOptions = []func() Option{
+	func() Option { return NewAuthenticationOption(fuzzAuthenticator{}) },
+	func() Option { return NewCharsetOption(false, true) },
+	func() Option { return NewCharsetOption(false, false) },
+	func() Option { return NewComPortOption(&fakeSerialPort{}) },
+	func() Option { return NewComPortOption(nil) },
+	func() Option { return NewEncryptOption(&xorCipher{0x55}) },
+	func() Option { return NewToggleFlowControlOption() },
+	func() Option { return NewLinemodeOption(LinemodeEdit) },
+	func() Option { return NewNAWSOption() },
+	func() Option { return NewNewEnvironOption(map[string]string{"USER": "guest", "TERM": "xterm"}) },
+	func() Option { return NewStatusOption() },
+	func() Option { return NewTerminalTypeOption("XTERM", "VT100") },
+	func() Option { return NewTerminalSpeedOption(38400, 38400) },
+	func() Option { return NewXDisplayLocationOption("myhost:0") },
+}
+
+// FuzzSubnegotiation enables an option in both directions and then feeds it a
+// subnegotiation, which mustn't panic or hang.
+func FuzzSubnegotiation(f *testing.F) {
+	for _, seed := range corpusSeeds(f) {
+		for _, frame := range decodeFrames(bytes.NewReader(seed), NewlineRaw) {
+			if frame.Type != SubnegotiationFrame {
+				continue
+			}
+			for i, fn := range subnegotiationOptions {
+				if fn().Byte() == frame.Option {
+					f.Add(byte(i), frame.Data)
+				}
+			}
+		}
+	}
+	for i := range subnegotiationOptions {
+		for c := byte(0); c < 12; c++ {
+			f.Add(byte(i), []byte{c})
+			f.Add(byte(i), []byte{c, IAC, 0})
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, i byte, data []byte) {
+		opt := subnegotiationOptions[int(i)%len(subnegotiationOptions)]()
+		code := opt.Byte()
+		in := bytes.NewBuffer([]byte{IAC, WILL, code, IAC, DO, code})
+		in.Write(Frame{Type: SubnegotiationFrame, Option: code, Data: data}.Bytes())
+		conn := newTestConn(in, nil)
+		opt.Allow(true, true)
+		conn.BindOption(opt)
+		if _, err := io.ReadAll(conn); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -6,6 +6,9 @@ toolchain go1.24.1
 
 require (
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/net v0.38.0
+	golang.org/x/sys v0.32.0
+	golang.org/x/term v0.31.0
 	golang.org/x/text v0.24.0
 )
 
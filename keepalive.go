@@ -0,0 +1,167 @@
+package telnet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+type KeepAliveProbe int
+
+const (
+	KeepAliveNOP KeepAliveProbe = iota
+	KeepAliveAYT
+	KeepAliveTimingMark
+)
+
+func (p KeepAliveProbe) String() string {
+	switch p {
+	case KeepAliveNOP:
+		return "NOP"
+	case KeepAliveAYT:
+		return "AYT"
+	case KeepAliveTimingMark:
+		return "TIMING-MARK"
+	default:
+		return "unknown"
+	}
+}
+
+type pinger interface {
+	Ping(context.Context) (time.Duration, error)
+}
+
+// SetIdleTimeout closes the connection once nothing has been received from the
+// peer for d. A "idle-timeout" event is sent before the connection is closed.
+// A d of zero turns the timeout off.
+func (c *connection) SetIdleTimeout(d time.Duration) {
+	c.idle.restart(c.done, d, func(stop <-chan struct{}) {
+		for {
+			idle := c.recv.idle()
+			if idle >= d {
+				c.SendEvent("idle-timeout", IdleTimeoutEvent{idle})
+				c.Close()
+				return
+			}
+			select {
+			case <-time.After(d - idle):
+			case <-stop:
+				return
+			}
+		}
+	})
+}
+
+// SetKeepAlive probes the peer whenever nothing has been received from it for
+// interval. If a probe fails, a "peer-unresponsive" event is sent and it is up
+// to the application whether to close the connection. A NOP or AYT probe only
+// fails if it can't be written, while a TIMING-MARK probe also fails if the
+// peer doesn't answer within interval, which requires that a TimingMarkOption
+// be bound. An interval of zero turns keepalives off.
+func (c *connection) SetKeepAlive(interval time.Duration, probe KeepAliveProbe) error {
+	var ping pinger
+	if probe == KeepAliveTimingMark {
+		var ok bool
+		if ping, ok = c.Option(TimingMark).(pinger); !ok {
+			return errors.New("timing-mark option not bound")
+		}
+	}
+
+	c.keepAlive.restart(c.done, interval, func(stop <-chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+
+			idle := c.recv.idle()
+			if idle < interval {
+				continue
+			}
+
+			var err error
+			switch probe {
+			case KeepAliveNOP:
+				_, err = c.Send([]byte{IAC, NOP})
+			case KeepAliveAYT:
+				_, err = c.Send([]byte{IAC, AYT})
+			case KeepAliveTimingMark:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				_, err = ping.Ping(ctx)
+				cancel()
+			}
+			if err != nil {
+				c.LogAttrs(slog.LevelWarn, "keepalive failed", slog.String("probe", probe.String()), slog.Any("error", err))
+				c.SendEvent("peer-unresponsive", PeerUnresponsiveEvent{probe, idle, err})
+			}
+		}
+	})
+	return nil
+}
+
+// recvTracker remembers when we last received anything from the peer.
+type recvTracker struct {
+	io.Reader
+	last atomic.Int64
+}
+
+func newRecvTracker(r io.Reader) *recvTracker {
+	t := &recvTracker{Reader: r}
+	t.last.Store(time.Now().UnixNano())
+	return t
+}
+
+func (t *recvTracker) Read(p []byte) (n int, err error) {
+	n, err = t.Reader.Read(p)
+	if n > 0 {
+		t.last.Store(time.Now().UnixNano())
+	}
+	return
+}
+
+func (t *recvTracker) idle() time.Duration {
+	return time.Since(time.Unix(0, t.last.Load()))
+}
+
+// timerLoop runs at most one goroutine at a time, stopping the previous one
+// whenever it's restarted.
+type timerLoop struct {
+	stop chan struct{}
+}
+
+func (l *timerLoop) restart(done <-chan struct{}, d time.Duration, fn func(<-chan struct{})) {
+	if l.stop != nil {
+		close(l.stop)
+		l.stop = nil
+	}
+	if d <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	l.stop = stop
+	merged := make(chan struct{})
+	go func() {
+		select {
+		case <-stop:
+		case <-done:
+		}
+		close(merged)
+	}()
+	go fn(merged)
+}
+
+type IdleTimeoutEvent struct {
+	Idle time.Duration
+}
+
+type PeerUnresponsiveEvent struct {
+	Probe KeepAliveProbe
+	Idle  time.Duration
+	Err   error
+}
@@ -0,0 +1,99 @@
+package telnet
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeepAliveSendsProbes(t *testing.T) {
+	for probe, expected := range map[KeepAliveProbe][]byte{
+		KeepAliveNOP: {IAC, NOP},
+		KeepAliveAYT: {IAC, AYT},
+	} {
+		local, remote := net.Pipe()
+		conn := New(local)
+		assert.NoError(t, conn.SetKeepAlive(10*time.Millisecond, probe))
+
+		buf := make([]byte, 2)
+		_, err := io.ReadFull(remote, buf)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, buf, probe.String())
+
+		conn.Close()
+		remote.Close()
+	}
+}
+
+func TestKeepAliveTimingMarkNeedsOption(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+	conn := New(local)
+	defer conn.Close()
+
+	assert.Error(t, conn.SetKeepAlive(time.Second, KeepAliveTimingMark))
+	conn.BindOption(NewTimingMarkOption())
+	assert.NoError(t, conn.SetKeepAlive(time.Second, KeepAliveTimingMark))
+}
+
+func TestKeepAlivePeerUnresponsive(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+	conn := New(local)
+	defer conn.Close()
+	conn.BindOption(NewTimingMarkOption())
+	go io.Copy(io.Discard, conn)
+
+	// The peer reads our probes but never answers them.
+	go io.Copy(io.Discard, remote)
+
+	events := make(chan any, 1)
+	conn.AddListener("peer-unresponsive", FuncListener{func(data any) {
+		select {
+		case events <- data:
+		default:
+		}
+	}})
+	assert.NoError(t, conn.SetKeepAlive(10*time.Millisecond, KeepAliveTimingMark))
+
+	select {
+	case data := <-events:
+		event, ok := data.(PeerUnresponsiveEvent)
+		assert.True(t, ok)
+		assert.Equal(t, KeepAliveTimingMark, event.Probe)
+		assert.Error(t, event.Err)
+		assert.GreaterOrEqual(t, event.Idle, 10*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("no peer-unresponsive event")
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+	conn := New(local)
+
+	timedOut := make(chan struct{})
+	conn.AddListener("idle-timeout", FuncListener{func(data any) {
+		assert.IsType(t, IdleTimeoutEvent{}, data)
+		close(timedOut)
+	}})
+	conn.SetIdleTimeout(50 * time.Millisecond)
+
+	// Data from the peer resets the timer.
+	go remote.Write([]byte("hi"))
+	buf := make([]byte, 2)
+	_, err := io.ReadFull(conn, buf)
+	assert.NoError(t, err)
+
+	select {
+	case <-timedOut:
+	case <-time.After(time.Second):
+		t.Fatal("no idle-timeout event")
+	}
+	_, err = conn.Read(buf)
+	assert.Error(t, err)
+}
@@ -0,0 +1,188 @@
+package telnet
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DefaultMaxSubnegotiation is the subnegotiation limit a new connection starts
+// with. None of the options we implement come close to it.
+const DefaultMaxSubnegotiation = 64 * 1024
+
+// Limits protects a connection from a peer that abuses the protocol. A zero
+// field means no limit.
+type Limits struct {
+	// MaxSubnegotiation is the most data we'll buffer for a subnegotiation
+	// before IAC SE arrives.
+	MaxSubnegotiation int
+
+	// MaxNegotiationRate is the most option commands (DO, DONT, WILL and
+	// WONT) we'll act on in any one second. Past it, we refuse whatever the
+	// peer asks us to enable.
+	MaxNegotiationRate int
+
+	// MaxPendingNegotiations is the most options we'll have waiting on the
+	// peer to answer a request of ours at once. Asking for more returns
+	// ErrTooManyPendingNegotiations, and if it was the peer's command that
+	// had us ask, that is a violation.
+	MaxPendingNegotiations int
+
+	// Policy is what to do when a limit is exceeded.
+	Policy ViolationPolicy
+}
+
+type ViolationPolicy int
+
+const (
+	// ViolationDrop drops whatever exceeded the limit.
+	ViolationDrop ViolationPolicy = iota
+
+	// ViolationTruncate passes on as much of a subnegotiation as fits in the
+	// limit. It is the same as ViolationDrop for the other limits.
+	ViolationTruncate
+
+	// ViolationDisconnect closes the connection. Read returns the
+	// ProtocolViolation.
+	ViolationDisconnect
+)
+
+func (p ViolationPolicy) String() string {
+	switch p {
+	case ViolationDrop:
+		return "drop"
+	case ViolationTruncate:
+		return "truncate"
+	case ViolationDisconnect:
+		return "disconnect"
+	default:
+		return "unknown"
+	}
+}
+
+type ViolationKind int
+
+const (
+	SubnegotiationTooLong ViolationKind = iota
+	NegotiationRateExceeded
+	TooManyPendingNegotiations
+)
+
+func (k ViolationKind) String() string {
+	switch k {
+	case SubnegotiationTooLong:
+		return "subnegotiation too long"
+	case NegotiationRateExceeded:
+		return "negotiation rate exceeded"
+	case TooManyPendingNegotiations:
+		return "too many pending negotiations"
+	default:
+		return "unknown"
+	}
+}
+
+// ProtocolViolation is sent with "protocol-violation" whenever a limit is
+// exceeded, and is the error Read returns if the policy is to disconnect.
+type ProtocolViolation struct {
+	Kind   ViolationKind
+	Option byte
+	Limit  int
+	Policy ViolationPolicy
+}
+
+func (v ProtocolViolation) Error() string {
+	return fmt.Sprintf("telnet: %s (%s, limit %d)", v.Kind, optionByte(v.Option), v.Limit)
+}
+
+// ErrTooManyPendingNegotiations is returned by EnableOptionForThem and
+// EnableOptionForUs when a request would take us past MaxPendingNegotiations.
+var ErrTooManyPendingNegotiations = errors.New("telnet: too many pending negotiations")
+
+// SetLimits replaces the connection's limits.
+func (c *connection) SetLimits(limits Limits) {
+	c.limits = limits
+	c.reader.maxSubnegotiation = limits.MaxSubnegotiation
+}
+
+func (c *connection) violation(v ProtocolViolation) error {
+	v.Policy = c.limits.Policy
+	c.LogAttrs(slog.LevelWarn, "protocol violation",
+		slog.String("violation", v.Kind.String()),
+		slog.String("option", optionByte(v.Option).String()),
+		slog.Int("limit", v.Limit),
+		slog.String("policy", v.Policy.String()),
+	)
+	c.SendEvent("protocol-violation", v)
+	if v.Policy == ViolationDisconnect {
+		c.Close()
+		return v
+	}
+	return nil
+}
+
+// allowNegotiation counts an option command against the rate limit and says
+// whether to act on it, or only refuse it.
+func (c *connection) allowNegotiation(opt byte) (bool, error) {
+	max := c.limits.MaxNegotiationRate
+	if max <= 0 {
+		return true, nil
+	}
+	if now := time.Now(); now.Sub(c.negotiationStart) >= time.Second {
+		c.negotiationStart, c.negotiations = now, 0
+	}
+	if c.negotiations++; c.negotiations <= max {
+		return true, nil
+	}
+	return false, c.violation(ProtocolViolation{Kind: NegotiationRateExceeded, Option: opt, Limit: max})
+}
+
+// checkPending returns ErrTooManyPendingNegotiations if opt would take us past
+// the limit on pending negotiations.
+func (c *connection) checkPending(opt Option) error {
+	max := c.limits.MaxPendingNegotiations
+	if max <= 0 || opt.negotiating() {
+		return nil
+	}
+	var pending int
+	c.opts.each(func(o Option) {
+		if o.negotiating() {
+			pending++
+		}
+	})
+	if pending < max {
+		return nil
+	}
+	c.receiveMu.Lock()
+	if c.receiving {
+		c.refused++
+	}
+	c.receiveMu.Unlock()
+	return ErrTooManyPendingNegotiations
+}
+
+// receiveOptionCommand hands an option command from the peer to its option. If
+// the listeners that hear about it ask for more negotiations than the limit
+// allows, it's the peer's doing, so that's a violation.
+func (c *connection) receiveOptionCommand(opt Option, cmd byte) error {
+	c.receiveMu.Lock()
+	c.receiving, c.refused = true, 0
+	c.receiveMu.Unlock()
+
+	err := opt.receive(cmd)
+
+	c.receiveMu.Lock()
+	refused := c.refused
+	c.receiving, c.refused = false, 0
+	c.receiveMu.Unlock()
+
+	if err != nil || refused == 0 {
+		return err
+	}
+	return c.violation(ProtocolViolation{Kind: TooManyPendingNegotiations, Option: opt.Byte(), Limit: c.limits.MaxPendingNegotiations})
+}
+
+func (c *connection) subnegotiationOverflow(opt byte) (keep bool, err error) {
+	err = c.violation(ProtocolViolation{Kind: SubnegotiationTooLong, Option: opt, Limit: c.limits.MaxSubnegotiation})
+	return c.limits.Policy == ViolationTruncate, err
+}
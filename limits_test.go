@@ -0,0 +1,132 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubnegotiationLimit(t *testing.T) {
+	var tests = []struct {
+		policy ViolationPolicy
+		events []any
+		data   string
+	}{
+		{ViolationDrop, nil, "hi"},
+		{ViolationTruncate, []any{WindowSizeEvent{80, 24}}, "hi"},
+		{ViolationDisconnect, nil, ""},
+	}
+	for _, test := range tests {
+		in := bytes.NewBuffer([]byte{IAC, WILL, NAWS})
+		in.Write([]byte{IAC, SB, NAWS, 0, 80, 0, 24, IAC, IAC, 1, IAC, SE, 'h', 'i'})
+		conn := newTestConn(in, nil)
+		conn.SetLimits(Limits{MaxSubnegotiation: 4, Policy: test.policy})
+		option := NewNAWSOption()
+		option.Allow(true, false)
+		conn.BindOption(option)
+
+		var events, violations []any
+		conn.AddListener("window-size", FuncListener{func(data any) { events = append(events, data) }})
+		conn.AddListener("protocol-violation", FuncListener{func(data any) { violations = append(violations, data) }})
+
+		expected := ProtocolViolation{Kind: SubnegotiationTooLong, Option: NAWS, Limit: 4, Policy: test.policy}
+		data, err := io.ReadAll(conn)
+		if test.policy == ViolationDisconnect {
+			assert.Equal(t, expected, err)
+		} else {
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, test.data, string(data))
+		assert.Equal(t, test.events, events)
+		assert.Equal(t, []any{expected}, violations)
+	}
+}
+
+func TestDefaultSubnegotiationLimit(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, SB, NAWS})
+	in.Write(make([]byte, DefaultMaxSubnegotiation+1))
+	in.Write([]byte{IAC, SE})
+	conn := newTestConn(in, nil)
+
+	var violations []any
+	conn.AddListener("protocol-violation", FuncListener{func(data any) { violations = append(violations, data) }})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{ProtocolViolation{Kind: SubnegotiationTooLong, Option: NAWS, Limit: DefaultMaxSubnegotiation}}, violations)
+}
+
+func TestNegotiationRateLimit(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, Echo, IAC, DO, SuppressGoAhead, IAC, DO, TransmitBinary, IAC, WILL, NAWS})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+	conn.SetLimits(Limits{MaxNegotiationRate: 2})
+	conn.Option(TransmitBinary).Allow(true, true)
+	conn.Option(NAWS).Allow(true, true)
+
+	var violations []any
+	conn.AddListener("protocol-violation", FuncListener{func(data any) { violations = append(violations, data) }})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	// Past the limit, we refuse even the options we allow.
+	assert.Equal(t, []byte{IAC, WONT, Echo, IAC, WONT, SuppressGoAhead, IAC, WONT, TransmitBinary, IAC, DONT, NAWS}, out.Bytes())
+	assert.Equal(t, []any{
+		ProtocolViolation{Kind: NegotiationRateExceeded, Option: TransmitBinary, Limit: 2},
+		ProtocolViolation{Kind: NegotiationRateExceeded, Option: NAWS, Limit: 2},
+	}, violations)
+}
+
+func TestPendingNegotiationLimit(t *testing.T) {
+	var out bytes.Buffer
+	conn := newTestConn(nil, &out)
+
+	conn.SetLimits(Limits{MaxPendingNegotiations: 1, Policy: ViolationDisconnect})
+
+	var violations []any
+	conn.AddListener("protocol-violation", FuncListener{func(data any) { violations = append(violations, data) }})
+
+	// Our own requests aren't the peer's fault.
+	assert.NoError(t, conn.EnableOptionForUs(Echo, true))
+	assert.Equal(t, ErrTooManyPendingNegotiations, conn.EnableOptionForThem(SuppressGoAhead, true))
+	assert.NoError(t, conn.EnableOptionForUs(Echo, false))
+	assert.Equal(t, []byte{IAC, WILL, Echo}, out.Bytes())
+	assert.Nil(t, violations)
+}
+
+func TestPendingNegotiationLimitFromPeer(t *testing.T) {
+	for _, policy := range []ViolationPolicy{ViolationDrop, ViolationDisconnect} {
+		in := bytes.NewBuffer([]byte{IAC, WILL, NAWS, 'h', 'i'})
+		var out bytes.Buffer
+		conn := newTestConn(in, &out)
+		conn.SetLimits(Limits{MaxPendingNegotiations: 1, Policy: policy})
+		conn.Option(NAWS).Allow(true, false)
+
+		// Whenever the peer enables an option, ask for two more.
+		conn.AddListener("update-option", FuncListener{func(any) {
+			conn.EnableOptionForUs(Echo, true)
+			conn.EnableOptionForUs(SuppressGoAhead, true)
+		}})
+		var violations []any
+		conn.AddListener("protocol-violation", FuncListener{func(data any) { violations = append(violations, data) }})
+
+		expected := ProtocolViolation{Kind: TooManyPendingNegotiations, Option: NAWS, Limit: 1, Policy: policy}
+		data, err := io.ReadAll(conn)
+		if policy == ViolationDisconnect {
+			assert.Equal(t, expected, err)
+			assert.Equal(t, "", string(data))
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, "hi", string(data))
+		}
+		assert.Equal(t, []byte{IAC, DO, NAWS, IAC, WILL, Echo}, out.Bytes())
+		assert.Equal(t, []any{expected}, violations)
+	}
+}
+
+func TestProtocolViolationError(t *testing.T) {
+	v := ProtocolViolation{Kind: SubnegotiationTooLong, Option: NAWS, Limit: 4}
+	assert.Equal(t, "telnet: subnegotiation too long (NAWS, limit 4)", v.Error())
+}
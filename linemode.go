@@ -0,0 +1,113 @@
+package telnet
+
+import "sync"
+
+// RFC 1184 bits for MODE.
+const (
+	LinemodeEdit    = 0x01
+	LinemodeTrapSig = 0x02
+	LinemodeSoftTab = 0x08
+	LinemodeLitEcho = 0x10
+
+	linemodeModeAck = 0x04
+)
+
+// LinemodeOption implements the MODE part of RFC 1184, which is how the server
+// tells the client whether to edit lines itself. As a server it sends the mode
+// it was created with once the client agrees to LINEMODE, and as a client it
+// acknowledges whatever mode the server sends. SLC and FORWARDMASK are not
+// supported.
+type LinemodeOption struct {
+	Option
+
+	mu   sync.Mutex
+	mode byte
+}
+
+func NewLinemodeOption(mode byte) *LinemodeOption {
+	return &LinemodeOption{
+		Option: NewOption(Linemode),
+		mode:   mode &^ linemodeModeAck,
+	}
+}
+
+func (l *LinemodeOption) Bind(conn Conn, sink EventSink) {
+	l.Option.Bind(conn, sink)
+	conn.AddListener("update-option", l)
+}
+
+// Mode returns the current mode.
+func (l *LinemodeOption) Mode() byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.mode
+}
+
+// Edit reports whether the client is editing lines itself.
+func (l *LinemodeOption) Edit() bool {
+	return l.Mode()&LinemodeEdit != 0
+}
+
+// SetMode changes the mode, telling the client if LINEMODE is on.
+func (l *LinemodeOption) SetMode(mode byte) {
+	mode &^= linemodeModeAck
+	l.mu.Lock()
+	l.mode = mode
+	l.mu.Unlock()
+	if l.EnabledForThem() {
+		l.send(linemodeMode, []byte{mode})
+	}
+}
+
+func (l *LinemodeOption) HandleEvent(data any) {
+	event, ok := data.(UpdateOptionEvent)
+	if !ok || event.Option.Byte() != Linemode {
+		return
+	}
+	if event.TheyChanged && event.EnabledForThem() {
+		l.send(linemodeMode, []byte{l.Mode()})
+	}
+}
+
+func (l *LinemodeOption) Subnegotiation(buf []byte) {
+	if len(buf) == 0 {
+		logSubnegotiation(l.Conn(), logRecv, l.Byte(), nil, nil)
+		return
+	}
+
+	cmd, buf := buf[0], buf[1:]
+	logSubnegotiation(l.Conn(), logRecv, l.Byte(), linemodeByte(cmd), buf)
+
+	if cmd != linemodeMode || len(buf) != 1 {
+		return
+	}
+	mode := buf[0]
+	switch {
+	case l.EnabledForThem() && mode&linemodeModeAck != 0:
+		// The client has agreed to a mode.
+		mode &^= linemodeModeAck
+		l.mu.Lock()
+		l.mode = mode
+		l.mu.Unlock()
+	case l.EnabledForUs() && mode&linemodeModeAck == 0:
+		// The server has sent us a mode, and we can do any of them.
+		l.mu.Lock()
+		l.mode = mode
+		l.mu.Unlock()
+		l.send(linemodeMode, []byte{mode | linemodeModeAck})
+	default:
+		return
+	}
+	l.Sink().SendEvent("linemode", LinemodeEvent{mode})
+}
+
+func (l *LinemodeOption) send(cmd byte, data []byte) {
+	logSubnegotiation(l.Conn(), logSend, l.Byte(), linemodeByte(cmd), data)
+	l.Conn().Send(subnegotiation(l.Byte(), append([]byte{cmd}, data...)))
+}
+
+// LinemodeEvent is sent with "linemode" when the client and server agree on a
+// mode.
+type LinemodeEvent struct {
+	Mode byte
+}
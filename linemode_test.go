@@ -0,0 +1,51 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinemodeServer(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, Linemode})
+	in.Write([]byte{IAC, SB, Linemode, linemodeMode, LinemodeEdit | linemodeModeAck, IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewLinemodeOption(LinemodeEdit | LinemodeTrapSig)
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	var events []any
+	conn.AddListener("linemode", FuncListener{func(data any) { events = append(events, data) }})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{LinemodeEvent{LinemodeEdit}}, events)
+	assert.True(t, option.Edit())
+	assert.Equal(t, []byte{
+		IAC, DO, Linemode,
+		IAC, SB, Linemode, linemodeMode, LinemodeEdit | LinemodeTrapSig, IAC, SE,
+	}, out.Bytes())
+}
+
+func TestLinemodeClient(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, Linemode})
+	in.Write([]byte{IAC, SB, Linemode, linemodeMode, LinemodeEdit, IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewLinemodeOption(0)
+	option.Allow(false, true)
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.True(t, option.Edit())
+	assert.Equal(t, []byte{
+		IAC, WILL, Linemode,
+		IAC, SB, Linemode, linemodeMode, LinemodeEdit | linemodeModeAck, IAC, SE,
+	}, out.Bytes())
+}
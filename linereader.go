@@ -0,0 +1,145 @@
+package telnet
+
+import "unicode/utf8"
+
+// LineReader reads a line at a time from a Conn, applying the editing the peer
+// does as it types: IAC EC and backspace (BS or DEL) erase the last character,
+// and IAC EL erases the whole line. A line ends with LF or CR (or both).
+type LineReader struct {
+	// MaxLength is the most characters a line can hold. Anything typed past
+	// it is discarded. Zero means no limit.
+	MaxLength int
+
+	// Echo makes us echo what the peer types, and how it edits the line,
+	// whenever ECHO is enabled for us. Since every write is followed by GA
+	// unless it's suppressed, SUPPRESS-GO-AHEAD should be enabled too.
+	Echo bool
+
+	conn    Conn
+	line    []byte
+	buf     []byte
+	pending []byte
+	sawCR   bool
+	err     error // from a Read that also returned data
+}
+
+// NewLineReader returns a LineReader reading from conn. Nothing else should read
+// from conn while it's in use. Close it to stop it handling commands.
+func NewLineReader(conn Conn) *LineReader {
+	l := &LineReader{conn: conn, buf: make([]byte, 1024)}
+	conn.SplitAtEdits(true)
+	conn.AddListener("command", l)
+	return l
+}
+
+func (l *LineReader) Close() error {
+	l.conn.RemoveListener("command", l)
+	l.conn.SplitAtEdits(false)
+	return nil
+}
+
+func (l *LineReader) HandleEvent(data any) {
+	event, ok := data.(CommandEvent)
+	if !ok {
+		return
+	}
+	switch event.Command {
+	case EC:
+		l.erase(1)
+	case EL:
+		l.erase(utf8.RuneCount(l.line))
+	}
+}
+
+// ReadLine returns the next line, without its line ending. If there's an error
+// before the end of a line, it returns what it has of the line along with the
+// error.
+func (l *LineReader) ReadLine() (string, error) {
+	for {
+		for len(l.pending) > 0 {
+			if !utf8.FullRune(l.pending) {
+				break
+			}
+			r, size := utf8.DecodeRune(l.pending)
+			c := l.pending[:size]
+			l.pending = l.pending[size:]
+			if line, ok := l.add(r, c); ok {
+				return line, nil
+			}
+		}
+
+		if l.err == nil {
+			var n int
+			n, l.err = l.conn.Read(l.buf)
+			l.pending = append(l.pending, l.buf[:n]...)
+			if n > 0 {
+				// Any error waits until we've handed out the lines
+				// that came with it.
+				continue
+			}
+		}
+		if l.err != nil {
+			if len(l.pending) > 0 {
+				// Whatever is left isn't a whole character, but it's
+				// all we're going to get.
+				l.line = append(l.line, l.pending...)
+				l.pending = nil
+			}
+			line, err := string(l.line), l.err
+			l.line, l.err = l.line[:0], nil
+			return line, err
+		}
+	}
+}
+
+// add adds a character to the line, and returns the line if that ended it.
+func (l *LineReader) add(r rune, c []byte) (string, bool) {
+	sawCR := l.sawCR
+	l.sawCR = false
+	switch r {
+	case '\n':
+		if sawCR {
+			return "", false
+		}
+		fallthrough
+	case '\r':
+		l.sawCR = r == '\r'
+		line := string(l.line)
+		l.line = l.line[:0]
+		l.echo([]byte("\n"))
+		return line, true
+	case '\b', '\x7f':
+		l.erase(1)
+	default:
+		if l.MaxLength > 0 && utf8.RuneCount(l.line) >= l.MaxLength {
+			l.echo([]byte("\a"))
+			break
+		}
+		l.line = append(l.line, c...)
+		l.echo(c)
+	}
+	return "", false
+}
+
+// erase erases n characters from the end of the line, backing over them on the
+// peer's screen if we're echoing.
+func (l *LineReader) erase(n int) {
+	var erased int
+	for ; erased < n && len(l.line) > 0; erased++ {
+		_, size := utf8.DecodeLastRune(l.line)
+		l.line = l.line[:len(l.line)-size]
+	}
+	if erased > 0 {
+		var buf []byte
+		for i := 0; i < erased; i++ {
+			buf = append(buf, "\b \b"...)
+		}
+		l.echo(buf)
+	}
+}
+
+func (l *LineReader) echo(p []byte) {
+	if l.Echo && l.conn.Option(Echo).EnabledForUs() {
+		l.conn.Write(p)
+	}
+}
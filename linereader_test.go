@@ -0,0 +1,130 @@
+package telnet
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func readLines(l *LineReader) (lines []string, err error) {
+	for {
+		var line string
+		line, err = l.ReadLine()
+		if err != nil {
+			if line != "" {
+				lines = append(lines, line)
+			}
+			return
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestLineReader(t *testing.T) {
+	in := bytes.NewBufferString("one\r\ntwo\r\x00three\nfour")
+	l := NewLineReader(newTestConn(in, nil))
+	lines, err := readLines(l)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, []string{"one", "two", "three", "four"}, lines)
+}
+
+// dataErrReader returns all of its data along with err, the way a net.Conn
+// can, and then io.EOF.
+type dataErrReader struct {
+	data []byte
+	err  error
+}
+
+func (r *dataErrReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, r.err
+}
+
+func TestLineReaderErrorWithData(t *testing.T) {
+	errBoom := errors.New("boom")
+	r := &dataErrReader{[]byte("one\ntwo"), errBoom}
+	conn := NewMockConn(t)
+	conn.EXPECT().SplitAtEdits(true)
+	conn.EXPECT().AddListener("command", mock.Anything)
+	conn.EXPECT().Read(mock.Anything).RunAndReturn(r.Read)
+
+	l := NewLineReader(conn)
+	lines, err := readLines(l)
+	assert.ErrorIs(t, err, errBoom)
+	assert.Equal(t, []string{"one", "two"}, lines)
+}
+
+func TestLineReaderRawNewlines(t *testing.T) {
+	in := bytes.NewBufferString("one\r\ntwo\rthree\n")
+	conn := newTestConn(in, nil)
+	conn.SetReadNewline(NewlineRaw)
+	lines, err := readLines(NewLineReader(conn))
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, []string{"one", "two", "three"}, lines)
+}
+
+func TestLineReaderEditing(t *testing.T) {
+	in := bytes.NewBufferString("abc\x7fd")
+	in.Write([]byte{IAC, EC})
+	in.WriteString("e\r\nxyz")
+	in.Write([]byte{IAC, EL})
+	in.WriteString("caf\xc3\xa9\b\be\r\n")
+	conn := newTestConn(in, nil)
+	conn.SetEncoding(unicode.UTF8)
+	l := NewLineReader(conn)
+	lines, err := readLines(l)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, []string{"abe", "cae"}, lines)
+}
+
+func TestLineReaderMaxLength(t *testing.T) {
+	in := bytes.NewBufferString("abcdef\nab\bcd\n")
+	l := NewLineReader(newTestConn(in, nil))
+	l.MaxLength = 3
+	lines, err := readLines(l)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, []string{"abc", "acd"}, lines)
+}
+
+func TestLineReaderEcho(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, Echo})
+	in.WriteString("ab\x7fc")
+	in.Write([]byte{IAC, EL})
+	in.WriteString("ok\r\n")
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+	conn.SuppressGoAhead(true)
+	conn.Option(Echo).Allow(false, true)
+
+	l := NewLineReader(conn)
+	l.Echo = true
+	line, err := l.ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", line)
+
+	expected := []byte{IAC, WILL, Echo}
+	expected = append(expected, "ab\b \bc\b \b\b \bok\r\n"...)
+	assert.Equal(t, expected, out.Bytes())
+}
+
+func TestLineReaderClose(t *testing.T) {
+	in := bytes.NewBufferString("ab")
+	in.Write([]byte{IAC, EC})
+	conn := newTestConn(in, nil)
+	l := NewLineReader(conn)
+	assert.NoError(t, l.Close())
+	assert.False(t, conn.reader.splitEdits)
+	data, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, "ab", string(data))
+	assert.Empty(t, conn.listeners["command"])
+}
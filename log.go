@@ -0,0 +1,157 @@
+package telnet
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// The connection logs with log/slog. Negotiation is logged at debug level, as
+// "command" and "subnegotiation" records with these attributes:
+//
+//	direction  "send" or "recv"
+//	command    the command, e.g. "DO", or for a subnegotiation the option's
+//	           own command, e.g. "IS"
+//	option     the option's name, e.g. "ECHO"
+//	payload    the rest of the subnegotiation
+//
+// Protocol violations and failures are logged at warn level.
+const (
+	logSend = "send"
+	logRecv = "recv"
+)
+
+// Logger is the printf-style logger the connection logged to before it used
+// slog. Use SetLogger to log to one, or NewLoggerHandler to adapt one to slog.
+type Logger interface {
+	Logf(fmt string, v ...any)
+}
+
+type NullLogger struct{}
+
+func (NullLogger) Logf(string, ...any) {}
+
+// NewLoggerHandler returns a slog.Handler that logs each record to logger as a
+// line of text. Negotiation is written the way it appears on the wire, e.g.
+// "SEND: IAC DO ECHO"; anything else is the message followed by its
+// attributes.
+func NewLoggerHandler(logger Logger) slog.Handler {
+	return &loggerHandler{logger: logger}
+}
+
+type loggerHandler struct {
+	logger Logger
+	attrs  []slog.Attr
+	group  string
+}
+
+func (h *loggerHandler) Enabled(context.Context, slog.Level) bool {
+	_, null := h.logger.(NullLogger)
+	return !null
+}
+
+func (h *loggerHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := append([]slog.Attr(nil), h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, h.qualify(a))
+		return true
+	})
+	h.logger.Logf("%s", formatRecord(r.Message, attrs))
+	return nil
+}
+
+func (h *loggerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		h2.attrs = append(h2.attrs, h.qualify(a))
+	}
+	return &h2
+}
+
+func (h *loggerHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group = h.group + name + "."
+	return &h2
+}
+
+func (h *loggerHandler) qualify(a slog.Attr) slog.Attr {
+	a.Key = h.group + a.Key
+	return a
+}
+
+func formatRecord(msg string, attrs []slog.Attr) string {
+	values := map[string]string{}
+	for _, a := range attrs {
+		values[a.Key] = a.Value.String()
+	}
+	var b strings.Builder
+	switch dir := values["direction"]; {
+	case dir != "" && msg == "command":
+		fmt.Fprintf(&b, "%s: IAC %s", strings.ToUpper(dir), values["command"])
+		if opt, ok := values["option"]; ok {
+			fmt.Fprintf(&b, " %s", opt)
+		}
+	case dir != "" && msg == "subnegotiation":
+		fmt.Fprintf(&b, "%s: IAC SB %s", strings.ToUpper(dir), values["option"])
+		if cmd, ok := values["command"]; ok {
+			fmt.Fprintf(&b, " %s", cmd)
+		}
+		if payload, ok := values["payload"]; ok {
+			fmt.Fprintf(&b, " %q", payload)
+		}
+		b.WriteString(" IAC SE")
+	default:
+		b.WriteString(msg)
+		for _, a := range attrs {
+			fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+		}
+	}
+	return b.String()
+}
+
+// logCommand logs a simple command, such as GA.
+func logCommand(conn Conn, direction string, cmd byte) {
+	conn.LogAttrs(slog.LevelDebug, "command",
+		slog.String("direction", direction),
+		slog.String("command", commandByte(cmd).String()),
+	)
+}
+
+// logOptionCommand logs an option command, such as DO ECHO.
+func logOptionCommand(conn Conn, direction string, cmd, opt byte) {
+	conn.LogAttrs(slog.LevelDebug, "command",
+		slog.String("direction", direction),
+		slog.String("command", commandByte(cmd).String()),
+		slog.String("option", optionByte(opt).String()),
+	)
+}
+
+// logSubnegotiation logs a subnegotiation. Its command may be nil, for options
+// that don't have them or when there's nothing to the subnegotiation at all.
+func logSubnegotiation(conn Conn, direction string, opt byte, cmd fmt.Stringer, payload []byte) {
+	attrs := []slog.Attr{
+		slog.String("direction", direction),
+		slog.String("option", optionByte(opt).String()),
+	}
+	if cmd != nil {
+		attrs = append(attrs, slog.String("command", cmd.String()))
+	}
+	if payload != nil {
+		attrs = append(attrs, slog.String("payload", string(payload)))
+	}
+	conn.LogAttrs(slog.LevelDebug, "subnegotiation", attrs...)
+}
+
+// logFailure logs something that went wrong with an option.
+func logFailure(conn Conn, opt byte, msg string, err error, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{slog.String("option", optionByte(opt).String())}, attrs...)
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	conn.LogAttrs(slog.LevelWarn, msg, attrs...)
+}
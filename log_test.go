@@ -0,0 +1,94 @@
+package telnet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func expectOptionCommandLog(conn *MockConn, direction string, cmd, opt byte) {
+	conn.EXPECT().LogAttrs(slog.LevelDebug, "command", []slog.Attr{
+		slog.String("direction", direction),
+		slog.String("command", commandByte(cmd).String()),
+		slog.String("option", optionByte(opt).String()),
+	})
+}
+
+func expectSubnegotiationLog(conn *MockConn, direction string, opt byte, cmd fmt.Stringer, payload []byte) {
+	attrs := []slog.Attr{
+		slog.String("direction", direction),
+		slog.String("option", optionByte(opt).String()),
+	}
+	if cmd != nil {
+		attrs = append(attrs, slog.String("command", cmd.String()))
+	}
+	if payload != nil {
+		attrs = append(attrs, slog.String("payload", string(payload)))
+	}
+	conn.EXPECT().LogAttrs(slog.LevelDebug, "subnegotiation", attrs)
+}
+
+type lines []string
+
+func (l *lines) Logf(format string, v ...any) {
+	*l = append(*l, fmt.Sprintf(format, v...))
+}
+
+func TestLoggerHandler(t *testing.T) {
+	var l lines
+	logger := slog.New(NewLoggerHandler(&l))
+	logger.Debug("command", "direction", "send", "command", "DO", "option", "ECHO")
+	logger.Debug("command", "direction", "recv", "command", "GA")
+	logger.Debug("subnegotiation", "direction", "recv", "option", "TERMINAL-TYPE", "command", "IS", "payload", "XTERM")
+	logger.Debug("subnegotiation", "direction", "send", "option", "NAWS", "payload", "\x00P\x00\x18")
+	logger.Debug("subnegotiation", "direction", "recv", "option", "CHARSET")
+	logger.With("option", "AUTHENTICATION").WithGroup("auth").Warn("authentication failed", "error", errors.New("oops"))
+	assert.Equal(t, lines{
+		"SEND: IAC DO ECHO",
+		"RECV: IAC GA",
+		`RECV: IAC SB TERMINAL-TYPE IS "XTERM" IAC SE`,
+		`SEND: IAC SB NAWS "\x00P\x00\x18" IAC SE`,
+		"RECV: IAC SB CHARSET IAC SE",
+		"authentication failed option=AUTHENTICATION auth.error=oops",
+	}, l)
+
+	assert.False(t, NewLoggerHandler(NullLogger{}).Enabled(context.Background(), slog.LevelWarn))
+}
+
+func TestSlogLogger(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, Echo, IAC, SB, NAWS, 'h', 'i', IAC, SE})
+	conn := newTestConn(in, nil)
+	conn.Conn = &addrConn{conn.Conn}
+
+	var out bytes.Buffer
+	conn.SetSlogLogger(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})))
+	conn.SetLimits(Limits{MaxSubnegotiation: 1})
+	conn.Read(make([]byte, 8))
+
+	assert.Equal(t, ""+
+		"level=DEBUG msg=command local_addr=127.0.0.1:23 remote_addr=127.0.0.1:1234 direction=recv command=DO option=ECHO\n"+
+		"level=DEBUG msg=command local_addr=127.0.0.1:23 remote_addr=127.0.0.1:1234 direction=send command=WONT option=ECHO\n"+
+		"level=WARN msg=\"protocol violation\" local_addr=127.0.0.1:23 remote_addr=127.0.0.1:1234 violation=\"subnegotiation too long\" option=NAWS limit=1 policy=drop\n",
+		out.String())
+}
+
+type addrConn struct {
+	net.Conn
+}
+
+func (addrConn) LocalAddr() net.Addr  { return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 23} }
+func (addrConn) RemoteAddr() net.Addr { return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234} }
@@ -0,0 +1,156 @@
+package telnet
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// LogoutOption implements RFC 727. A client asks to be logged out with DO
+// LOGOUT and a server announces that it is logging the client out with WILL
+// LOGOUT. Either way a "logout" event is sent once both sides agree, and a
+// server closes the connection when a client asks to be logged out.
+type LogoutOption struct {
+	Option
+	isServer bool
+
+	mu     sync.Mutex
+	answer chan bool
+}
+
+func NewLogoutOption(isServer bool) *LogoutOption {
+	return &LogoutOption{Option: NewOption(Logout), isServer: isServer}
+}
+
+func (l *LogoutOption) Bind(conn Conn, sink EventSink) {
+	l.Option.Bind(conn, sink)
+	conn.AddListener("update-option", l)
+}
+
+func (l *LogoutOption) Subnegotiation([]byte) {}
+
+func (l *LogoutOption) HandleEvent(data any) {
+	event, ok := data.(UpdateOptionEvent)
+	if !ok || event.Option.Byte() != Logout {
+		return
+	}
+
+	l.mu.Lock()
+	waiting := l.answer != nil
+	l.mu.Unlock()
+	if waiting {
+		// Logout will send the event itself.
+		return
+	}
+
+	if l.isServer && event.WeChanged && event.EnabledForUs() {
+		l.Sink().SendEvent("logout", LogoutEvent{Forced: false})
+		l.Conn().Close()
+	} else if !l.isServer && event.TheyChanged && event.EnabledForThem() {
+		l.Sink().SendEvent("logout", LogoutEvent{Forced: true})
+	}
+}
+
+// Logout starts the logout negotiation and waits for the peer to answer,
+// returning whether it agreed. It does not close the connection, which is left
+// to Conn.Shutdown. As with Ping, something else must be reading from the
+// connection while Logout waits.
+func (l *LogoutOption) Logout(ctx context.Context) (bool, error) {
+	if l.agreed() {
+		return true, nil
+	}
+
+	answer := make(chan bool, 1)
+	l.mu.Lock()
+	l.answer = answer
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.answer = nil
+		l.mu.Unlock()
+	}()
+
+	var err error
+	if l.isServer {
+		err = l.enableUs()
+	} else {
+		err = l.enableThem()
+	}
+	if err != nil {
+		return false, err
+	}
+
+	select {
+	case ok := <-answer:
+		if ok {
+			l.Sink().SendEvent("logout", LogoutEvent{Forced: l.isServer})
+		}
+		return ok, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+func (l *LogoutOption) agreed() bool {
+	if l.isServer {
+		return l.EnabledForUs()
+	}
+	return l.EnabledForThem()
+}
+
+func (l *LogoutOption) receive(c byte) error {
+	err := l.Option.receive(c)
+
+	var answered bool
+	if l.isServer {
+		answered = c == DO || c == DONT
+	} else {
+		answered = c == WILL || c == WONT
+	}
+	if answered {
+		l.mu.Lock()
+		if l.answer != nil {
+			l.answer <- l.agreed()
+			l.answer = nil
+		}
+		l.mu.Unlock()
+	}
+	return err
+}
+
+type logouter interface {
+	Logout(context.Context) (bool, error)
+}
+
+// Shutdown ends the session gracefully. It flushes any output still held by
+// the write encoding, negotiates LOGOUT if a LogoutOption is bound (waiting
+// until ctx is done for the peer to answer) and then closes the connection,
+// sending a "shutdown" event once it has done so. The connection is closed
+// even if the negotiation fails, in which case the error is returned.
+func (c *connection) Shutdown(ctx context.Context) (err error) {
+	if f, ok := c.out.(io.Closer); ok {
+		err = f.Close()
+	}
+
+	var loggedOut bool
+	if l, ok := c.Option(Logout).(logouter); ok && err == nil {
+		loggedOut, err = l.Logout(ctx)
+	}
+
+	if cerr := c.Close(); err == nil {
+		err = cerr
+	}
+	c.SendEvent("shutdown", ShutdownEvent{LoggedOut: loggedOut, Err: err})
+	return
+}
+
+// LogoutEvent is sent with "logout". Forced is true when it was the server that
+// logged the client out, rather than the client asking to be logged out.
+type LogoutEvent struct {
+	Forced bool
+}
+
+type ShutdownEvent struct {
+	LoggedOut bool
+	Err       error
+}
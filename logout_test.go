@@ -0,0 +1,106 @@
+package telnet
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogoutRequestedByClient(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, Logout})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewLogoutOption(true)
+	option.Allow(false, true)
+	conn.BindOption(option)
+
+	var events []any
+	conn.AddListener("logout", FuncListener{func(data any) { events = append(events, data) }})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{LogoutEvent{Forced: false}}, events)
+	assert.Equal(t, []byte{IAC, WILL, Logout}, out.Bytes())
+}
+
+func TestLogoutForcedByServer(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, Logout})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewLogoutOption(false)
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	var events []any
+	conn.AddListener("logout", FuncListener{func(data any) { events = append(events, data) }})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{LogoutEvent{Forced: true}}, events)
+	assert.Equal(t, []byte{IAC, DO, Logout}, out.Bytes())
+}
+
+func TestShutdown(t *testing.T) {
+	for _, tc := range []struct {
+		isServer       bool
+		request, reply []byte
+		loggedOut      bool
+	}{
+		{true, []byte{IAC, WILL, Logout}, []byte{IAC, DO, Logout}, true},
+		{true, []byte{IAC, WILL, Logout}, []byte{IAC, DONT, Logout}, false},
+		{false, []byte{IAC, DO, Logout}, []byte{IAC, WILL, Logout}, true},
+		{false, []byte{IAC, DO, Logout}, []byte{IAC, WONT, Logout}, false},
+	} {
+		local, remote := net.Pipe()
+		conn := New(local)
+		conn.BindOption(NewLogoutOption(tc.isServer))
+		go io.Copy(io.Discard, conn)
+
+		go func() {
+			buf := make([]byte, 3)
+			_, err := io.ReadFull(remote, buf)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.request, buf)
+			remote.Write(tc.reply)
+		}()
+
+		var logouts, shutdowns []any
+		conn.AddListener("logout", FuncListener{func(data any) { logouts = append(logouts, data) }})
+		conn.AddListener("shutdown", FuncListener{func(data any) { shutdowns = append(shutdowns, data) }})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		assert.NoError(t, conn.Shutdown(ctx))
+		cancel()
+
+		assert.Equal(t, []any{ShutdownEvent{LoggedOut: tc.loggedOut}}, shutdowns)
+		if tc.loggedOut {
+			assert.Equal(t, []any{LogoutEvent{Forced: tc.isServer}}, logouts)
+		} else {
+			assert.Empty(t, logouts)
+		}
+		_, err := remote.Read(make([]byte, 1))
+		assert.ErrorIs(t, err, io.EOF)
+		remote.Close()
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+	conn := New(local)
+	conn.BindOption(NewLogoutOption(true))
+	go io.Copy(io.Discard, remote)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, conn.Shutdown(ctx), context.DeadlineExceeded)
+	_, err := conn.Write([]byte("x"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,427 @@
+package telnet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ColorMode is how much of the markup a client can be shown.
+type ColorMode int
+
+const (
+	// ColorStrip leaves out the markup, and any escape sequences written
+	// directly, for clients using screen readers.
+	ColorStrip ColorMode = iota
+	// ColorNone leaves out the markup.
+	ColorNone
+	// Color16 renders colors as the nearest of the 16 ANSI colors.
+	Color16
+	// Color256 renders colors as the nearest of the xterm 256 colors.
+	Color256
+	// ColorTrue renders colors exactly, with 24-bit color.
+	ColorTrue
+)
+
+func (m ColorMode) String() string {
+	switch m {
+	case ColorStrip:
+		return "strip"
+	case ColorNone:
+		return "none"
+	case Color16:
+		return "16"
+	case Color256:
+		return "256"
+	case ColorTrue:
+		return "truecolor"
+	}
+	return fmt.Sprintf("ColorMode(%d)", int(m))
+}
+
+// Render renders markup for a client in the given mode. The markup is text
+// with tags in braces:
+//
+//   - a color by name, as in {red} or {bright-red}: black, red, green, yellow,
+//     blue, magenta, cyan and white, and gray for bright-black
+//   - an RGB color, as in {#ff8800} or {#f80}
+//   - a background color, as in {bg:blue} or {bg:#000080}
+//   - an attribute: {bold}, {dim}, {italic}, {underline}, {blink} or {reverse}
+//   - {reset} or {/}, to go back to plain text
+//
+// {{ is a literal brace. Anything else in braces is left as it is.
+func Render(markup string, mode ColorMode) string {
+	var b strings.Builder
+	for len(markup) > 0 {
+		i := strings.IndexByte(markup, '{')
+		if i < 0 {
+			writeText(&b, markup, mode)
+			break
+		}
+		writeText(&b, markup[:i], mode)
+		markup = markup[i:]
+		if strings.HasPrefix(markup, "{{") {
+			b.WriteByte('{')
+			markup = markup[2:]
+			continue
+		}
+		var (
+			tag markupTag
+			ok  bool
+		)
+		end := strings.IndexByte(markup, '}')
+		if end > 0 {
+			tag, ok = parseMarkupTag(markup[1:end])
+		}
+		if !ok {
+			b.WriteByte('{')
+			markup = markup[1:]
+			continue
+		}
+		if mode > ColorNone {
+			b.WriteString("\x1b[" + tag.sgr(mode) + "m")
+		}
+		markup = markup[end+1:]
+	}
+	return b.String()
+}
+
+func writeText(b *strings.Builder, s string, mode ColorMode) {
+	if mode == ColorStrip {
+		s = stripEscapes(s)
+	}
+	b.WriteString(s)
+}
+
+// stripEscapes removes ANSI escape sequences: CSI sequences, OSC and other
+// strings ending with BEL or ST, and the rest, which end with their first
+// byte that isn't an intermediate.
+func stripEscapes(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != 0x1b {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			break
+		}
+		switch s[i] {
+		case '[':
+			for i++; i < len(s) && (s[i] < 0x40 || s[i] > 0x7e); i++ {
+			}
+		case ']', 'P', '_', '^':
+			for i++; i < len(s); i++ {
+				if s[i] == '\a' {
+					break
+				}
+				if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+					i++
+					break
+				}
+			}
+		default:
+			// Skip any intermediate bytes, as in ESC ( B.
+			for ; i+1 < len(s) && s[i] >= 0x20 && s[i] <= 0x2f; i++ {
+			}
+		}
+	}
+	return b.String()
+}
+
+type markupTag struct {
+	sgr func(mode ColorMode) string
+}
+
+var markupColors = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+var markupAttributes = map[string]string{
+	"reset":     "0",
+	"/":         "0",
+	"bold":      "1",
+	"dim":       "2",
+	"italic":    "3",
+	"underline": "4",
+	"blink":     "5",
+	"reverse":   "7",
+}
+
+func parseMarkupTag(tag string) (markupTag, bool) {
+	if code, ok := markupAttributes[tag]; ok {
+		return markupTag{func(ColorMode) string { return code }}, true
+	}
+	name, bg := strings.CutPrefix(tag, "bg:")
+	if strings.HasPrefix(name, "#") {
+		r, g, b, ok := parseHexColor(name[1:])
+		if !ok {
+			return markupTag{}, false
+		}
+		return markupTag{func(mode ColorMode) string { return rgbSGR(r, g, b, bg, mode) }}, true
+	}
+	index, ok := namedColor(name)
+	if !ok {
+		return markupTag{}, false
+	}
+	return markupTag{func(ColorMode) string { return indexedSGR(index, bg) }}, true
+}
+
+func namedColor(name string) (int, bool) {
+	if name == "gray" || name == "grey" {
+		return 8, true
+	}
+	base, bright := strings.CutPrefix(name, "bright-")
+	for i, color := range markupColors {
+		if color == base {
+			if bright {
+				i += 8
+			}
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func parseHexColor(s string) (r, g, b uint8, ok bool) {
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return 0, 0, 0, false
+	}
+	n, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(n >> 16), uint8(n >> 8), uint8(n), true
+}
+
+// indexedSGR returns the SGR parameters for one of the 16 ANSI colors.
+func indexedSGR(index int, bg bool) string {
+	base := 30
+	if index >= 8 {
+		base, index = 90, index-8
+	}
+	if bg {
+		base += 10
+	}
+	return strconv.Itoa(base + index)
+}
+
+func rgbSGR(r, g, b uint8, bg bool, mode ColorMode) string {
+	prefix := "38"
+	if bg {
+		prefix = "48"
+	}
+	switch mode {
+	case ColorTrue:
+		return fmt.Sprintf("%s;2;%d;%d;%d", prefix, r, g, b)
+	case Color256:
+		return fmt.Sprintf("%s;5;%d", prefix, nearest256(r, g, b))
+	}
+	return indexedSGR(nearest16(r, g, b), bg)
+}
+
+// ansiPalette is xterm's default palette for the 16 ANSI colors.
+var ansiPalette = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+func nearest16(r, g, b uint8) int {
+	best, bestDistance := 0, -1
+	for i, c := range ansiPalette {
+		if d := colorDistance(r, g, b, c[0], c[1], c[2]); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return best
+}
+
+// cubeLevels are the levels of each component in the xterm 6x6x6 color cube.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+func cubeIndex(v uint8) int {
+	switch {
+	case v < 48:
+		return 0
+	case v < 115:
+		return 1
+	}
+	return (int(v) - 35) / 40
+}
+
+// nearest256 returns the nearest of the colors in the cube or the gray ramp,
+// leaving out the 16 ANSI colors since clients often change those.
+func nearest256(r, g, b uint8) int {
+	ri, gi, bi := cubeIndex(r), cubeIndex(g), cubeIndex(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDistance := colorDistance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	average := (int(r) + int(g) + int(b)) / 3
+	grayIndex := min(max((average-8+5)/10, 0), 23)
+	level := uint8(8 + 10*grayIndex)
+	if colorDistance(r, g, b, level, level, level) < cubeDistance {
+		return 232 + grayIndex
+	}
+	return cube
+}
+
+func colorDistance(r1, g1, b1, r2, g2, b2 uint8) int {
+	dr, dg, db := int(r1)-int(r2), int(g1)-int(g2), int(b1)-int(b2)
+	return dr*dr + dg*dg + db*db
+}
+
+// MarkupWriter renders markup (see Render) as it's written to a Conn, in the
+// color mode the client's capabilities allow. It works them out from the
+// TERMINAL-TYPE option, including MTTS (which needs CycleTypes), and from the
+// TERM, COLORTERM, NO_COLOR and MTTS variables of the NEW-ENVIRON option, and
+// keeps them up to date as the client reports them. Until the client has
+// reported anything, the mode is ColorNone.
+//
+// Each write is rendered on its own, so a tag can't be split across writes.
+type MarkupWriter struct {
+	conn  Conn
+	mu    sync.Mutex
+	mode  ColorMode
+	fixed bool
+}
+
+func NewMarkupWriter(conn Conn) *MarkupWriter {
+	w := &MarkupWriter{conn: conn}
+	w.detect()
+	conn.AddListener("terminal-type", w)
+	conn.AddListener("environ", w)
+	return w
+}
+
+func (w *MarkupWriter) Close() error {
+	w.conn.RemoveListener("terminal-type", w)
+	w.conn.RemoveListener("environ", w)
+	return nil
+}
+
+func (w *MarkupWriter) Mode() ColorMode {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.mode
+}
+
+// SetMode sets the color mode, e.g. because the user has asked for it, and
+// stops the writer from changing it when the client reports its capabilities.
+func (w *MarkupWriter) SetMode(mode ColorMode) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.mode, w.fixed = mode, true
+}
+
+func (w *MarkupWriter) HandleEvent(data any) {
+	switch data.(type) {
+	case TerminalTypeEvent, EnvironEvent:
+		w.detect()
+	}
+}
+
+func (w *MarkupWriter) Write(p []byte) (int, error) {
+	if _, err := w.conn.Write([]byte(Render(string(p), w.Mode()))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *MarkupWriter) Printf(format string, args ...any) (int, error) {
+	return w.Write([]byte(fmt.Sprintf(format, args...)))
+}
+
+func (w *MarkupWriter) detect() {
+	var (
+		types []string
+		env   map[string]string
+	)
+	if ttype, ok := w.conn.Option(TerminalType).(*TerminalTypeOption); ok {
+		types = ttype.TerminalTypes()
+	}
+	if environ, ok := w.conn.Option(NewEnviron).(*NewEnvironOption); ok {
+		env = environ.Environ()
+	}
+	mode := detectColorMode(types, env)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.fixed {
+		w.mode = mode
+	}
+}
+
+// colorTerminals are the prefixes of terminal types (and client names) that
+// can show the 16 ANSI colors.
+var colorTerminals = []string{
+	"ANSI", "XTERM", "LINUX", "SCREEN", "TMUX", "RXVT", "PUTTY", "CYGWIN",
+	"KONSOLE", "MUDLET", "MUSHCLIENT", "TINTIN", "CMUD", "ZMUD",
+}
+
+func detectColorMode(types []string, env map[string]string) ColorMode {
+	mode := ColorNone
+	var screenReader bool
+	fromMTTS := func(flags MTTSFlags) {
+		switch {
+		case flags&MTTSTrueColor != 0:
+			mode = max(mode, ColorTrue)
+		case flags&MTTS256Colors != 0:
+			mode = max(mode, Color256)
+		case flags&MTTSANSI != 0:
+			mode = max(mode, Color16)
+		}
+		screenReader = screenReader || flags&MTTSScreenReader != 0
+	}
+	fromType := func(name string) {
+		name = strings.ToUpper(name)
+		if s, ok := strings.CutPrefix(name, "MTTS "); ok {
+			if flags, ok := parseMTTS(s); ok {
+				fromMTTS(flags)
+			}
+			return
+		}
+		switch {
+		case strings.Contains(name, "TRUECOLOR"), strings.Contains(name, "24BIT"), strings.Contains(name, "DIRECT"):
+			mode = max(mode, ColorTrue)
+		case strings.Contains(name, "256COLOR"):
+			mode = max(mode, Color256)
+		default:
+			for _, prefix := range colorTerminals {
+				if strings.HasPrefix(name, prefix) {
+					mode = max(mode, Color16)
+				}
+			}
+		}
+	}
+
+	for _, name := range types {
+		fromType(name)
+	}
+	if term, ok := env["TERM"]; ok {
+		fromType(term)
+	}
+	if s, ok := env["MTTS"]; ok {
+		if flags, ok := parseMTTS(s); ok {
+			fromMTTS(flags)
+		}
+	}
+	switch strings.ToLower(env["COLORTERM"]) {
+	case "truecolor", "24bit":
+		mode = max(mode, ColorTrue)
+	}
+	if _, ok := env["NO_COLOR"]; ok {
+		mode = ColorNone
+	}
+	if screenReader {
+		mode = ColorStrip
+	}
+	return mode
+}
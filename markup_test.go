@@ -0,0 +1,152 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	const markup = "{red}a{bg:bright-blue}b{#ff8800}c{bg:#00f}{bold}d{/}e {{x} {nope} {"
+	var tests = []struct {
+		mode     ColorMode
+		expected string
+	}{
+		{ColorTrue, "\x1b[31ma\x1b[104mb\x1b[38;2;255;136;0mc\x1b[48;2;0;0;255m\x1b[1md\x1b[0me {x} {nope} {"},
+		{Color256, "\x1b[31ma\x1b[104mb\x1b[38;5;208mc\x1b[48;5;21m\x1b[1md\x1b[0me {x} {nope} {"},
+		{Color16, "\x1b[31ma\x1b[104mb\x1b[33mc\x1b[44m\x1b[1md\x1b[0me {x} {nope} {"},
+		{ColorNone, "abcde {x} {nope} {"},
+		{ColorStrip, "abcde {x} {nope} {"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Render(markup, test.mode), test.mode.String())
+	}
+}
+
+func TestRenderStripsEscapes(t *testing.T) {
+	const text = "\x1b[1;31ma\x1b]0;title\x07b\x1b]2;x\x1b\\c\x1b(Bd\x1b7e{green}f"
+	assert.Equal(t, "abcdef", Render(text, ColorStrip))
+	assert.Equal(t, "\x1b[1;31ma\x1b]0;title\x07b\x1b]2;x\x1b\\c\x1b(Bd\x1b7ef", Render(text, ColorNone))
+}
+
+func TestNearest256(t *testing.T) {
+	assert.Equal(t, 16, nearest256(0, 0, 0))
+	assert.Equal(t, 231, nearest256(255, 255, 255))
+	assert.Equal(t, 196, nearest256(255, 0, 0))
+	assert.Equal(t, 244, nearest256(128, 128, 128))
+	assert.Equal(t, 232, nearest256(8, 8, 8))
+}
+
+func TestDetectColorMode(t *testing.T) {
+	var tests = []struct {
+		types    []string
+		env      map[string]string
+		expected ColorMode
+	}{
+		{nil, nil, ColorNone},
+		{[]string{"DUMB"}, nil, ColorNone},
+		{[]string{"VT100"}, nil, ColorNone},
+		{[]string{"ANSI"}, nil, Color16},
+		{[]string{"XTERM"}, nil, Color16},
+		{[]string{"xterm-256color"}, nil, Color256},
+		{[]string{"XTERM-DIRECT"}, nil, ColorTrue},
+		{[]string{"MUDLET", "XTERM", "MTTS 137"}, nil, Color256},
+		{[]string{"MUDLET", "XTERM", "MTTS 269"}, nil, ColorTrue},
+		{[]string{"MUDLET", "XTERM", "MTTS 1"}, nil, Color16},
+		{[]string{"XTERM-256COLOR", "MTTS 64"}, nil, ColorStrip},
+		{[]string{"XTERM"}, map[string]string{"COLORTERM": "truecolor"}, ColorTrue},
+		{nil, map[string]string{"TERM": "screen-256color"}, Color256},
+		{nil, map[string]string{"MTTS": "9"}, Color256},
+		{[]string{"XTERM-256COLOR"}, map[string]string{"NO_COLOR": "1"}, ColorNone},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, detectColorMode(test.types, test.env), "%v %v", test.types, test.env)
+	}
+}
+
+func TestMarkupWriter(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, TerminalType, IAC, WILL, NewEnviron})
+	in.Write([]byte{IAC, SB, TerminalType, optionIs})
+	in.WriteString("XTERM")
+	in.Write([]byte{IAC, SE})
+	in.Write([]byte{IAC, SB, NewEnviron, environIs, environUservar})
+	in.WriteString("COLORTERM")
+	in.WriteByte(environValue)
+	in.WriteString("truecolor")
+	in.Write([]byte{IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+	conn.SuppressGoAhead(true)
+
+	ttype := NewTerminalTypeOption()
+	ttype.Allow(true, false)
+	conn.BindOption(ttype)
+	environ := NewNewEnvironOption(nil)
+	environ.Allow(true, false)
+	conn.BindOption(environ)
+
+	w := NewMarkupWriter(conn)
+	defer w.Close()
+	assert.Equal(t, ColorNone, w.Mode())
+
+	var modes []ColorMode
+	listener := FuncListener{func(any) { modes = append(modes, w.Mode()) }}
+	conn.AddListener("terminal-type", listener)
+	conn.AddListener("environ", listener)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []ColorMode{Color16, ColorTrue}, modes)
+
+	out.Reset()
+	n, err := w.Printf("{#%06x}hi{/}", 0x102030)
+	assert.NoError(t, err)
+	assert.Equal(t, 14, n)
+	assert.Equal(t, "\x1b[38;2;16;32;48mhi\x1b[0m", out.String())
+
+	w.SetMode(ColorNone)
+	w.HandleEvent(TerminalTypeEvent{"XTERM-256COLOR"})
+	assert.Equal(t, ColorNone, w.Mode())
+
+	out.Reset()
+	w.Write([]byte("{red}hi{/}"))
+	assert.Equal(t, "hi", out.String())
+}
+
+func TestNewMarkupWriterWhileReading(t *testing.T) {
+	r, w := io.Pipe()
+	conn := newTestConn(r, io.Discard)
+	conn.SuppressGoAhead(true)
+
+	ttype := NewTerminalTypeOption()
+	ttype.Allow(true, false)
+	conn.BindOption(ttype)
+	environ := NewNewEnvironOption(nil)
+	environ.Allow(true, false)
+	conn.BindOption(environ)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(io.Discard, conn)
+	}()
+
+	w.Write([]byte{IAC, WILL, TerminalType, IAC, WILL, NewEnviron})
+	go func() {
+		for i := 0; i < 100; i++ {
+			w.Write([]byte{IAC, SB, TerminalType, optionIs, 'X', 'T', 'E', 'R', 'M', IAC, SE})
+			w.Write([]byte{IAC, SB, NewEnviron, environInfo, environVar, 'T', 'E', 'R', 'M', environValue, 'x', IAC, SE})
+		}
+		w.Close()
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			NewMarkupWriter(conn).Close()
+		}
+	}
+}
@@ -5,6 +5,9 @@
 package telnet
 
 import (
+	"context"
+	"crypto/cipher"
+	"log/slog"
 	"net"
 	"time"
 
@@ -12,13 +15,13 @@ import (
 	"golang.org/x/text/encoding"
 )
 
-// NewMockConn creates a new instance of MockConn. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// NewMockAuthenticator creates a new instance of MockAuthenticator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
-func NewMockConn(t interface {
+func NewMockAuthenticator(t interface {
 	mock.TestingT
 	Cleanup(func())
-}) *MockConn {
-	mock := &MockConn{}
+}) *MockAuthenticator {
+	mock := &MockAuthenticator{}
 	mock.Mock.Test(t)
 
 	t.Cleanup(func() { mock.AssertExpectations(t) })
@@ -26,948 +29,2790 @@ func NewMockConn(t interface {
 	return mock
 }
 
-// MockConn is an autogenerated mock type for the Conn type
-type MockConn struct {
+// MockAuthenticator is an autogenerated mock type for the Authenticator type
+type MockAuthenticator struct {
 	mock.Mock
 }
 
-type MockConn_Expecter struct {
+type MockAuthenticator_Expecter struct {
 	mock *mock.Mock
 }
 
-func (_m *MockConn) EXPECT() *MockConn_Expecter {
-	return &MockConn_Expecter{mock: &_m.Mock}
+func (_m *MockAuthenticator) EXPECT() *MockAuthenticator_Expecter {
+	return &MockAuthenticator_Expecter{mock: &_m.Mock}
 }
 
-// AddListener provides a mock function for the type MockConn
-func (_mock *MockConn) AddListener(s string, eventListener EventListener) {
-	_mock.Called(s, eventListener)
-	return
+// Is provides a mock function for the type MockAuthenticator
+func (_mock *MockAuthenticator) Is(name string, data []byte) ([]byte, AuthStatus, error) {
+	ret := _mock.Called(name, data)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Is")
+	}
+
+	var r0 []byte
+	var r1 AuthStatus
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(string, []byte) ([]byte, AuthStatus, error)); ok {
+		return returnFunc(name, data)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, []byte) []byte); ok {
+		r0 = returnFunc(name, data)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, []byte) AuthStatus); ok {
+		r1 = returnFunc(name, data)
+	} else {
+		r1 = ret.Get(1).(AuthStatus)
+	}
+	if returnFunc, ok := ret.Get(2).(func(string, []byte) error); ok {
+		r2 = returnFunc(name, data)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
 }
 
-// MockConn_AddListener_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddListener'
-type MockConn_AddListener_Call struct {
+// MockAuthenticator_Is_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Is'
+type MockAuthenticator_Is_Call struct {
 	*mock.Call
 }
 
-// AddListener is a helper method to define mock.On call
-//   - s
-//   - eventListener
-func (_e *MockConn_Expecter) AddListener(s interface{}, eventListener interface{}) *MockConn_AddListener_Call {
-	return &MockConn_AddListener_Call{Call: _e.mock.On("AddListener", s, eventListener)}
+// Is is a helper method to define mock.On call
+//   - name
+//   - data
+func (_e *MockAuthenticator_Expecter) Is(name interface{}, data interface{}) *MockAuthenticator_Is_Call {
+	return &MockAuthenticator_Is_Call{Call: _e.mock.On("Is", name, data)}
 }
 
-func (_c *MockConn_AddListener_Call) Run(run func(s string, eventListener EventListener)) *MockConn_AddListener_Call {
+func (_c *MockAuthenticator_Is_Call) Run(run func(name string, data []byte)) *MockAuthenticator_Is_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(EventListener))
+		run(args[0].(string), args[1].([]byte))
 	})
 	return _c
 }
 
-func (_c *MockConn_AddListener_Call) Return() *MockConn_AddListener_Call {
-	_c.Call.Return()
+func (_c *MockAuthenticator_Is_Call) Return(reply []byte, status AuthStatus, err error) *MockAuthenticator_Is_Call {
+	_c.Call.Return(reply, status, err)
 	return _c
 }
 
-func (_c *MockConn_AddListener_Call) RunAndReturn(run func(s string, eventListener EventListener)) *MockConn_AddListener_Call {
-	_c.Run(run)
+func (_c *MockAuthenticator_Is_Call) RunAndReturn(run func(name string, data []byte) ([]byte, AuthStatus, error)) *MockAuthenticator_Is_Call {
+	_c.Call.Return(run)
 	return _c
 }
 
-// BindOption provides a mock function for the type MockConn
-func (_mock *MockConn) BindOption(o Option) {
-	_mock.Called(o)
-	return
+// Principal provides a mock function for the type MockAuthenticator
+func (_mock *MockAuthenticator) Principal() string {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Principal")
+	}
+
+	var r0 string
+	if returnFunc, ok := ret.Get(0).(func() string); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	return r0
 }
 
-// MockConn_BindOption_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BindOption'
-type MockConn_BindOption_Call struct {
+// MockAuthenticator_Principal_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Principal'
+type MockAuthenticator_Principal_Call struct {
 	*mock.Call
 }
 
-// BindOption is a helper method to define mock.On call
-//   - o
-func (_e *MockConn_Expecter) BindOption(o interface{}) *MockConn_BindOption_Call {
-	return &MockConn_BindOption_Call{Call: _e.mock.On("BindOption", o)}
+// Principal is a helper method to define mock.On call
+func (_e *MockAuthenticator_Expecter) Principal() *MockAuthenticator_Principal_Call {
+	return &MockAuthenticator_Principal_Call{Call: _e.mock.On("Principal")}
 }
 
-func (_c *MockConn_BindOption_Call) Run(run func(o Option)) *MockConn_BindOption_Call {
+func (_c *MockAuthenticator_Principal_Call) Run(run func()) *MockAuthenticator_Principal_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(Option))
+		run()
 	})
 	return _c
 }
 
-func (_c *MockConn_BindOption_Call) Return() *MockConn_BindOption_Call {
-	_c.Call.Return()
+func (_c *MockAuthenticator_Principal_Call) Return(s string) *MockAuthenticator_Principal_Call {
+	_c.Call.Return(s)
 	return _c
 }
 
-func (_c *MockConn_BindOption_Call) RunAndReturn(run func(o Option)) *MockConn_BindOption_Call {
-	_c.Run(run)
+func (_c *MockAuthenticator_Principal_Call) RunAndReturn(run func() string) *MockAuthenticator_Principal_Call {
+	_c.Call.Return(run)
 	return _c
 }
 
-// Close provides a mock function for the type MockConn
-func (_mock *MockConn) Close() error {
-	ret := _mock.Called()
+// Reply provides a mock function for the type MockAuthenticator
+func (_mock *MockAuthenticator) Reply(data []byte) ([]byte, AuthStatus, error) {
+	ret := _mock.Called(data)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Close")
+		panic("no return value specified for Reply")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func() error); ok {
-		r0 = returnFunc()
+	var r0 []byte
+	var r1 AuthStatus
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func([]byte) ([]byte, AuthStatus, error)); ok {
+		return returnFunc(data)
+	}
+	if returnFunc, ok := ret.Get(0).(func([]byte) []byte); ok {
+		r0 = returnFunc(data)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func([]byte) AuthStatus); ok {
+		r1 = returnFunc(data)
+	} else {
+		r1 = ret.Get(1).(AuthStatus)
+	}
+	if returnFunc, ok := ret.Get(2).(func([]byte) error); ok {
+		r2 = returnFunc(data)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
 }
 
-// MockConn_Close_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Close'
-type MockConn_Close_Call struct {
+// MockAuthenticator_Reply_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Reply'
+type MockAuthenticator_Reply_Call struct {
 	*mock.Call
 }
 
-// Close is a helper method to define mock.On call
-func (_e *MockConn_Expecter) Close() *MockConn_Close_Call {
-	return &MockConn_Close_Call{Call: _e.mock.On("Close")}
+// Reply is a helper method to define mock.On call
+//   - data
+func (_e *MockAuthenticator_Expecter) Reply(data interface{}) *MockAuthenticator_Reply_Call {
+	return &MockAuthenticator_Reply_Call{Call: _e.mock.On("Reply", data)}
 }
 
-func (_c *MockConn_Close_Call) Run(run func()) *MockConn_Close_Call {
+func (_c *MockAuthenticator_Reply_Call) Run(run func(data []byte)) *MockAuthenticator_Reply_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run()
+		run(args[0].([]byte))
 	})
 	return _c
 }
 
-func (_c *MockConn_Close_Call) Return(err error) *MockConn_Close_Call {
-	_c.Call.Return(err)
+func (_c *MockAuthenticator_Reply_Call) Return(next []byte, status AuthStatus, err error) *MockAuthenticator_Reply_Call {
+	_c.Call.Return(next, status, err)
 	return _c
 }
 
-func (_c *MockConn_Close_Call) RunAndReturn(run func() error) *MockConn_Close_Call {
+func (_c *MockAuthenticator_Reply_Call) RunAndReturn(run func(data []byte) ([]byte, AuthStatus, error)) *MockAuthenticator_Reply_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// EnableOptionForThem provides a mock function for the type MockConn
-func (_mock *MockConn) EnableOptionForThem(option byte, enable bool) error {
-	ret := _mock.Called(option, enable)
+// Start provides a mock function for the type MockAuthenticator
+func (_mock *MockAuthenticator) Start() ([]byte, error) {
+	ret := _mock.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for EnableOptionForThem")
+		panic("no return value specified for Start")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(byte, bool) error); ok {
-		r0 = returnFunc(option, enable)
+	var r0 []byte
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func() ([]byte, error)); ok {
+		return returnFunc()
+	}
+	if returnFunc, ok := ret.Get(0).(func() []byte); ok {
+		r0 = returnFunc()
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func() error); ok {
+		r1 = returnFunc()
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockConn_EnableOptionForThem_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnableOptionForThem'
-type MockConn_EnableOptionForThem_Call struct {
+// MockAuthenticator_Start_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Start'
+type MockAuthenticator_Start_Call struct {
 	*mock.Call
 }
 
-// EnableOptionForThem is a helper method to define mock.On call
-//   - option
-//   - enable
-func (_e *MockConn_Expecter) EnableOptionForThem(option interface{}, enable interface{}) *MockConn_EnableOptionForThem_Call {
-	return &MockConn_EnableOptionForThem_Call{Call: _e.mock.On("EnableOptionForThem", option, enable)}
+// Start is a helper method to define mock.On call
+func (_e *MockAuthenticator_Expecter) Start() *MockAuthenticator_Start_Call {
+	return &MockAuthenticator_Start_Call{Call: _e.mock.On("Start")}
 }
 
-func (_c *MockConn_EnableOptionForThem_Call) Run(run func(option byte, enable bool)) *MockConn_EnableOptionForThem_Call {
+func (_c *MockAuthenticator_Start_Call) Run(run func()) *MockAuthenticator_Start_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(byte), args[1].(bool))
+		run()
 	})
 	return _c
 }
 
-func (_c *MockConn_EnableOptionForThem_Call) Return(err error) *MockConn_EnableOptionForThem_Call {
-	_c.Call.Return(err)
+func (_c *MockAuthenticator_Start_Call) Return(bytes []byte, err error) *MockAuthenticator_Start_Call {
+	_c.Call.Return(bytes, err)
 	return _c
 }
 
-func (_c *MockConn_EnableOptionForThem_Call) RunAndReturn(run func(option byte, enable bool) error) *MockConn_EnableOptionForThem_Call {
+func (_c *MockAuthenticator_Start_Call) RunAndReturn(run func() ([]byte, error)) *MockAuthenticator_Start_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// EnableOptionForUs provides a mock function for the type MockConn
-func (_mock *MockConn) EnableOptionForUs(option byte, enable bool) error {
-	ret := _mock.Called(option, enable)
+// Type provides a mock function for the type MockAuthenticator
+func (_mock *MockAuthenticator) Type() (byte, byte) {
+	ret := _mock.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for EnableOptionForUs")
+		panic("no return value specified for Type")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(byte, bool) error); ok {
-		r0 = returnFunc(option, enable)
+	var r0 byte
+	var r1 byte
+	if returnFunc, ok := ret.Get(0).(func() (byte, byte)); ok {
+		return returnFunc()
+	}
+	if returnFunc, ok := ret.Get(0).(func() byte); ok {
+		r0 = returnFunc()
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(byte)
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func() byte); ok {
+		r1 = returnFunc()
+	} else {
+		r1 = ret.Get(1).(byte)
+	}
+	return r0, r1
 }
 
-// MockConn_EnableOptionForUs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnableOptionForUs'
-type MockConn_EnableOptionForUs_Call struct {
+// MockAuthenticator_Type_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Type'
+type MockAuthenticator_Type_Call struct {
 	*mock.Call
 }
 
-// EnableOptionForUs is a helper method to define mock.On call
-//   - option
-//   - enable
-func (_e *MockConn_Expecter) EnableOptionForUs(option interface{}, enable interface{}) *MockConn_EnableOptionForUs_Call {
-	return &MockConn_EnableOptionForUs_Call{Call: _e.mock.On("EnableOptionForUs", option, enable)}
+// Type is a helper method to define mock.On call
+func (_e *MockAuthenticator_Expecter) Type() *MockAuthenticator_Type_Call {
+	return &MockAuthenticator_Type_Call{Call: _e.mock.On("Type")}
 }
 
-func (_c *MockConn_EnableOptionForUs_Call) Run(run func(option byte, enable bool)) *MockConn_EnableOptionForUs_Call {
+func (_c *MockAuthenticator_Type_Call) Run(run func()) *MockAuthenticator_Type_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(byte), args[1].(bool))
+		run()
 	})
 	return _c
 }
 
-func (_c *MockConn_EnableOptionForUs_Call) Return(err error) *MockConn_EnableOptionForUs_Call {
-	_c.Call.Return(err)
+func (_c *MockAuthenticator_Type_Call) Return(authType byte, modifiers byte) *MockAuthenticator_Type_Call {
+	_c.Call.Return(authType, modifiers)
 	return _c
 }
 
-func (_c *MockConn_EnableOptionForUs_Call) RunAndReturn(run func(option byte, enable bool) error) *MockConn_EnableOptionForUs_Call {
+func (_c *MockAuthenticator_Type_Call) RunAndReturn(run func() (byte, byte)) *MockAuthenticator_Type_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// LocalAddr provides a mock function for the type MockConn
-func (_mock *MockConn) LocalAddr() net.Addr {
+// NewMockSessionKeyer creates a new instance of MockSessionKeyer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockSessionKeyer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockSessionKeyer {
+	mock := &MockSessionKeyer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockSessionKeyer is an autogenerated mock type for the SessionKeyer type
+type MockSessionKeyer struct {
+	mock.Mock
+}
+
+type MockSessionKeyer_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockSessionKeyer) EXPECT() *MockSessionKeyer_Expecter {
+	return &MockSessionKeyer_Expecter{mock: &_m.Mock}
+}
+
+// SessionKey provides a mock function for the type MockSessionKeyer
+func (_mock *MockSessionKeyer) SessionKey() []byte {
 	ret := _mock.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for LocalAddr")
+		panic("no return value specified for SessionKey")
 	}
 
-	var r0 net.Addr
-	if returnFunc, ok := ret.Get(0).(func() net.Addr); ok {
+	var r0 []byte
+	if returnFunc, ok := ret.Get(0).(func() []byte); ok {
 		r0 = returnFunc()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(net.Addr)
+			r0 = ret.Get(0).([]byte)
 		}
 	}
 	return r0
 }
 
-// MockConn_LocalAddr_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LocalAddr'
-type MockConn_LocalAddr_Call struct {
+// MockSessionKeyer_SessionKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SessionKey'
+type MockSessionKeyer_SessionKey_Call struct {
 	*mock.Call
 }
 
-// LocalAddr is a helper method to define mock.On call
-func (_e *MockConn_Expecter) LocalAddr() *MockConn_LocalAddr_Call {
-	return &MockConn_LocalAddr_Call{Call: _e.mock.On("LocalAddr")}
+// SessionKey is a helper method to define mock.On call
+func (_e *MockSessionKeyer_Expecter) SessionKey() *MockSessionKeyer_SessionKey_Call {
+	return &MockSessionKeyer_SessionKey_Call{Call: _e.mock.On("SessionKey")}
 }
 
-func (_c *MockConn_LocalAddr_Call) Run(run func()) *MockConn_LocalAddr_Call {
+func (_c *MockSessionKeyer_SessionKey_Call) Run(run func()) *MockSessionKeyer_SessionKey_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run()
 	})
 	return _c
 }
 
-func (_c *MockConn_LocalAddr_Call) Return(addr net.Addr) *MockConn_LocalAddr_Call {
-	_c.Call.Return(addr)
+func (_c *MockSessionKeyer_SessionKey_Call) Return(bytes []byte) *MockSessionKeyer_SessionKey_Call {
+	_c.Call.Return(bytes)
 	return _c
 }
 
-func (_c *MockConn_LocalAddr_Call) RunAndReturn(run func() net.Addr) *MockConn_LocalAddr_Call {
+func (_c *MockSessionKeyer_SessionKey_Call) RunAndReturn(run func() []byte) *MockSessionKeyer_SessionKey_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Logf provides a mock function for the type MockConn
-func (_mock *MockConn) Logf(fmt string, v ...any) {
-	if len(v) > 0 {
-		_mock.Called(fmt, v)
-	} else {
-		_mock.Called(fmt)
-	}
-
-	return
-}
+// NewMockComPortBackend creates a new instance of MockComPortBackend. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockComPortBackend(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockComPortBackend {
+	mock := &MockComPortBackend{}
+	mock.Mock.Test(t)
 
-// MockConn_Logf_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Logf'
-type MockConn_Logf_Call struct {
-	*mock.Call
-}
+	t.Cleanup(func() { mock.AssertExpectations(t) })
 
-// Logf is a helper method to define mock.On call
-//   - fmt
-//   - v
-func (_e *MockConn_Expecter) Logf(fmt interface{}, v ...interface{}) *MockConn_Logf_Call {
-	return &MockConn_Logf_Call{Call: _e.mock.On("Logf",
-		append([]interface{}{fmt}, v...)...)}
+	return mock
 }
 
-func (_c *MockConn_Logf_Call) Run(run func(fmt string, v ...any)) *MockConn_Logf_Call {
-	_c.Call.Run(func(args mock.Arguments) {
-		variadicArgs := args[1].([]any)
-		run(args[0].(string), variadicArgs...)
-	})
-	return _c
+// MockComPortBackend is an autogenerated mock type for the ComPortBackend type
+type MockComPortBackend struct {
+	mock.Mock
 }
 
-func (_c *MockConn_Logf_Call) Return() *MockConn_Logf_Call {
-	_c.Call.Return()
-	return _c
+type MockComPortBackend_Expecter struct {
+	mock *mock.Mock
 }
 
-func (_c *MockConn_Logf_Call) RunAndReturn(run func(fmt string, v ...any)) *MockConn_Logf_Call {
-	_c.Run(run)
-	return _c
+func (_m *MockComPortBackend) EXPECT() *MockComPortBackend_Expecter {
+	return &MockComPortBackend_Expecter{mock: &_m.Mock}
 }
 
-// Option provides a mock function for the type MockConn
-func (_mock *MockConn) Option(option byte) Option {
-	ret := _mock.Called(option)
+// Purge provides a mock function for the type MockComPortBackend
+func (_mock *MockComPortBackend) Purge(what byte) error {
+	ret := _mock.Called(what)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Option")
+		panic("no return value specified for Purge")
 	}
 
-	var r0 Option
-	if returnFunc, ok := ret.Get(0).(func(byte) Option); ok {
-		r0 = returnFunc(option)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(byte) error); ok {
+		r0 = returnFunc(what)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(Option)
-		}
+		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// MockConn_Option_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Option'
-type MockConn_Option_Call struct {
+// MockComPortBackend_Purge_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Purge'
+type MockComPortBackend_Purge_Call struct {
 	*mock.Call
 }
 
-// Option is a helper method to define mock.On call
-//   - option
-func (_e *MockConn_Expecter) Option(option interface{}) *MockConn_Option_Call {
-	return &MockConn_Option_Call{Call: _e.mock.On("Option", option)}
+// Purge is a helper method to define mock.On call
+//   - what
+func (_e *MockComPortBackend_Expecter) Purge(what interface{}) *MockComPortBackend_Purge_Call {
+	return &MockComPortBackend_Purge_Call{Call: _e.mock.On("Purge", what)}
 }
 
-func (_c *MockConn_Option_Call) Run(run func(option byte)) *MockConn_Option_Call {
+func (_c *MockComPortBackend_Purge_Call) Run(run func(what byte)) *MockComPortBackend_Purge_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(byte))
 	})
 	return _c
 }
 
-func (_c *MockConn_Option_Call) Return(option1 Option) *MockConn_Option_Call {
-	_c.Call.Return(option1)
+func (_c *MockComPortBackend_Purge_Call) Return(err error) *MockComPortBackend_Purge_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockConn_Option_Call) RunAndReturn(run func(option byte) Option) *MockConn_Option_Call {
+func (_c *MockComPortBackend_Purge_Call) RunAndReturn(run func(what byte) error) *MockComPortBackend_Purge_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Read provides a mock function for the type MockConn
-func (_mock *MockConn) Read(b []byte) (int, error) {
-	ret := _mock.Called(b)
+// SetBaudRate provides a mock function for the type MockComPortBackend
+func (_mock *MockComPortBackend) SetBaudRate(rate uint32) (uint32, error) {
+	ret := _mock.Called(rate)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Read")
+		panic("no return value specified for SetBaudRate")
 	}
 
-	var r0 int
+	var r0 uint32
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func([]byte) (int, error)); ok {
-		return returnFunc(b)
+	if returnFunc, ok := ret.Get(0).(func(uint32) (uint32, error)); ok {
+		return returnFunc(rate)
 	}
-	if returnFunc, ok := ret.Get(0).(func([]byte) int); ok {
-		r0 = returnFunc(b)
+	if returnFunc, ok := ret.Get(0).(func(uint32) uint32); ok {
+		r0 = returnFunc(rate)
 	} else {
-		r0 = ret.Get(0).(int)
+		r0 = ret.Get(0).(uint32)
 	}
-	if returnFunc, ok := ret.Get(1).(func([]byte) error); ok {
-		r1 = returnFunc(b)
+	if returnFunc, ok := ret.Get(1).(func(uint32) error); ok {
+		r1 = returnFunc(rate)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockConn_Read_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Read'
-type MockConn_Read_Call struct {
+// MockComPortBackend_SetBaudRate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetBaudRate'
+type MockComPortBackend_SetBaudRate_Call struct {
 	*mock.Call
 }
 
-// Read is a helper method to define mock.On call
-//   - b
-func (_e *MockConn_Expecter) Read(b interface{}) *MockConn_Read_Call {
-	return &MockConn_Read_Call{Call: _e.mock.On("Read", b)}
+// SetBaudRate is a helper method to define mock.On call
+//   - rate
+func (_e *MockComPortBackend_Expecter) SetBaudRate(rate interface{}) *MockComPortBackend_SetBaudRate_Call {
+	return &MockComPortBackend_SetBaudRate_Call{Call: _e.mock.On("SetBaudRate", rate)}
 }
 
-func (_c *MockConn_Read_Call) Run(run func(b []byte)) *MockConn_Read_Call {
+func (_c *MockComPortBackend_SetBaudRate_Call) Run(run func(rate uint32)) *MockComPortBackend_SetBaudRate_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].([]byte))
+		run(args[0].(uint32))
 	})
 	return _c
 }
 
-func (_c *MockConn_Read_Call) Return(n int, err error) *MockConn_Read_Call {
-	_c.Call.Return(n, err)
+func (_c *MockComPortBackend_SetBaudRate_Call) Return(v uint32, err error) *MockComPortBackend_SetBaudRate_Call {
+	_c.Call.Return(v, err)
 	return _c
 }
 
-func (_c *MockConn_Read_Call) RunAndReturn(run func(b []byte) (int, error)) *MockConn_Read_Call {
+func (_c *MockComPortBackend_SetBaudRate_Call) RunAndReturn(run func(rate uint32) (uint32, error)) *MockComPortBackend_SetBaudRate_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RemoteAddr provides a mock function for the type MockConn
-func (_mock *MockConn) RemoteAddr() net.Addr {
-	ret := _mock.Called()
+// SetControl provides a mock function for the type MockComPortBackend
+func (_mock *MockComPortBackend) SetControl(control byte) (byte, error) {
+	ret := _mock.Called(control)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RemoteAddr")
+		panic("no return value specified for SetControl")
 	}
 
-	var r0 net.Addr
-	if returnFunc, ok := ret.Get(0).(func() net.Addr); ok {
-		r0 = returnFunc()
+	var r0 byte
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(byte) (byte, error)); ok {
+		return returnFunc(control)
+	}
+	if returnFunc, ok := ret.Get(0).(func(byte) byte); ok {
+		r0 = returnFunc(control)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(net.Addr)
-		}
+		r0 = ret.Get(0).(byte)
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(byte) error); ok {
+		r1 = returnFunc(control)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockConn_RemoteAddr_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoteAddr'
-type MockConn_RemoteAddr_Call struct {
+// MockComPortBackend_SetControl_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetControl'
+type MockComPortBackend_SetControl_Call struct {
 	*mock.Call
 }
 
-// RemoteAddr is a helper method to define mock.On call
-func (_e *MockConn_Expecter) RemoteAddr() *MockConn_RemoteAddr_Call {
-	return &MockConn_RemoteAddr_Call{Call: _e.mock.On("RemoteAddr")}
+// SetControl is a helper method to define mock.On call
+//   - control
+func (_e *MockComPortBackend_Expecter) SetControl(control interface{}) *MockComPortBackend_SetControl_Call {
+	return &MockComPortBackend_SetControl_Call{Call: _e.mock.On("SetControl", control)}
 }
 
-func (_c *MockConn_RemoteAddr_Call) Run(run func()) *MockConn_RemoteAddr_Call {
+func (_c *MockComPortBackend_SetControl_Call) Run(run func(control byte)) *MockComPortBackend_SetControl_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run()
+		run(args[0].(byte))
 	})
 	return _c
 }
 
-func (_c *MockConn_RemoteAddr_Call) Return(addr net.Addr) *MockConn_RemoteAddr_Call {
-	_c.Call.Return(addr)
+func (_c *MockComPortBackend_SetControl_Call) Return(v byte, err error) *MockComPortBackend_SetControl_Call {
+	_c.Call.Return(v, err)
 	return _c
 }
 
-func (_c *MockConn_RemoteAddr_Call) RunAndReturn(run func() net.Addr) *MockConn_RemoteAddr_Call {
+func (_c *MockComPortBackend_SetControl_Call) RunAndReturn(run func(control byte) (byte, error)) *MockComPortBackend_SetControl_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RemoveListener provides a mock function for the type MockConn
-func (_mock *MockConn) RemoveListener(s string, eventListener EventListener) {
-	_mock.Called(s, eventListener)
-	return
+// SetDataSize provides a mock function for the type MockComPortBackend
+func (_mock *MockComPortBackend) SetDataSize(size byte) (byte, error) {
+	ret := _mock.Called(size)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetDataSize")
+	}
+
+	var r0 byte
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(byte) (byte, error)); ok {
+		return returnFunc(size)
+	}
+	if returnFunc, ok := ret.Get(0).(func(byte) byte); ok {
+		r0 = returnFunc(size)
+	} else {
+		r0 = ret.Get(0).(byte)
+	}
+	if returnFunc, ok := ret.Get(1).(func(byte) error); ok {
+		r1 = returnFunc(size)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockConn_RemoveListener_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveListener'
-type MockConn_RemoveListener_Call struct {
+// MockComPortBackend_SetDataSize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetDataSize'
+type MockComPortBackend_SetDataSize_Call struct {
 	*mock.Call
 }
 
-// RemoveListener is a helper method to define mock.On call
-//   - s
-//   - eventListener
-func (_e *MockConn_Expecter) RemoveListener(s interface{}, eventListener interface{}) *MockConn_RemoveListener_Call {
-	return &MockConn_RemoveListener_Call{Call: _e.mock.On("RemoveListener", s, eventListener)}
+// SetDataSize is a helper method to define mock.On call
+//   - size
+func (_e *MockComPortBackend_Expecter) SetDataSize(size interface{}) *MockComPortBackend_SetDataSize_Call {
+	return &MockComPortBackend_SetDataSize_Call{Call: _e.mock.On("SetDataSize", size)}
 }
 
-func (_c *MockConn_RemoveListener_Call) Run(run func(s string, eventListener EventListener)) *MockConn_RemoveListener_Call {
+func (_c *MockComPortBackend_SetDataSize_Call) Run(run func(size byte)) *MockComPortBackend_SetDataSize_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(EventListener))
+		run(args[0].(byte))
 	})
 	return _c
 }
 
-func (_c *MockConn_RemoveListener_Call) Return() *MockConn_RemoveListener_Call {
-	_c.Call.Return()
+func (_c *MockComPortBackend_SetDataSize_Call) Return(v byte, err error) *MockComPortBackend_SetDataSize_Call {
+	_c.Call.Return(v, err)
 	return _c
 }
 
-func (_c *MockConn_RemoveListener_Call) RunAndReturn(run func(s string, eventListener EventListener)) *MockConn_RemoveListener_Call {
-	_c.Run(run)
+func (_c *MockComPortBackend_SetDataSize_Call) RunAndReturn(run func(size byte) (byte, error)) *MockComPortBackend_SetDataSize_Call {
+	_c.Call.Return(run)
 	return _c
 }
 
-// RequestEncoding provides a mock function for the type MockConn
-func (_mock *MockConn) RequestEncoding(encoding1 encoding.Encoding) error {
-	ret := _mock.Called(encoding1)
+// SetParity provides a mock function for the type MockComPortBackend
+func (_mock *MockComPortBackend) SetParity(parity byte) (byte, error) {
+	ret := _mock.Called(parity)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RequestEncoding")
+		panic("no return value specified for SetParity")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(encoding.Encoding) error); ok {
-		r0 = returnFunc(encoding1)
+	var r0 byte
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(byte) (byte, error)); ok {
+		return returnFunc(parity)
+	}
+	if returnFunc, ok := ret.Get(0).(func(byte) byte); ok {
+		r0 = returnFunc(parity)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(byte)
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(byte) error); ok {
+		r1 = returnFunc(parity)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockConn_RequestEncoding_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RequestEncoding'
-type MockConn_RequestEncoding_Call struct {
+// MockComPortBackend_SetParity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetParity'
+type MockComPortBackend_SetParity_Call struct {
 	*mock.Call
 }
 
-// RequestEncoding is a helper method to define mock.On call
-//   - encoding1
-func (_e *MockConn_Expecter) RequestEncoding(encoding1 interface{}) *MockConn_RequestEncoding_Call {
-	return &MockConn_RequestEncoding_Call{Call: _e.mock.On("RequestEncoding", encoding1)}
+// SetParity is a helper method to define mock.On call
+//   - parity
+func (_e *MockComPortBackend_Expecter) SetParity(parity interface{}) *MockComPortBackend_SetParity_Call {
+	return &MockComPortBackend_SetParity_Call{Call: _e.mock.On("SetParity", parity)}
 }
 
-func (_c *MockConn_RequestEncoding_Call) Run(run func(encoding1 encoding.Encoding)) *MockConn_RequestEncoding_Call {
+func (_c *MockComPortBackend_SetParity_Call) Run(run func(parity byte)) *MockComPortBackend_SetParity_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(encoding.Encoding))
+		run(args[0].(byte))
 	})
 	return _c
 }
 
-func (_c *MockConn_RequestEncoding_Call) Return(err error) *MockConn_RequestEncoding_Call {
-	_c.Call.Return(err)
+func (_c *MockComPortBackend_SetParity_Call) Return(v byte, err error) *MockComPortBackend_SetParity_Call {
+	_c.Call.Return(v, err)
 	return _c
 }
 
-func (_c *MockConn_RequestEncoding_Call) RunAndReturn(run func(encoding1 encoding.Encoding) error) *MockConn_RequestEncoding_Call {
+func (_c *MockComPortBackend_SetParity_Call) RunAndReturn(run func(parity byte) (byte, error)) *MockComPortBackend_SetParity_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Send provides a mock function for the type MockConn
-func (_mock *MockConn) Send(p []byte) (int, error) {
-	ret := _mock.Called(p)
+// SetStopSize provides a mock function for the type MockComPortBackend
+func (_mock *MockComPortBackend) SetStopSize(size byte) (byte, error) {
+	ret := _mock.Called(size)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Send")
+		panic("no return value specified for SetStopSize")
 	}
 
-	var r0 int
+	var r0 byte
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func([]byte) (int, error)); ok {
-		return returnFunc(p)
+	if returnFunc, ok := ret.Get(0).(func(byte) (byte, error)); ok {
+		return returnFunc(size)
 	}
-	if returnFunc, ok := ret.Get(0).(func([]byte) int); ok {
-		r0 = returnFunc(p)
+	if returnFunc, ok := ret.Get(0).(func(byte) byte); ok {
+		r0 = returnFunc(size)
 	} else {
-		r0 = ret.Get(0).(int)
+		r0 = ret.Get(0).(byte)
 	}
-	if returnFunc, ok := ret.Get(1).(func([]byte) error); ok {
-		r1 = returnFunc(p)
+	if returnFunc, ok := ret.Get(1).(func(byte) error); ok {
+		r1 = returnFunc(size)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockConn_Send_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Send'
-type MockConn_Send_Call struct {
+// MockComPortBackend_SetStopSize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetStopSize'
+type MockComPortBackend_SetStopSize_Call struct {
 	*mock.Call
 }
 
-// Send is a helper method to define mock.On call
-//   - p
-func (_e *MockConn_Expecter) Send(p interface{}) *MockConn_Send_Call {
-	return &MockConn_Send_Call{Call: _e.mock.On("Send", p)}
+// SetStopSize is a helper method to define mock.On call
+//   - size
+func (_e *MockComPortBackend_Expecter) SetStopSize(size interface{}) *MockComPortBackend_SetStopSize_Call {
+	return &MockComPortBackend_SetStopSize_Call{Call: _e.mock.On("SetStopSize", size)}
 }
 
-func (_c *MockConn_Send_Call) Run(run func(p []byte)) *MockConn_Send_Call {
+func (_c *MockComPortBackend_SetStopSize_Call) Run(run func(size byte)) *MockComPortBackend_SetStopSize_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].([]byte))
+		run(args[0].(byte))
 	})
 	return _c
 }
 
-func (_c *MockConn_Send_Call) Return(n int, err error) *MockConn_Send_Call {
-	_c.Call.Return(n, err)
+func (_c *MockComPortBackend_SetStopSize_Call) Return(v byte, err error) *MockComPortBackend_SetStopSize_Call {
+	_c.Call.Return(v, err)
 	return _c
 }
 
-func (_c *MockConn_Send_Call) RunAndReturn(run func(p []byte) (int, error)) *MockConn_Send_Call {
+func (_c *MockComPortBackend_SetStopSize_Call) RunAndReturn(run func(size byte) (byte, error)) *MockComPortBackend_SetStopSize_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SetDeadline provides a mock function for the type MockConn
-func (_mock *MockConn) SetDeadline(t time.Time) error {
-	ret := _mock.Called(t)
-
-	if len(ret) == 0 {
-		panic("no return value specified for SetDeadline")
-	}
-
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(time.Time) error); ok {
-		r0 = returnFunc(t)
-	} else {
-		r0 = ret.Error(0)
-	}
-	return r0
+// Suspend provides a mock function for the type MockComPortBackend
+func (_mock *MockComPortBackend) Suspend(suspended bool) {
+	_mock.Called(suspended)
+	return
 }
 
-// MockConn_SetDeadline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetDeadline'
-type MockConn_SetDeadline_Call struct {
+// MockComPortBackend_Suspend_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Suspend'
+type MockComPortBackend_Suspend_Call struct {
 	*mock.Call
 }
 
-// SetDeadline is a helper method to define mock.On call
-//   - t
-func (_e *MockConn_Expecter) SetDeadline(t interface{}) *MockConn_SetDeadline_Call {
-	return &MockConn_SetDeadline_Call{Call: _e.mock.On("SetDeadline", t)}
+// Suspend is a helper method to define mock.On call
+//   - suspended
+func (_e *MockComPortBackend_Expecter) Suspend(suspended interface{}) *MockComPortBackend_Suspend_Call {
+	return &MockComPortBackend_Suspend_Call{Call: _e.mock.On("Suspend", suspended)}
 }
 
-func (_c *MockConn_SetDeadline_Call) Run(run func(t time.Time)) *MockConn_SetDeadline_Call {
+func (_c *MockComPortBackend_Suspend_Call) Run(run func(suspended bool)) *MockComPortBackend_Suspend_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(time.Time))
+		run(args[0].(bool))
 	})
 	return _c
 }
 
-func (_c *MockConn_SetDeadline_Call) Return(err error) *MockConn_SetDeadline_Call {
-	_c.Call.Return(err)
+func (_c *MockComPortBackend_Suspend_Call) Return() *MockComPortBackend_Suspend_Call {
+	_c.Call.Return()
 	return _c
 }
 
-func (_c *MockConn_SetDeadline_Call) RunAndReturn(run func(t time.Time) error) *MockConn_SetDeadline_Call {
-	_c.Call.Return(run)
+func (_c *MockComPortBackend_Suspend_Call) RunAndReturn(run func(suspended bool)) *MockComPortBackend_Suspend_Call {
+	_c.Run(run)
 	return _c
 }
 
-// SetEncoding provides a mock function for the type MockConn
-func (_mock *MockConn) SetEncoding(encoding1 encoding.Encoding) {
-	_mock.Called(encoding1)
+// NewMockConn creates a new instance of MockConn. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockConn(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockConn {
+	mock := &MockConn{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockConn is an autogenerated mock type for the Conn type
+type MockConn struct {
+	mock.Mock
+}
+
+type MockConn_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockConn) EXPECT() *MockConn_Expecter {
+	return &MockConn_Expecter{mock: &_m.Mock}
+}
+
+// AddListener provides a mock function for the type MockConn
+func (_mock *MockConn) AddListener(s string, eventListener EventListener) {
+	_mock.Called(s, eventListener)
 	return
 }
 
-// MockConn_SetEncoding_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetEncoding'
-type MockConn_SetEncoding_Call struct {
+// MockConn_AddListener_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddListener'
+type MockConn_AddListener_Call struct {
 	*mock.Call
 }
 
-// SetEncoding is a helper method to define mock.On call
-//   - encoding1
-func (_e *MockConn_Expecter) SetEncoding(encoding1 interface{}) *MockConn_SetEncoding_Call {
-	return &MockConn_SetEncoding_Call{Call: _e.mock.On("SetEncoding", encoding1)}
+// AddListener is a helper method to define mock.On call
+//   - s
+//   - eventListener
+func (_e *MockConn_Expecter) AddListener(s interface{}, eventListener interface{}) *MockConn_AddListener_Call {
+	return &MockConn_AddListener_Call{Call: _e.mock.On("AddListener", s, eventListener)}
 }
 
-func (_c *MockConn_SetEncoding_Call) Run(run func(encoding1 encoding.Encoding)) *MockConn_SetEncoding_Call {
+func (_c *MockConn_AddListener_Call) Run(run func(s string, eventListener EventListener)) *MockConn_AddListener_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(encoding.Encoding))
+		run(args[0].(string), args[1].(EventListener))
 	})
 	return _c
 }
 
-func (_c *MockConn_SetEncoding_Call) Return() *MockConn_SetEncoding_Call {
+func (_c *MockConn_AddListener_Call) Return() *MockConn_AddListener_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *MockConn_SetEncoding_Call) RunAndReturn(run func(encoding1 encoding.Encoding)) *MockConn_SetEncoding_Call {
+func (_c *MockConn_AddListener_Call) RunAndReturn(run func(s string, eventListener EventListener)) *MockConn_AddListener_Call {
 	_c.Run(run)
 	return _c
 }
 
-// SetLogger provides a mock function for the type MockConn
-func (_mock *MockConn) SetLogger(logger Logger) {
-	_mock.Called(logger)
+// BindOption provides a mock function for the type MockConn
+func (_mock *MockConn) BindOption(o Option) {
+	_mock.Called(o)
 	return
 }
 
-// MockConn_SetLogger_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetLogger'
-type MockConn_SetLogger_Call struct {
+// MockConn_BindOption_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BindOption'
+type MockConn_BindOption_Call struct {
 	*mock.Call
 }
 
-// SetLogger is a helper method to define mock.On call
-//   - logger
-func (_e *MockConn_Expecter) SetLogger(logger interface{}) *MockConn_SetLogger_Call {
-	return &MockConn_SetLogger_Call{Call: _e.mock.On("SetLogger", logger)}
+// BindOption is a helper method to define mock.On call
+//   - o
+func (_e *MockConn_Expecter) BindOption(o interface{}) *MockConn_BindOption_Call {
+	return &MockConn_BindOption_Call{Call: _e.mock.On("BindOption", o)}
 }
 
-func (_c *MockConn_SetLogger_Call) Run(run func(logger Logger)) *MockConn_SetLogger_Call {
+func (_c *MockConn_BindOption_Call) Run(run func(o Option)) *MockConn_BindOption_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(Logger))
+		run(args[0].(Option))
 	})
 	return _c
 }
 
-func (_c *MockConn_SetLogger_Call) Return() *MockConn_SetLogger_Call {
+func (_c *MockConn_BindOption_Call) Return() *MockConn_BindOption_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *MockConn_SetLogger_Call) RunAndReturn(run func(logger Logger)) *MockConn_SetLogger_Call {
+func (_c *MockConn_BindOption_Call) RunAndReturn(run func(o Option)) *MockConn_BindOption_Call {
 	_c.Run(run)
 	return _c
 }
 
-// SetReadDeadline provides a mock function for the type MockConn
-func (_mock *MockConn) SetReadDeadline(t time.Time) error {
-	ret := _mock.Called(t)
-
+// Close provides a mock function for the type MockConn
+func (_mock *MockConn) Close() error {
+	ret := _mock.Called()
+
 	if len(ret) == 0 {
-		panic("no return value specified for SetReadDeadline")
+		panic("no return value specified for Close")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(time.Time) error); ok {
-		r0 = returnFunc(t)
+	if returnFunc, ok := ret.Get(0).(func() error); ok {
+		r0 = returnFunc()
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// MockConn_SetReadDeadline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetReadDeadline'
-type MockConn_SetReadDeadline_Call struct {
+// MockConn_Close_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Close'
+type MockConn_Close_Call struct {
 	*mock.Call
 }
 
-// SetReadDeadline is a helper method to define mock.On call
-//   - t
-func (_e *MockConn_Expecter) SetReadDeadline(t interface{}) *MockConn_SetReadDeadline_Call {
-	return &MockConn_SetReadDeadline_Call{Call: _e.mock.On("SetReadDeadline", t)}
+// Close is a helper method to define mock.On call
+func (_e *MockConn_Expecter) Close() *MockConn_Close_Call {
+	return &MockConn_Close_Call{Call: _e.mock.On("Close")}
 }
 
-func (_c *MockConn_SetReadDeadline_Call) Run(run func(t time.Time)) *MockConn_SetReadDeadline_Call {
+func (_c *MockConn_Close_Call) Run(run func()) *MockConn_Close_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(time.Time))
+		run()
 	})
 	return _c
 }
 
-func (_c *MockConn_SetReadDeadline_Call) Return(err error) *MockConn_SetReadDeadline_Call {
+func (_c *MockConn_Close_Call) Return(err error) *MockConn_Close_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockConn_SetReadDeadline_Call) RunAndReturn(run func(t time.Time) error) *MockConn_SetReadDeadline_Call {
+func (_c *MockConn_Close_Call) RunAndReturn(run func() error) *MockConn_Close_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SetReadEncoding provides a mock function for the type MockConn
-func (_mock *MockConn) SetReadEncoding(encoding1 encoding.Encoding) {
-	_mock.Called(encoding1)
-	return
+// EnableOptionForThem provides a mock function for the type MockConn
+func (_mock *MockConn) EnableOptionForThem(option byte, enable bool) error {
+	ret := _mock.Called(option, enable)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnableOptionForThem")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(byte, bool) error); ok {
+		r0 = returnFunc(option, enable)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
 }
 
-// MockConn_SetReadEncoding_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetReadEncoding'
-type MockConn_SetReadEncoding_Call struct {
+// MockConn_EnableOptionForThem_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnableOptionForThem'
+type MockConn_EnableOptionForThem_Call struct {
 	*mock.Call
 }
 
-// SetReadEncoding is a helper method to define mock.On call
-//   - encoding1
-func (_e *MockConn_Expecter) SetReadEncoding(encoding1 interface{}) *MockConn_SetReadEncoding_Call {
-	return &MockConn_SetReadEncoding_Call{Call: _e.mock.On("SetReadEncoding", encoding1)}
+// EnableOptionForThem is a helper method to define mock.On call
+//   - option
+//   - enable
+func (_e *MockConn_Expecter) EnableOptionForThem(option interface{}, enable interface{}) *MockConn_EnableOptionForThem_Call {
+	return &MockConn_EnableOptionForThem_Call{Call: _e.mock.On("EnableOptionForThem", option, enable)}
 }
 
-func (_c *MockConn_SetReadEncoding_Call) Run(run func(encoding1 encoding.Encoding)) *MockConn_SetReadEncoding_Call {
+func (_c *MockConn_EnableOptionForThem_Call) Run(run func(option byte, enable bool)) *MockConn_EnableOptionForThem_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(encoding.Encoding))
+		run(args[0].(byte), args[1].(bool))
 	})
 	return _c
 }
 
-func (_c *MockConn_SetReadEncoding_Call) Return() *MockConn_SetReadEncoding_Call {
-	_c.Call.Return()
+func (_c *MockConn_EnableOptionForThem_Call) Return(err error) *MockConn_EnableOptionForThem_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockConn_SetReadEncoding_Call) RunAndReturn(run func(encoding1 encoding.Encoding)) *MockConn_SetReadEncoding_Call {
-	_c.Run(run)
+func (_c *MockConn_EnableOptionForThem_Call) RunAndReturn(run func(option byte, enable bool) error) *MockConn_EnableOptionForThem_Call {
+	_c.Call.Return(run)
 	return _c
 }
 
-// SetWriteDeadline provides a mock function for the type MockConn
-func (_mock *MockConn) SetWriteDeadline(t time.Time) error {
-	ret := _mock.Called(t)
+// EnableOptionForUs provides a mock function for the type MockConn
+func (_mock *MockConn) EnableOptionForUs(option byte, enable bool) error {
+	ret := _mock.Called(option, enable)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SetWriteDeadline")
+		panic("no return value specified for EnableOptionForUs")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(time.Time) error); ok {
-		r0 = returnFunc(t)
+	if returnFunc, ok := ret.Get(0).(func(byte, bool) error); ok {
+		r0 = returnFunc(option, enable)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// MockConn_SetWriteDeadline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWriteDeadline'
-type MockConn_SetWriteDeadline_Call struct {
+// MockConn_EnableOptionForUs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnableOptionForUs'
+type MockConn_EnableOptionForUs_Call struct {
 	*mock.Call
 }
 
-// SetWriteDeadline is a helper method to define mock.On call
-//   - t
-func (_e *MockConn_Expecter) SetWriteDeadline(t interface{}) *MockConn_SetWriteDeadline_Call {
-	return &MockConn_SetWriteDeadline_Call{Call: _e.mock.On("SetWriteDeadline", t)}
+// EnableOptionForUs is a helper method to define mock.On call
+//   - option
+//   - enable
+func (_e *MockConn_Expecter) EnableOptionForUs(option interface{}, enable interface{}) *MockConn_EnableOptionForUs_Call {
+	return &MockConn_EnableOptionForUs_Call{Call: _e.mock.On("EnableOptionForUs", option, enable)}
 }
 
-func (_c *MockConn_SetWriteDeadline_Call) Run(run func(t time.Time)) *MockConn_SetWriteDeadline_Call {
+func (_c *MockConn_EnableOptionForUs_Call) Run(run func(option byte, enable bool)) *MockConn_EnableOptionForUs_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(time.Time))
+		run(args[0].(byte), args[1].(bool))
 	})
 	return _c
 }
 
-func (_c *MockConn_SetWriteDeadline_Call) Return(err error) *MockConn_SetWriteDeadline_Call {
+func (_c *MockConn_EnableOptionForUs_Call) Return(err error) *MockConn_EnableOptionForUs_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockConn_SetWriteDeadline_Call) RunAndReturn(run func(t time.Time) error) *MockConn_SetWriteDeadline_Call {
+func (_c *MockConn_EnableOptionForUs_Call) RunAndReturn(run func(option byte, enable bool) error) *MockConn_EnableOptionForUs_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SetWriteEncoding provides a mock function for the type MockConn
-func (_mock *MockConn) SetWriteEncoding(encoding1 encoding.Encoding) {
-	_mock.Called(encoding1)
+// LocalAddr provides a mock function for the type MockConn
+func (_mock *MockConn) LocalAddr() net.Addr {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for LocalAddr")
+	}
+
+	var r0 net.Addr
+	if returnFunc, ok := ret.Get(0).(func() net.Addr); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(net.Addr)
+		}
+	}
+	return r0
+}
+
+// MockConn_LocalAddr_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LocalAddr'
+type MockConn_LocalAddr_Call struct {
+	*mock.Call
+}
+
+// LocalAddr is a helper method to define mock.On call
+func (_e *MockConn_Expecter) LocalAddr() *MockConn_LocalAddr_Call {
+	return &MockConn_LocalAddr_Call{Call: _e.mock.On("LocalAddr")}
+}
+
+func (_c *MockConn_LocalAddr_Call) Run(run func()) *MockConn_LocalAddr_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockConn_LocalAddr_Call) Return(addr net.Addr) *MockConn_LocalAddr_Call {
+	_c.Call.Return(addr)
+	return _c
+}
+
+func (_c *MockConn_LocalAddr_Call) RunAndReturn(run func() net.Addr) *MockConn_LocalAddr_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LogAttrs provides a mock function for the type MockConn
+func (_mock *MockConn) LogAttrs(level slog.Level, msg string, attrs ...slog.Attr) {
+	if len(attrs) > 0 {
+		_mock.Called(level, msg, attrs)
+	} else {
+		_mock.Called(level, msg)
+	}
+
+	return
+}
+
+// MockConn_LogAttrs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LogAttrs'
+type MockConn_LogAttrs_Call struct {
+	*mock.Call
+}
+
+// LogAttrs is a helper method to define mock.On call
+//   - level
+//   - msg
+//   - attrs
+func (_e *MockConn_Expecter) LogAttrs(level interface{}, msg interface{}, attrs ...interface{}) *MockConn_LogAttrs_Call {
+	return &MockConn_LogAttrs_Call{Call: _e.mock.On("LogAttrs",
+		append([]interface{}{level, msg}, attrs...)...)}
+}
+
+func (_c *MockConn_LogAttrs_Call) Run(run func(level slog.Level, msg string, attrs ...slog.Attr)) *MockConn_LogAttrs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := args[2].([]slog.Attr)
+		run(args[0].(slog.Level), args[1].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockConn_LogAttrs_Call) Return() *MockConn_LogAttrs_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_LogAttrs_Call) RunAndReturn(run func(level slog.Level, msg string, attrs ...slog.Attr)) *MockConn_LogAttrs_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Logf provides a mock function for the type MockConn
+func (_mock *MockConn) Logf(fmt string, v ...any) {
+	if len(v) > 0 {
+		_mock.Called(fmt, v)
+	} else {
+		_mock.Called(fmt)
+	}
+
+	return
+}
+
+// MockConn_Logf_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Logf'
+type MockConn_Logf_Call struct {
+	*mock.Call
+}
+
+// Logf is a helper method to define mock.On call
+//   - fmt
+//   - v
+func (_e *MockConn_Expecter) Logf(fmt interface{}, v ...interface{}) *MockConn_Logf_Call {
+	return &MockConn_Logf_Call{Call: _e.mock.On("Logf",
+		append([]interface{}{fmt}, v...)...)}
+}
+
+func (_c *MockConn_Logf_Call) Run(run func(fmt string, v ...any)) *MockConn_Logf_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := args[1].([]any)
+		run(args[0].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockConn_Logf_Call) Return() *MockConn_Logf_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_Logf_Call) RunAndReturn(run func(fmt string, v ...any)) *MockConn_Logf_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Option provides a mock function for the type MockConn
+func (_mock *MockConn) Option(option byte) Option {
+	ret := _mock.Called(option)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Option")
+	}
+
+	var r0 Option
+	if returnFunc, ok := ret.Get(0).(func(byte) Option); ok {
+		r0 = returnFunc(option)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Option)
+		}
+	}
+	return r0
+}
+
+// MockConn_Option_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Option'
+type MockConn_Option_Call struct {
+	*mock.Call
+}
+
+// Option is a helper method to define mock.On call
+//   - option
+func (_e *MockConn_Expecter) Option(option interface{}) *MockConn_Option_Call {
+	return &MockConn_Option_Call{Call: _e.mock.On("Option", option)}
+}
+
+func (_c *MockConn_Option_Call) Run(run func(option byte)) *MockConn_Option_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(byte))
+	})
+	return _c
+}
+
+func (_c *MockConn_Option_Call) Return(option1 Option) *MockConn_Option_Call {
+	_c.Call.Return(option1)
+	return _c
+}
+
+func (_c *MockConn_Option_Call) RunAndReturn(run func(option byte) Option) *MockConn_Option_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Read provides a mock function for the type MockConn
+func (_mock *MockConn) Read(b []byte) (int, error) {
+	ret := _mock.Called(b)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Read")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func([]byte) (int, error)); ok {
+		return returnFunc(b)
+	}
+	if returnFunc, ok := ret.Get(0).(func([]byte) int); ok {
+		r0 = returnFunc(b)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func([]byte) error); ok {
+		r1 = returnFunc(b)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockConn_Read_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Read'
+type MockConn_Read_Call struct {
+	*mock.Call
+}
+
+// Read is a helper method to define mock.On call
+//   - b
+func (_e *MockConn_Expecter) Read(b interface{}) *MockConn_Read_Call {
+	return &MockConn_Read_Call{Call: _e.mock.On("Read", b)}
+}
+
+func (_c *MockConn_Read_Call) Run(run func(b []byte)) *MockConn_Read_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]byte))
+	})
+	return _c
+}
+
+func (_c *MockConn_Read_Call) Return(n int, err error) *MockConn_Read_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockConn_Read_Call) RunAndReturn(run func(b []byte) (int, error)) *MockConn_Read_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoteAddr provides a mock function for the type MockConn
+func (_mock *MockConn) RemoteAddr() net.Addr {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoteAddr")
+	}
+
+	var r0 net.Addr
+	if returnFunc, ok := ret.Get(0).(func() net.Addr); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(net.Addr)
+		}
+	}
+	return r0
+}
+
+// MockConn_RemoteAddr_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoteAddr'
+type MockConn_RemoteAddr_Call struct {
+	*mock.Call
+}
+
+// RemoteAddr is a helper method to define mock.On call
+func (_e *MockConn_Expecter) RemoteAddr() *MockConn_RemoteAddr_Call {
+	return &MockConn_RemoteAddr_Call{Call: _e.mock.On("RemoteAddr")}
+}
+
+func (_c *MockConn_RemoteAddr_Call) Run(run func()) *MockConn_RemoteAddr_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockConn_RemoteAddr_Call) Return(addr net.Addr) *MockConn_RemoteAddr_Call {
+	_c.Call.Return(addr)
+	return _c
+}
+
+func (_c *MockConn_RemoteAddr_Call) RunAndReturn(run func() net.Addr) *MockConn_RemoteAddr_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveListener provides a mock function for the type MockConn
+func (_mock *MockConn) RemoveListener(s string, eventListener EventListener) {
+	_mock.Called(s, eventListener)
+	return
+}
+
+// MockConn_RemoveListener_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveListener'
+type MockConn_RemoveListener_Call struct {
+	*mock.Call
+}
+
+// RemoveListener is a helper method to define mock.On call
+//   - s
+//   - eventListener
+func (_e *MockConn_Expecter) RemoveListener(s interface{}, eventListener interface{}) *MockConn_RemoveListener_Call {
+	return &MockConn_RemoveListener_Call{Call: _e.mock.On("RemoveListener", s, eventListener)}
+}
+
+func (_c *MockConn_RemoveListener_Call) Run(run func(s string, eventListener EventListener)) *MockConn_RemoveListener_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(EventListener))
+	})
+	return _c
+}
+
+func (_c *MockConn_RemoveListener_Call) Return() *MockConn_RemoveListener_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_RemoveListener_Call) RunAndReturn(run func(s string, eventListener EventListener)) *MockConn_RemoveListener_Call {
+	_c.Run(run)
+	return _c
+}
+
+// RequestEncoding provides a mock function for the type MockConn
+func (_mock *MockConn) RequestEncoding(encoding1 encoding.Encoding) error {
+	ret := _mock.Called(encoding1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequestEncoding")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(encoding.Encoding) error); ok {
+		r0 = returnFunc(encoding1)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockConn_RequestEncoding_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RequestEncoding'
+type MockConn_RequestEncoding_Call struct {
+	*mock.Call
+}
+
+// RequestEncoding is a helper method to define mock.On call
+//   - encoding1
+func (_e *MockConn_Expecter) RequestEncoding(encoding1 interface{}) *MockConn_RequestEncoding_Call {
+	return &MockConn_RequestEncoding_Call{Call: _e.mock.On("RequestEncoding", encoding1)}
+}
+
+func (_c *MockConn_RequestEncoding_Call) Run(run func(encoding1 encoding.Encoding)) *MockConn_RequestEncoding_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(encoding.Encoding))
+	})
+	return _c
+}
+
+func (_c *MockConn_RequestEncoding_Call) Return(err error) *MockConn_RequestEncoding_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockConn_RequestEncoding_Call) RunAndReturn(run func(encoding1 encoding.Encoding) error) *MockConn_RequestEncoding_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Send provides a mock function for the type MockConn
+func (_mock *MockConn) Send(p []byte) (int, error) {
+	ret := _mock.Called(p)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Send")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func([]byte) (int, error)); ok {
+		return returnFunc(p)
+	}
+	if returnFunc, ok := ret.Get(0).(func([]byte) int); ok {
+		r0 = returnFunc(p)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func([]byte) error); ok {
+		r1 = returnFunc(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockConn_Send_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Send'
+type MockConn_Send_Call struct {
+	*mock.Call
+}
+
+// Send is a helper method to define mock.On call
+//   - p
+func (_e *MockConn_Expecter) Send(p interface{}) *MockConn_Send_Call {
+	return &MockConn_Send_Call{Call: _e.mock.On("Send", p)}
+}
+
+func (_c *MockConn_Send_Call) Run(run func(p []byte)) *MockConn_Send_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]byte))
+	})
+	return _c
+}
+
+func (_c *MockConn_Send_Call) Return(n int, err error) *MockConn_Send_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockConn_Send_Call) RunAndReturn(run func(p []byte) (int, error)) *MockConn_Send_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetDeadline provides a mock function for the type MockConn
+func (_mock *MockConn) SetDeadline(t time.Time) error {
+	ret := _mock.Called(t)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetDeadline")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(time.Time) error); ok {
+		r0 = returnFunc(t)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockConn_SetDeadline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetDeadline'
+type MockConn_SetDeadline_Call struct {
+	*mock.Call
+}
+
+// SetDeadline is a helper method to define mock.On call
+//   - t
+func (_e *MockConn_Expecter) SetDeadline(t interface{}) *MockConn_SetDeadline_Call {
+	return &MockConn_SetDeadline_Call{Call: _e.mock.On("SetDeadline", t)}
+}
+
+func (_c *MockConn_SetDeadline_Call) Run(run func(t time.Time)) *MockConn_SetDeadline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetDeadline_Call) Return(err error) *MockConn_SetDeadline_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockConn_SetDeadline_Call) RunAndReturn(run func(t time.Time) error) *MockConn_SetDeadline_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetEncoding provides a mock function for the type MockConn
+func (_mock *MockConn) SetEncoding(encoding1 encoding.Encoding) {
+	_mock.Called(encoding1)
+	return
+}
+
+// MockConn_SetEncoding_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetEncoding'
+type MockConn_SetEncoding_Call struct {
+	*mock.Call
+}
+
+// SetEncoding is a helper method to define mock.On call
+//   - encoding1
+func (_e *MockConn_Expecter) SetEncoding(encoding1 interface{}) *MockConn_SetEncoding_Call {
+	return &MockConn_SetEncoding_Call{Call: _e.mock.On("SetEncoding", encoding1)}
+}
+
+func (_c *MockConn_SetEncoding_Call) Run(run func(encoding1 encoding.Encoding)) *MockConn_SetEncoding_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(encoding.Encoding))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetEncoding_Call) Return() *MockConn_SetEncoding_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetEncoding_Call) RunAndReturn(run func(encoding1 encoding.Encoding)) *MockConn_SetEncoding_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetFlowControl provides a mock function for the type MockConn
+func (_mock *MockConn) SetFlowControl(enabled bool, restartAny bool) {
+	_mock.Called(enabled, restartAny)
+	return
+}
+
+// MockConn_SetFlowControl_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetFlowControl'
+type MockConn_SetFlowControl_Call struct {
+	*mock.Call
+}
+
+// SetFlowControl is a helper method to define mock.On call
+//   - enabled
+//   - restartAny
+func (_e *MockConn_Expecter) SetFlowControl(enabled interface{}, restartAny interface{}) *MockConn_SetFlowControl_Call {
+	return &MockConn_SetFlowControl_Call{Call: _e.mock.On("SetFlowControl", enabled, restartAny)}
+}
+
+func (_c *MockConn_SetFlowControl_Call) Run(run func(enabled bool, restartAny bool)) *MockConn_SetFlowControl_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(bool), args[1].(bool))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetFlowControl_Call) Return() *MockConn_SetFlowControl_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetFlowControl_Call) RunAndReturn(run func(enabled bool, restartAny bool)) *MockConn_SetFlowControl_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetIdleTimeout provides a mock function for the type MockConn
+func (_mock *MockConn) SetIdleTimeout(duration time.Duration) {
+	_mock.Called(duration)
+	return
+}
+
+// MockConn_SetIdleTimeout_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetIdleTimeout'
+type MockConn_SetIdleTimeout_Call struct {
+	*mock.Call
+}
+
+// SetIdleTimeout is a helper method to define mock.On call
+//   - duration
+func (_e *MockConn_Expecter) SetIdleTimeout(duration interface{}) *MockConn_SetIdleTimeout_Call {
+	return &MockConn_SetIdleTimeout_Call{Call: _e.mock.On("SetIdleTimeout", duration)}
+}
+
+func (_c *MockConn_SetIdleTimeout_Call) Run(run func(duration time.Duration)) *MockConn_SetIdleTimeout_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetIdleTimeout_Call) Return() *MockConn_SetIdleTimeout_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetIdleTimeout_Call) RunAndReturn(run func(duration time.Duration)) *MockConn_SetIdleTimeout_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetKeepAlive provides a mock function for the type MockConn
+func (_mock *MockConn) SetKeepAlive(interval time.Duration, probe KeepAliveProbe) error {
+	ret := _mock.Called(interval, probe)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetKeepAlive")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(time.Duration, KeepAliveProbe) error); ok {
+		r0 = returnFunc(interval, probe)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockConn_SetKeepAlive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetKeepAlive'
+type MockConn_SetKeepAlive_Call struct {
+	*mock.Call
+}
+
+// SetKeepAlive is a helper method to define mock.On call
+//   - interval
+//   - probe
+func (_e *MockConn_Expecter) SetKeepAlive(interval interface{}, probe interface{}) *MockConn_SetKeepAlive_Call {
+	return &MockConn_SetKeepAlive_Call{Call: _e.mock.On("SetKeepAlive", interval, probe)}
+}
+
+func (_c *MockConn_SetKeepAlive_Call) Run(run func(interval time.Duration, probe KeepAliveProbe)) *MockConn_SetKeepAlive_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Duration), args[1].(KeepAliveProbe))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetKeepAlive_Call) Return(err error) *MockConn_SetKeepAlive_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockConn_SetKeepAlive_Call) RunAndReturn(run func(interval time.Duration, probe KeepAliveProbe) error) *MockConn_SetKeepAlive_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetLimits provides a mock function for the type MockConn
+func (_mock *MockConn) SetLimits(limits Limits) {
+	_mock.Called(limits)
+	return
+}
+
+// MockConn_SetLimits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetLimits'
+type MockConn_SetLimits_Call struct {
+	*mock.Call
+}
+
+// SetLimits is a helper method to define mock.On call
+//   - limits
+func (_e *MockConn_Expecter) SetLimits(limits interface{}) *MockConn_SetLimits_Call {
+	return &MockConn_SetLimits_Call{Call: _e.mock.On("SetLimits", limits)}
+}
+
+func (_c *MockConn_SetLimits_Call) Run(run func(limits Limits)) *MockConn_SetLimits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(Limits))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetLimits_Call) Return() *MockConn_SetLimits_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetLimits_Call) RunAndReturn(run func(limits Limits)) *MockConn_SetLimits_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetLogger provides a mock function for the type MockConn
+func (_mock *MockConn) SetLogger(logger Logger) {
+	_mock.Called(logger)
+	return
+}
+
+// MockConn_SetLogger_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetLogger'
+type MockConn_SetLogger_Call struct {
+	*mock.Call
+}
+
+// SetLogger is a helper method to define mock.On call
+//   - logger
+func (_e *MockConn_Expecter) SetLogger(logger interface{}) *MockConn_SetLogger_Call {
+	return &MockConn_SetLogger_Call{Call: _e.mock.On("SetLogger", logger)}
+}
+
+func (_c *MockConn_SetLogger_Call) Run(run func(logger Logger)) *MockConn_SetLogger_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(Logger))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetLogger_Call) Return() *MockConn_SetLogger_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetLogger_Call) RunAndReturn(run func(logger Logger)) *MockConn_SetLogger_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetNewline provides a mock function for the type MockConn
+func (_mock *MockConn) SetNewline(newlineMode NewlineMode) {
+	_mock.Called(newlineMode)
+	return
+}
+
+// MockConn_SetNewline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetNewline'
+type MockConn_SetNewline_Call struct {
+	*mock.Call
+}
+
+// SetNewline is a helper method to define mock.On call
+//   - newlineMode
+func (_e *MockConn_Expecter) SetNewline(newlineMode interface{}) *MockConn_SetNewline_Call {
+	return &MockConn_SetNewline_Call{Call: _e.mock.On("SetNewline", newlineMode)}
+}
+
+func (_c *MockConn_SetNewline_Call) Run(run func(newlineMode NewlineMode)) *MockConn_SetNewline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(NewlineMode))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetNewline_Call) Return() *MockConn_SetNewline_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetNewline_Call) RunAndReturn(run func(newlineMode NewlineMode)) *MockConn_SetNewline_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetReadCipher provides a mock function for the type MockConn
+func (_mock *MockConn) SetReadCipher(stream cipher.Stream) {
+	_mock.Called(stream)
+	return
+}
+
+// MockConn_SetReadCipher_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetReadCipher'
+type MockConn_SetReadCipher_Call struct {
+	*mock.Call
+}
+
+// SetReadCipher is a helper method to define mock.On call
+//   - stream
+func (_e *MockConn_Expecter) SetReadCipher(stream interface{}) *MockConn_SetReadCipher_Call {
+	return &MockConn_SetReadCipher_Call{Call: _e.mock.On("SetReadCipher", stream)}
+}
+
+func (_c *MockConn_SetReadCipher_Call) Run(run func(stream cipher.Stream)) *MockConn_SetReadCipher_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(cipher.Stream))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetReadCipher_Call) Return() *MockConn_SetReadCipher_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetReadCipher_Call) RunAndReturn(run func(stream cipher.Stream)) *MockConn_SetReadCipher_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetReadDeadline provides a mock function for the type MockConn
+func (_mock *MockConn) SetReadDeadline(t time.Time) error {
+	ret := _mock.Called(t)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetReadDeadline")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(time.Time) error); ok {
+		r0 = returnFunc(t)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockConn_SetReadDeadline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetReadDeadline'
+type MockConn_SetReadDeadline_Call struct {
+	*mock.Call
+}
+
+// SetReadDeadline is a helper method to define mock.On call
+//   - t
+func (_e *MockConn_Expecter) SetReadDeadline(t interface{}) *MockConn_SetReadDeadline_Call {
+	return &MockConn_SetReadDeadline_Call{Call: _e.mock.On("SetReadDeadline", t)}
+}
+
+func (_c *MockConn_SetReadDeadline_Call) Run(run func(t time.Time)) *MockConn_SetReadDeadline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetReadDeadline_Call) Return(err error) *MockConn_SetReadDeadline_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockConn_SetReadDeadline_Call) RunAndReturn(run func(t time.Time) error) *MockConn_SetReadDeadline_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetReadEncoding provides a mock function for the type MockConn
+func (_mock *MockConn) SetReadEncoding(encoding1 encoding.Encoding) {
+	_mock.Called(encoding1)
+	return
+}
+
+// MockConn_SetReadEncoding_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetReadEncoding'
+type MockConn_SetReadEncoding_Call struct {
+	*mock.Call
+}
+
+// SetReadEncoding is a helper method to define mock.On call
+//   - encoding1
+func (_e *MockConn_Expecter) SetReadEncoding(encoding1 interface{}) *MockConn_SetReadEncoding_Call {
+	return &MockConn_SetReadEncoding_Call{Call: _e.mock.On("SetReadEncoding", encoding1)}
+}
+
+func (_c *MockConn_SetReadEncoding_Call) Run(run func(encoding1 encoding.Encoding)) *MockConn_SetReadEncoding_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(encoding.Encoding))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetReadEncoding_Call) Return() *MockConn_SetReadEncoding_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetReadEncoding_Call) RunAndReturn(run func(encoding1 encoding.Encoding)) *MockConn_SetReadEncoding_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetReadNewline provides a mock function for the type MockConn
+func (_mock *MockConn) SetReadNewline(newlineMode NewlineMode) {
+	_mock.Called(newlineMode)
+	return
+}
+
+// MockConn_SetReadNewline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetReadNewline'
+type MockConn_SetReadNewline_Call struct {
+	*mock.Call
+}
+
+// SetReadNewline is a helper method to define mock.On call
+//   - newlineMode
+func (_e *MockConn_Expecter) SetReadNewline(newlineMode interface{}) *MockConn_SetReadNewline_Call {
+	return &MockConn_SetReadNewline_Call{Call: _e.mock.On("SetReadNewline", newlineMode)}
+}
+
+func (_c *MockConn_SetReadNewline_Call) Run(run func(newlineMode NewlineMode)) *MockConn_SetReadNewline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(NewlineMode))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetReadNewline_Call) Return() *MockConn_SetReadNewline_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetReadNewline_Call) RunAndReturn(run func(newlineMode NewlineMode)) *MockConn_SetReadNewline_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetSlogLogger provides a mock function for the type MockConn
+func (_mock *MockConn) SetSlogLogger(logger *slog.Logger) {
+	_mock.Called(logger)
+	return
+}
+
+// MockConn_SetSlogLogger_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetSlogLogger'
+type MockConn_SetSlogLogger_Call struct {
+	*mock.Call
+}
+
+// SetSlogLogger is a helper method to define mock.On call
+//   - logger
+func (_e *MockConn_Expecter) SetSlogLogger(logger interface{}) *MockConn_SetSlogLogger_Call {
+	return &MockConn_SetSlogLogger_Call{Call: _e.mock.On("SetSlogLogger", logger)}
+}
+
+func (_c *MockConn_SetSlogLogger_Call) Run(run func(logger *slog.Logger)) *MockConn_SetSlogLogger_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*slog.Logger))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetSlogLogger_Call) Return() *MockConn_SetSlogLogger_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetSlogLogger_Call) RunAndReturn(run func(logger *slog.Logger)) *MockConn_SetSlogLogger_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetWriteCipher provides a mock function for the type MockConn
+func (_mock *MockConn) SetWriteCipher(stream cipher.Stream) {
+	_mock.Called(stream)
+	return
+}
+
+// MockConn_SetWriteCipher_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWriteCipher'
+type MockConn_SetWriteCipher_Call struct {
+	*mock.Call
+}
+
+// SetWriteCipher is a helper method to define mock.On call
+//   - stream
+func (_e *MockConn_Expecter) SetWriteCipher(stream interface{}) *MockConn_SetWriteCipher_Call {
+	return &MockConn_SetWriteCipher_Call{Call: _e.mock.On("SetWriteCipher", stream)}
+}
+
+func (_c *MockConn_SetWriteCipher_Call) Run(run func(stream cipher.Stream)) *MockConn_SetWriteCipher_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(cipher.Stream))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetWriteCipher_Call) Return() *MockConn_SetWriteCipher_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetWriteCipher_Call) RunAndReturn(run func(stream cipher.Stream)) *MockConn_SetWriteCipher_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetWriteDeadline provides a mock function for the type MockConn
+func (_mock *MockConn) SetWriteDeadline(t time.Time) error {
+	ret := _mock.Called(t)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetWriteDeadline")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(time.Time) error); ok {
+		r0 = returnFunc(t)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockConn_SetWriteDeadline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWriteDeadline'
+type MockConn_SetWriteDeadline_Call struct {
+	*mock.Call
+}
+
+// SetWriteDeadline is a helper method to define mock.On call
+//   - t
+func (_e *MockConn_Expecter) SetWriteDeadline(t interface{}) *MockConn_SetWriteDeadline_Call {
+	return &MockConn_SetWriteDeadline_Call{Call: _e.mock.On("SetWriteDeadline", t)}
+}
+
+func (_c *MockConn_SetWriteDeadline_Call) Run(run func(t time.Time)) *MockConn_SetWriteDeadline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetWriteDeadline_Call) Return(err error) *MockConn_SetWriteDeadline_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockConn_SetWriteDeadline_Call) RunAndReturn(run func(t time.Time) error) *MockConn_SetWriteDeadline_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetWriteEncoding provides a mock function for the type MockConn
+func (_mock *MockConn) SetWriteEncoding(encoding1 encoding.Encoding) {
+	_mock.Called(encoding1)
+	return
+}
+
+// MockConn_SetWriteEncoding_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWriteEncoding'
+type MockConn_SetWriteEncoding_Call struct {
+	*mock.Call
+}
+
+// SetWriteEncoding is a helper method to define mock.On call
+//   - encoding1
+func (_e *MockConn_Expecter) SetWriteEncoding(encoding1 interface{}) *MockConn_SetWriteEncoding_Call {
+	return &MockConn_SetWriteEncoding_Call{Call: _e.mock.On("SetWriteEncoding", encoding1)}
+}
+
+func (_c *MockConn_SetWriteEncoding_Call) Run(run func(encoding1 encoding.Encoding)) *MockConn_SetWriteEncoding_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(encoding.Encoding))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetWriteEncoding_Call) Return() *MockConn_SetWriteEncoding_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetWriteEncoding_Call) RunAndReturn(run func(encoding1 encoding.Encoding)) *MockConn_SetWriteEncoding_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetWriteNewline provides a mock function for the type MockConn
+func (_mock *MockConn) SetWriteNewline(newlineMode NewlineMode) {
+	_mock.Called(newlineMode)
+	return
+}
+
+// MockConn_SetWriteNewline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWriteNewline'
+type MockConn_SetWriteNewline_Call struct {
+	*mock.Call
+}
+
+// SetWriteNewline is a helper method to define mock.On call
+//   - newlineMode
+func (_e *MockConn_Expecter) SetWriteNewline(newlineMode interface{}) *MockConn_SetWriteNewline_Call {
+	return &MockConn_SetWriteNewline_Call{Call: _e.mock.On("SetWriteNewline", newlineMode)}
+}
+
+func (_c *MockConn_SetWriteNewline_Call) Run(run func(newlineMode NewlineMode)) *MockConn_SetWriteNewline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(NewlineMode))
+	})
+	return _c
+}
+
+func (_c *MockConn_SetWriteNewline_Call) Return() *MockConn_SetWriteNewline_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SetWriteNewline_Call) RunAndReturn(run func(newlineMode NewlineMode)) *MockConn_SetWriteNewline_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Shutdown provides a mock function for the type MockConn
+func (_mock *MockConn) Shutdown(context1 context.Context) error {
+	ret := _mock.Called(context1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Shutdown")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = returnFunc(context1)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockConn_Shutdown_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Shutdown'
+type MockConn_Shutdown_Call struct {
+	*mock.Call
+}
+
+// Shutdown is a helper method to define mock.On call
+//   - context1
+func (_e *MockConn_Expecter) Shutdown(context1 interface{}) *MockConn_Shutdown_Call {
+	return &MockConn_Shutdown_Call{Call: _e.mock.On("Shutdown", context1)}
+}
+
+func (_c *MockConn_Shutdown_Call) Run(run func(context1 context.Context)) *MockConn_Shutdown_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockConn_Shutdown_Call) Return(err error) *MockConn_Shutdown_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockConn_Shutdown_Call) RunAndReturn(run func(context1 context.Context) error) *MockConn_Shutdown_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SplitAtEdits provides a mock function for the type MockConn
+func (_mock *MockConn) SplitAtEdits(enabled bool) {
+	_mock.Called(enabled)
+	return
+}
+
+// MockConn_SplitAtEdits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SplitAtEdits'
+type MockConn_SplitAtEdits_Call struct {
+	*mock.Call
+}
+
+// SplitAtEdits is a helper method to define mock.On call
+//   - enabled
+func (_e *MockConn_Expecter) SplitAtEdits(enabled interface{}) *MockConn_SplitAtEdits_Call {
+	return &MockConn_SplitAtEdits_Call{Call: _e.mock.On("SplitAtEdits", enabled)}
+}
+
+func (_c *MockConn_SplitAtEdits_Call) Run(run func(enabled bool)) *MockConn_SplitAtEdits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(bool))
+	})
+	return _c
+}
+
+func (_c *MockConn_SplitAtEdits_Call) Return() *MockConn_SplitAtEdits_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SplitAtEdits_Call) RunAndReturn(run func(enabled bool)) *MockConn_SplitAtEdits_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SplitAtPrompts provides a mock function for the type MockConn
+func (_mock *MockConn) SplitAtPrompts(enabled bool) {
+	_mock.Called(enabled)
+	return
+}
+
+// MockConn_SplitAtPrompts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SplitAtPrompts'
+type MockConn_SplitAtPrompts_Call struct {
+	*mock.Call
+}
+
+// SplitAtPrompts is a helper method to define mock.On call
+//   - enabled
+func (_e *MockConn_Expecter) SplitAtPrompts(enabled interface{}) *MockConn_SplitAtPrompts_Call {
+	return &MockConn_SplitAtPrompts_Call{Call: _e.mock.On("SplitAtPrompts", enabled)}
+}
+
+func (_c *MockConn_SplitAtPrompts_Call) Run(run func(enabled bool)) *MockConn_SplitAtPrompts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(bool))
+	})
+	return _c
+}
+
+func (_c *MockConn_SplitAtPrompts_Call) Return() *MockConn_SplitAtPrompts_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SplitAtPrompts_Call) RunAndReturn(run func(enabled bool)) *MockConn_SplitAtPrompts_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SuppressGoAhead provides a mock function for the type MockConn
+func (_mock *MockConn) SuppressGoAhead(enabled bool) {
+	_mock.Called(enabled)
+	return
+}
+
+// MockConn_SuppressGoAhead_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SuppressGoAhead'
+type MockConn_SuppressGoAhead_Call struct {
+	*mock.Call
+}
+
+// SuppressGoAhead is a helper method to define mock.On call
+//   - enabled
+func (_e *MockConn_Expecter) SuppressGoAhead(enabled interface{}) *MockConn_SuppressGoAhead_Call {
+	return &MockConn_SuppressGoAhead_Call{Call: _e.mock.On("SuppressGoAhead", enabled)}
+}
+
+func (_c *MockConn_SuppressGoAhead_Call) Run(run func(enabled bool)) *MockConn_SuppressGoAhead_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(bool))
+	})
+	return _c
+}
+
+func (_c *MockConn_SuppressGoAhead_Call) Return() *MockConn_SuppressGoAhead_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockConn_SuppressGoAhead_Call) RunAndReturn(run func(enabled bool)) *MockConn_SuppressGoAhead_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Write provides a mock function for the type MockConn
+func (_mock *MockConn) Write(b []byte) (int, error) {
+	ret := _mock.Called(b)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Write")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func([]byte) (int, error)); ok {
+		return returnFunc(b)
+	}
+	if returnFunc, ok := ret.Get(0).(func([]byte) int); ok {
+		r0 = returnFunc(b)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func([]byte) error); ok {
+		r1 = returnFunc(b)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockConn_Write_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Write'
+type MockConn_Write_Call struct {
+	*mock.Call
+}
+
+// Write is a helper method to define mock.On call
+//   - b
+func (_e *MockConn_Expecter) Write(b interface{}) *MockConn_Write_Call {
+	return &MockConn_Write_Call{Call: _e.mock.On("Write", b)}
+}
+
+func (_c *MockConn_Write_Call) Run(run func(b []byte)) *MockConn_Write_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]byte))
+	})
+	return _c
+}
+
+func (_c *MockConn_Write_Call) Return(n int, err error) *MockConn_Write_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockConn_Write_Call) RunAndReturn(run func(b []byte) (int, error)) *MockConn_Write_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockCipher creates a new instance of MockCipher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockCipher(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockCipher {
+	mock := &MockCipher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockCipher is an autogenerated mock type for the Cipher type
+type MockCipher struct {
+	mock.Mock
+}
+
+type MockCipher_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockCipher) EXPECT() *MockCipher_Expecter {
+	return &MockCipher_Expecter{mock: &_m.Mock}
+}
+
+// Decrypter provides a mock function for the type MockCipher
+func (_mock *MockCipher) Decrypter() cipher.Stream {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Decrypter")
+	}
+
+	var r0 cipher.Stream
+	if returnFunc, ok := ret.Get(0).(func() cipher.Stream); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(cipher.Stream)
+		}
+	}
+	return r0
+}
+
+// MockCipher_Decrypter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Decrypter'
+type MockCipher_Decrypter_Call struct {
+	*mock.Call
+}
+
+// Decrypter is a helper method to define mock.On call
+func (_e *MockCipher_Expecter) Decrypter() *MockCipher_Decrypter_Call {
+	return &MockCipher_Decrypter_Call{Call: _e.mock.On("Decrypter")}
+}
+
+func (_c *MockCipher_Decrypter_Call) Run(run func()) *MockCipher_Decrypter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockCipher_Decrypter_Call) Return(stream cipher.Stream) *MockCipher_Decrypter_Call {
+	_c.Call.Return(stream)
+	return _c
+}
+
+func (_c *MockCipher_Decrypter_Call) RunAndReturn(run func() cipher.Stream) *MockCipher_Decrypter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Encrypter provides a mock function for the type MockCipher
+func (_mock *MockCipher) Encrypter() cipher.Stream {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Encrypter")
+	}
+
+	var r0 cipher.Stream
+	if returnFunc, ok := ret.Get(0).(func() cipher.Stream); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(cipher.Stream)
+		}
+	}
+	return r0
+}
+
+// MockCipher_Encrypter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Encrypter'
+type MockCipher_Encrypter_Call struct {
+	*mock.Call
+}
+
+// Encrypter is a helper method to define mock.On call
+func (_e *MockCipher_Expecter) Encrypter() *MockCipher_Encrypter_Call {
+	return &MockCipher_Encrypter_Call{Call: _e.mock.On("Encrypter")}
+}
+
+func (_c *MockCipher_Encrypter_Call) Run(run func()) *MockCipher_Encrypter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockCipher_Encrypter_Call) Return(stream cipher.Stream) *MockCipher_Encrypter_Call {
+	_c.Call.Return(stream)
+	return _c
+}
+
+func (_c *MockCipher_Encrypter_Call) RunAndReturn(run func() cipher.Stream) *MockCipher_Encrypter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Is provides a mock function for the type MockCipher
+func (_mock *MockCipher) Is(data []byte) ([]byte, bool, error) {
+	ret := _mock.Called(data)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Is")
+	}
+
+	var r0 []byte
+	var r1 bool
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func([]byte) ([]byte, bool, error)); ok {
+		return returnFunc(data)
+	}
+	if returnFunc, ok := ret.Get(0).(func([]byte) []byte); ok {
+		r0 = returnFunc(data)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func([]byte) bool); ok {
+		r1 = returnFunc(data)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+	if returnFunc, ok := ret.Get(2).(func([]byte) error); ok {
+		r2 = returnFunc(data)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockCipher_Is_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Is'
+type MockCipher_Is_Call struct {
+	*mock.Call
+}
+
+// Is is a helper method to define mock.On call
+//   - data
+func (_e *MockCipher_Expecter) Is(data interface{}) *MockCipher_Is_Call {
+	return &MockCipher_Is_Call{Call: _e.mock.On("Is", data)}
+}
+
+func (_c *MockCipher_Is_Call) Run(run func(data []byte)) *MockCipher_Is_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]byte))
+	})
+	return _c
+}
+
+func (_c *MockCipher_Is_Call) Return(reply []byte, ready bool, err error) *MockCipher_Is_Call {
+	_c.Call.Return(reply, ready, err)
+	return _c
+}
+
+func (_c *MockCipher_Is_Call) RunAndReturn(run func(data []byte) ([]byte, bool, error)) *MockCipher_Is_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Reply provides a mock function for the type MockCipher
+func (_mock *MockCipher) Reply(data []byte) ([]byte, bool, error) {
+	ret := _mock.Called(data)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reply")
+	}
+
+	var r0 []byte
+	var r1 bool
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func([]byte) ([]byte, bool, error)); ok {
+		return returnFunc(data)
+	}
+	if returnFunc, ok := ret.Get(0).(func([]byte) []byte); ok {
+		r0 = returnFunc(data)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func([]byte) bool); ok {
+		r1 = returnFunc(data)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+	if returnFunc, ok := ret.Get(2).(func([]byte) error); ok {
+		r2 = returnFunc(data)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockCipher_Reply_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Reply'
+type MockCipher_Reply_Call struct {
+	*mock.Call
+}
+
+// Reply is a helper method to define mock.On call
+//   - data
+func (_e *MockCipher_Expecter) Reply(data interface{}) *MockCipher_Reply_Call {
+	return &MockCipher_Reply_Call{Call: _e.mock.On("Reply", data)}
+}
+
+func (_c *MockCipher_Reply_Call) Run(run func(data []byte)) *MockCipher_Reply_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]byte))
+	})
+	return _c
+}
+
+func (_c *MockCipher_Reply_Call) Return(next []byte, ready bool, err error) *MockCipher_Reply_Call {
+	_c.Call.Return(next, ready, err)
+	return _c
+}
+
+func (_c *MockCipher_Reply_Call) RunAndReturn(run func(data []byte) ([]byte, bool, error)) *MockCipher_Reply_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetKey provides a mock function for the type MockCipher
+func (_mock *MockCipher) SetKey(key []byte) {
+	_mock.Called(key)
+	return
+}
+
+// MockCipher_SetKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetKey'
+type MockCipher_SetKey_Call struct {
+	*mock.Call
+}
+
+// SetKey is a helper method to define mock.On call
+//   - key
+func (_e *MockCipher_Expecter) SetKey(key interface{}) *MockCipher_SetKey_Call {
+	return &MockCipher_SetKey_Call{Call: _e.mock.On("SetKey", key)}
+}
+
+func (_c *MockCipher_SetKey_Call) Run(run func(key []byte)) *MockCipher_SetKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]byte))
+	})
+	return _c
+}
+
+func (_c *MockCipher_SetKey_Call) Return() *MockCipher_SetKey_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockCipher_SetKey_Call) RunAndReturn(run func(key []byte)) *MockCipher_SetKey_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Start provides a mock function for the type MockCipher
+func (_mock *MockCipher) Start() ([]byte, error) {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Start")
+	}
+
+	var r0 []byte
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func() ([]byte, error)); ok {
+		return returnFunc()
+	}
+	if returnFunc, ok := ret.Get(0).(func() []byte); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func() error); ok {
+		r1 = returnFunc()
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCipher_Start_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Start'
+type MockCipher_Start_Call struct {
+	*mock.Call
+}
+
+// Start is a helper method to define mock.On call
+func (_e *MockCipher_Expecter) Start() *MockCipher_Start_Call {
+	return &MockCipher_Start_Call{Call: _e.mock.On("Start")}
+}
+
+func (_c *MockCipher_Start_Call) Run(run func()) *MockCipher_Start_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockCipher_Start_Call) Return(bytes []byte, err error) *MockCipher_Start_Call {
+	_c.Call.Return(bytes, err)
+	return _c
+}
+
+func (_c *MockCipher_Start_Call) RunAndReturn(run func() ([]byte, error)) *MockCipher_Start_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Type provides a mock function for the type MockCipher
+func (_mock *MockCipher) Type() byte {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Type")
+	}
+
+	var r0 byte
+	if returnFunc, ok := ret.Get(0).(func() byte); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(byte)
+	}
+	return r0
+}
+
+// MockCipher_Type_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Type'
+type MockCipher_Type_Call struct {
+	*mock.Call
+}
+
+// Type is a helper method to define mock.On call
+func (_e *MockCipher_Expecter) Type() *MockCipher_Type_Call {
+	return &MockCipher_Type_Call{Call: _e.mock.On("Type")}
+}
+
+func (_c *MockCipher_Type_Call) Run(run func()) *MockCipher_Type_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockCipher_Type_Call) Return(v byte) *MockCipher_Type_Call {
+	_c.Call.Return(v)
+	return _c
+}
+
+func (_c *MockCipher_Type_Call) RunAndReturn(run func() byte) *MockCipher_Type_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockEventListener creates a new instance of MockEventListener. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockEventListener(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockEventListener {
+	mock := &MockEventListener{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockEventListener is an autogenerated mock type for the EventListener type
+type MockEventListener struct {
+	mock.Mock
+}
+
+type MockEventListener_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockEventListener) EXPECT() *MockEventListener_Expecter {
+	return &MockEventListener_Expecter{mock: &_m.Mock}
+}
+
+// HandleEvent provides a mock function for the type MockEventListener
+func (_mock *MockEventListener) HandleEvent(v any) {
+	_mock.Called(v)
+	return
+}
+
+// MockEventListener_HandleEvent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HandleEvent'
+type MockEventListener_HandleEvent_Call struct {
+	*mock.Call
+}
+
+// HandleEvent is a helper method to define mock.On call
+//   - v
+func (_e *MockEventListener_Expecter) HandleEvent(v interface{}) *MockEventListener_HandleEvent_Call {
+	return &MockEventListener_HandleEvent_Call{Call: _e.mock.On("HandleEvent", v)}
+}
+
+func (_c *MockEventListener_HandleEvent_Call) Run(run func(v any)) *MockEventListener_HandleEvent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(any))
+	})
+	return _c
+}
+
+func (_c *MockEventListener_HandleEvent_Call) Return() *MockEventListener_HandleEvent_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockEventListener_HandleEvent_Call) RunAndReturn(run func(v any)) *MockEventListener_HandleEvent_Call {
+	_c.Run(run)
+	return _c
+}
+
+// NewMockEventSink creates a new instance of MockEventSink. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockEventSink(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockEventSink {
+	mock := &MockEventSink{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockEventSink is an autogenerated mock type for the EventSink type
+type MockEventSink struct {
+	mock.Mock
+}
+
+type MockEventSink_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockEventSink) EXPECT() *MockEventSink_Expecter {
+	return &MockEventSink_Expecter{mock: &_m.Mock}
+}
+
+// SendEvent provides a mock function for the type MockEventSink
+func (_mock *MockEventSink) SendEvent(event string, data any) {
+	_mock.Called(event, data)
 	return
 }
 
-// MockConn_SetWriteEncoding_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWriteEncoding'
-type MockConn_SetWriteEncoding_Call struct {
+// MockEventSink_SendEvent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SendEvent'
+type MockEventSink_SendEvent_Call struct {
 	*mock.Call
 }
 
-// SetWriteEncoding is a helper method to define mock.On call
-//   - encoding1
-func (_e *MockConn_Expecter) SetWriteEncoding(encoding1 interface{}) *MockConn_SetWriteEncoding_Call {
-	return &MockConn_SetWriteEncoding_Call{Call: _e.mock.On("SetWriteEncoding", encoding1)}
+// SendEvent is a helper method to define mock.On call
+//   - event
+//   - data
+func (_e *MockEventSink_Expecter) SendEvent(event interface{}, data interface{}) *MockEventSink_SendEvent_Call {
+	return &MockEventSink_SendEvent_Call{Call: _e.mock.On("SendEvent", event, data)}
 }
 
-func (_c *MockConn_SetWriteEncoding_Call) Run(run func(encoding1 encoding.Encoding)) *MockConn_SetWriteEncoding_Call {
+func (_c *MockEventSink_SendEvent_Call) Run(run func(event string, data any)) *MockEventSink_SendEvent_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(encoding.Encoding))
+		run(args[0].(string), args[1].(any))
 	})
 	return _c
 }
 
-func (_c *MockConn_SetWriteEncoding_Call) Return() *MockConn_SetWriteEncoding_Call {
+func (_c *MockEventSink_SendEvent_Call) Return() *MockEventSink_SendEvent_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *MockConn_SetWriteEncoding_Call) RunAndReturn(run func(encoding1 encoding.Encoding)) *MockConn_SetWriteEncoding_Call {
+func (_c *MockEventSink_SendEvent_Call) RunAndReturn(run func(event string, data any)) *MockEventSink_SendEvent_Call {
 	_c.Run(run)
 	return _c
 }
 
-// SuppressGoAhead provides a mock function for the type MockConn
-func (_mock *MockConn) SuppressGoAhead(enabled bool) {
-	_mock.Called(enabled)
-	return
-}
+// NewMockpinger creates a new instance of Mockpinger. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockpinger(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Mockpinger {
+	mock := &Mockpinger{}
+	mock.Mock.Test(t)
 
-// MockConn_SuppressGoAhead_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SuppressGoAhead'
-type MockConn_SuppressGoAhead_Call struct {
-	*mock.Call
-}
+	t.Cleanup(func() { mock.AssertExpectations(t) })
 
-// SuppressGoAhead is a helper method to define mock.On call
-//   - enabled
-func (_e *MockConn_Expecter) SuppressGoAhead(enabled interface{}) *MockConn_SuppressGoAhead_Call {
-	return &MockConn_SuppressGoAhead_Call{Call: _e.mock.On("SuppressGoAhead", enabled)}
+	return mock
 }
 
-func (_c *MockConn_SuppressGoAhead_Call) Run(run func(enabled bool)) *MockConn_SuppressGoAhead_Call {
-	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(bool))
-	})
-	return _c
+// Mockpinger is an autogenerated mock type for the pinger type
+type Mockpinger struct {
+	mock.Mock
 }
 
-func (_c *MockConn_SuppressGoAhead_Call) Return() *MockConn_SuppressGoAhead_Call {
-	_c.Call.Return()
-	return _c
+type Mockpinger_Expecter struct {
+	mock *mock.Mock
 }
 
-func (_c *MockConn_SuppressGoAhead_Call) RunAndReturn(run func(enabled bool)) *MockConn_SuppressGoAhead_Call {
-	_c.Run(run)
-	return _c
+func (_m *Mockpinger) EXPECT() *Mockpinger_Expecter {
+	return &Mockpinger_Expecter{mock: &_m.Mock}
 }
 
-// Write provides a mock function for the type MockConn
-func (_mock *MockConn) Write(b []byte) (int, error) {
-	ret := _mock.Called(b)
+// Ping provides a mock function for the type Mockpinger
+func (_mock *Mockpinger) Ping(context1 context.Context) (time.Duration, error) {
+	ret := _mock.Called(context1)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Write")
+		panic("no return value specified for Ping")
 	}
 
-	var r0 int
+	var r0 time.Duration
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func([]byte) (int, error)); ok {
-		return returnFunc(b)
+	if returnFunc, ok := ret.Get(0).(func(context.Context) (time.Duration, error)); ok {
+		return returnFunc(context1)
 	}
-	if returnFunc, ok := ret.Get(0).(func([]byte) int); ok {
-		r0 = returnFunc(b)
+	if returnFunc, ok := ret.Get(0).(func(context.Context) time.Duration); ok {
+		r0 = returnFunc(context1)
 	} else {
-		r0 = ret.Get(0).(int)
+		r0 = ret.Get(0).(time.Duration)
 	}
-	if returnFunc, ok := ret.Get(1).(func([]byte) error); ok {
-		r1 = returnFunc(b)
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(context1)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockConn_Write_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Write'
-type MockConn_Write_Call struct {
+// Mockpinger_Ping_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Ping'
+type Mockpinger_Ping_Call struct {
 	*mock.Call
 }
 
-// Write is a helper method to define mock.On call
-//   - b
-func (_e *MockConn_Expecter) Write(b interface{}) *MockConn_Write_Call {
-	return &MockConn_Write_Call{Call: _e.mock.On("Write", b)}
+// Ping is a helper method to define mock.On call
+//   - context1
+func (_e *Mockpinger_Expecter) Ping(context1 interface{}) *Mockpinger_Ping_Call {
+	return &Mockpinger_Ping_Call{Call: _e.mock.On("Ping", context1)}
 }
 
-func (_c *MockConn_Write_Call) Run(run func(b []byte)) *MockConn_Write_Call {
+func (_c *Mockpinger_Ping_Call) Run(run func(context1 context.Context)) *Mockpinger_Ping_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].([]byte))
+		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *MockConn_Write_Call) Return(n int, err error) *MockConn_Write_Call {
-	_c.Call.Return(n, err)
+func (_c *Mockpinger_Ping_Call) Return(duration time.Duration, err error) *Mockpinger_Ping_Call {
+	_c.Call.Return(duration, err)
 	return _c
 }
 
-func (_c *MockConn_Write_Call) RunAndReturn(run func(b []byte) (int, error)) *MockConn_Write_Call {
+func (_c *Mockpinger_Ping_Call) RunAndReturn(run func(context1 context.Context) (time.Duration, error)) *Mockpinger_Ping_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -1041,13 +2886,13 @@ func (_c *MockLogger_Logf_Call) RunAndReturn(run func(fmt string, v ...any)) *Mo
 	return _c
 }
 
-// NewMockEventListener creates a new instance of MockEventListener. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// NewMocklogouter creates a new instance of Mocklogouter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
-func NewMockEventListener(t interface {
+func NewMocklogouter(t interface {
 	mock.TestingT
 	Cleanup(func())
-}) *MockEventListener {
-	mock := &MockEventListener{}
+}) *Mocklogouter {
+	mock := &Mocklogouter{}
 	mock.Mock.Test(t)
 
 	t.Cleanup(func() { mock.AssertExpectations(t) })
@@ -1055,60 +2900,80 @@ func NewMockEventListener(t interface {
 	return mock
 }
 
-// MockEventListener is an autogenerated mock type for the EventListener type
-type MockEventListener struct {
+// Mocklogouter is an autogenerated mock type for the logouter type
+type Mocklogouter struct {
 	mock.Mock
 }
 
-type MockEventListener_Expecter struct {
+type Mocklogouter_Expecter struct {
 	mock *mock.Mock
 }
 
-func (_m *MockEventListener) EXPECT() *MockEventListener_Expecter {
-	return &MockEventListener_Expecter{mock: &_m.Mock}
+func (_m *Mocklogouter) EXPECT() *Mocklogouter_Expecter {
+	return &Mocklogouter_Expecter{mock: &_m.Mock}
 }
 
-// HandleEvent provides a mock function for the type MockEventListener
-func (_mock *MockEventListener) HandleEvent(v any) {
-	_mock.Called(v)
-	return
+// Logout provides a mock function for the type Mocklogouter
+func (_mock *Mocklogouter) Logout(context1 context.Context) (bool, error) {
+	ret := _mock.Called(context1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) (bool, error)); ok {
+		return returnFunc(context1)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) bool); ok {
+		r0 = returnFunc(context1)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(context1)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockEventListener_HandleEvent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HandleEvent'
-type MockEventListener_HandleEvent_Call struct {
+// Mocklogouter_Logout_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Logout'
+type Mocklogouter_Logout_Call struct {
 	*mock.Call
 }
 
-// HandleEvent is a helper method to define mock.On call
-//   - v
-func (_e *MockEventListener_Expecter) HandleEvent(v interface{}) *MockEventListener_HandleEvent_Call {
-	return &MockEventListener_HandleEvent_Call{Call: _e.mock.On("HandleEvent", v)}
+// Logout is a helper method to define mock.On call
+//   - context1
+func (_e *Mocklogouter_Expecter) Logout(context1 interface{}) *Mocklogouter_Logout_Call {
+	return &Mocklogouter_Logout_Call{Call: _e.mock.On("Logout", context1)}
 }
 
-func (_c *MockEventListener_HandleEvent_Call) Run(run func(v any)) *MockEventListener_HandleEvent_Call {
+func (_c *Mocklogouter_Logout_Call) Run(run func(context1 context.Context)) *Mocklogouter_Logout_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(any))
+		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *MockEventListener_HandleEvent_Call) Return() *MockEventListener_HandleEvent_Call {
-	_c.Call.Return()
+func (_c *Mocklogouter_Logout_Call) Return(b bool, err error) *Mocklogouter_Logout_Call {
+	_c.Call.Return(b, err)
 	return _c
 }
 
-func (_c *MockEventListener_HandleEvent_Call) RunAndReturn(run func(v any)) *MockEventListener_HandleEvent_Call {
-	_c.Run(run)
+func (_c *Mocklogouter_Logout_Call) RunAndReturn(run func(context1 context.Context) (bool, error)) *Mocklogouter_Logout_Call {
+	_c.Call.Return(run)
 	return _c
 }
 
-// NewMockEventSink creates a new instance of MockEventSink. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// NewMockbinaryConn creates a new instance of MockbinaryConn. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
-func NewMockEventSink(t interface {
+func NewMockbinaryConn(t interface {
 	mock.TestingT
 	Cleanup(func())
-}) *MockEventSink {
-	mock := &MockEventSink{}
+}) *MockbinaryConn {
+	mock := &MockbinaryConn{}
 	mock.Mock.Test(t)
 
 	t.Cleanup(func() { mock.AssertExpectations(t) })
@@ -1116,50 +2981,50 @@ func NewMockEventSink(t interface {
 	return mock
 }
 
-// MockEventSink is an autogenerated mock type for the EventSink type
-type MockEventSink struct {
+// MockbinaryConn is an autogenerated mock type for the binaryConn type
+type MockbinaryConn struct {
 	mock.Mock
 }
 
-type MockEventSink_Expecter struct {
+type MockbinaryConn_Expecter struct {
 	mock *mock.Mock
 }
 
-func (_m *MockEventSink) EXPECT() *MockEventSink_Expecter {
-	return &MockEventSink_Expecter{mock: &_m.Mock}
+func (_m *MockbinaryConn) EXPECT() *MockbinaryConn_Expecter {
+	return &MockbinaryConn_Expecter{mock: &_m.Mock}
 }
 
-// SendEvent provides a mock function for the type MockEventSink
-func (_mock *MockEventSink) SendEvent(event string, data any) {
-	_mock.Called(event, data)
+// setBinary provides a mock function for the type MockbinaryConn
+func (_mock *MockbinaryConn) setBinary(read bool, write bool) {
+	_mock.Called(read, write)
 	return
 }
 
-// MockEventSink_SendEvent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SendEvent'
-type MockEventSink_SendEvent_Call struct {
+// MockbinaryConn_setBinary_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'setBinary'
+type MockbinaryConn_setBinary_Call struct {
 	*mock.Call
 }
 
-// SendEvent is a helper method to define mock.On call
-//   - event
-//   - data
-func (_e *MockEventSink_Expecter) SendEvent(event interface{}, data interface{}) *MockEventSink_SendEvent_Call {
-	return &MockEventSink_SendEvent_Call{Call: _e.mock.On("SendEvent", event, data)}
+// setBinary is a helper method to define mock.On call
+//   - read
+//   - write
+func (_e *MockbinaryConn_Expecter) setBinary(read interface{}, write interface{}) *MockbinaryConn_setBinary_Call {
+	return &MockbinaryConn_setBinary_Call{Call: _e.mock.On("setBinary", read, write)}
 }
 
-func (_c *MockEventSink_SendEvent_Call) Run(run func(event string, data any)) *MockEventSink_SendEvent_Call {
+func (_c *MockbinaryConn_setBinary_Call) Run(run func(read bool, write bool)) *MockbinaryConn_setBinary_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(any))
+		run(args[0].(bool), args[1].(bool))
 	})
 	return _c
 }
 
-func (_c *MockEventSink_SendEvent_Call) Return() *MockEventSink_SendEvent_Call {
+func (_c *MockbinaryConn_setBinary_Call) Return() *MockbinaryConn_setBinary_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *MockEventSink_SendEvent_Call) RunAndReturn(run func(event string, data any)) *MockEventSink_SendEvent_Call {
+func (_c *MockbinaryConn_setBinary_Call) RunAndReturn(run func(read bool, write bool)) *MockbinaryConn_setBinary_Call {
 	_c.Run(run)
 	return _c
 }
@@ -1695,6 +3560,50 @@ func (_c *MockOption_enableUs_Call) RunAndReturn(run func() error) *MockOption_e
 	return _c
 }
 
+// negotiating provides a mock function for the type MockOption
+func (_mock *MockOption) negotiating() bool {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for negotiating")
+	}
+
+	var r0 bool
+	if returnFunc, ok := ret.Get(0).(func() bool); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	return r0
+}
+
+// MockOption_negotiating_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'negotiating'
+type MockOption_negotiating_Call struct {
+	*mock.Call
+}
+
+// negotiating is a helper method to define mock.On call
+func (_e *MockOption_Expecter) negotiating() *MockOption_negotiating_Call {
+	return &MockOption_negotiating_Call{Call: _e.mock.On("negotiating")}
+}
+
+func (_c *MockOption_negotiating_Call) Run(run func()) *MockOption_negotiating_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockOption_negotiating_Call) Return(b bool) *MockOption_negotiating_Call {
+	_c.Call.Return(b)
+	return _c
+}
+
+func (_c *MockOption_negotiating_Call) RunAndReturn(run func() bool) *MockOption_negotiating_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // receive provides a mock function for the type MockOption
 func (_mock *MockOption) receive(c byte) error {
 	ret := _mock.Called(c)
@@ -1739,3 +3648,121 @@ func (_c *MockOption_receive_Call) RunAndReturn(run func(c byte) error) *MockOpt
 	_c.Call.Return(run)
 	return _c
 }
+
+// refuse provides a mock function for the type MockOption
+func (_mock *MockOption) refuse(c byte) error {
+	ret := _mock.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for refuse")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(byte) error); ok {
+		r0 = returnFunc(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockOption_refuse_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'refuse'
+type MockOption_refuse_Call struct {
+	*mock.Call
+}
+
+// refuse is a helper method to define mock.On call
+//   - c
+func (_e *MockOption_Expecter) refuse(c interface{}) *MockOption_refuse_Call {
+	return &MockOption_refuse_Call{Call: _e.mock.On("refuse", c)}
+}
+
+func (_c *MockOption_refuse_Call) Run(run func(c byte)) *MockOption_refuse_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(byte))
+	})
+	return _c
+}
+
+func (_c *MockOption_refuse_Call) Return(err error) *MockOption_refuse_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockOption_refuse_Call) RunAndReturn(run func(c byte) error) *MockOption_refuse_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockStatusReporter creates a new instance of MockStatusReporter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockStatusReporter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockStatusReporter {
+	mock := &MockStatusReporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockStatusReporter is an autogenerated mock type for the StatusReporter type
+type MockStatusReporter struct {
+	mock.Mock
+}
+
+type MockStatusReporter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockStatusReporter) EXPECT() *MockStatusReporter_Expecter {
+	return &MockStatusReporter_Expecter{mock: &_m.Mock}
+}
+
+// Status provides a mock function for the type MockStatusReporter
+func (_mock *MockStatusReporter) Status() []byte {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Status")
+	}
+
+	var r0 []byte
+	if returnFunc, ok := ret.Get(0).(func() []byte); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+	return r0
+}
+
+// MockStatusReporter_Status_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Status'
+type MockStatusReporter_Status_Call struct {
+	*mock.Call
+}
+
+// Status is a helper method to define mock.On call
+func (_e *MockStatusReporter_Expecter) Status() *MockStatusReporter_Status_Call {
+	return &MockStatusReporter_Status_Call{Call: _e.mock.On("Status")}
+}
+
+func (_c *MockStatusReporter_Status_Call) Run(run func()) *MockStatusReporter_Status_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockStatusReporter_Status_Call) Return(bytes []byte) *MockStatusReporter_Status_Call {
+	_c.Call.Return(bytes)
+	return _c
+}
+
+func (_c *MockStatusReporter_Status_Call) RunAndReturn(run func() []byte) *MockStatusReporter_Status_Call {
+	_c.Call.Return(run)
+	return _c
+}
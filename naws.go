@@ -0,0 +1,84 @@
+package telnet
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+// NAWSOption implements RFC 1073. As a client it sends our window size
+// whenever it is enabled or changes, and as a server it keeps track of the
+// client's window size. SetWindowSize can be called from any goroutine.
+type NAWSOption struct {
+	Option
+
+	mu                    sync.Mutex
+	width, height         uint16
+	peerWidth, peerHeight uint16
+}
+
+func NewNAWSOption() *NAWSOption {
+	return &NAWSOption{Option: NewOption(NAWS)}
+}
+
+func (n *NAWSOption) Bind(conn Conn, sink EventSink) {
+	n.Option.Bind(conn, sink)
+	conn.AddListener("update-option", n)
+}
+
+// SetWindowSize sets our window size, sending it to the server if it wants
+// it.
+func (n *NAWSOption) SetWindowSize(width, height uint16) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.width, n.height = width, height
+	if !n.EnabledForUs() {
+		return nil
+	}
+	return n.send()
+}
+
+// WindowSize returns the peer's window size, which is zero until it has sent
+// it to us.
+func (n *NAWSOption) WindowSize() (width, height uint16) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.peerWidth, n.peerHeight
+}
+
+func (n *NAWSOption) HandleEvent(data any) {
+	event, ok := data.(UpdateOptionEvent)
+	if !ok || event.Option.Byte() != NAWS {
+		return
+	}
+	if event.WeChanged && event.EnabledForUs() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		n.send()
+	}
+}
+
+func (n *NAWSOption) Subnegotiation(buf []byte) {
+	logSubnegotiation(n.Conn(), logRecv, n.Byte(), nil, buf)
+	if !n.EnabledForThem() || len(buf) != 4 {
+		return
+	}
+	event := WindowSizeEvent{binary.BigEndian.Uint16(buf[0:2]), binary.BigEndian.Uint16(buf[2:4])}
+	n.mu.Lock()
+	n.peerWidth, n.peerHeight = event.Width, event.Height
+	n.mu.Unlock()
+	n.Sink().SendEvent("window-size", event)
+}
+
+// send sends our window size. The caller holds n.mu, so that sizes go out in
+// the order they were set.
+func (n *NAWSOption) send() error {
+	data := binary.BigEndian.AppendUint16(nil, n.width)
+	data = binary.BigEndian.AppendUint16(data, n.height)
+	logSubnegotiation(n.Conn(), logSend, n.Byte(), nil, data)
+	_, err := n.Conn().Send(subnegotiation(n.Byte(), data))
+	return err
+}
+
+type WindowSizeEvent struct {
+	Width, Height uint16
+}
@@ -0,0 +1,52 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNAWSServer(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, NAWS})
+	in.Write([]byte{IAC, SB, NAWS, 0, 80, 0, 24, IAC, SE})
+	in.Write([]byte{IAC, SB, NAWS, 1, 0, IAC, IAC, 50, IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewNAWSOption()
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	var events []any
+	conn.AddListener("window-size", FuncListener{func(data any) { events = append(events, data) }})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{WindowSizeEvent{80, 24}, WindowSizeEvent{256, 0xff32}}, events)
+	width, height := option.WindowSize()
+	assert.Equal(t, uint16(256), width)
+	assert.Equal(t, uint16(0xff32), height)
+	assert.Equal(t, []byte{IAC, DO, NAWS}, out.Bytes())
+}
+
+func TestNAWSClient(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, NAWS})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewNAWSOption()
+	option.Allow(false, true)
+	assert.NoError(t, option.SetWindowSize(80, 24))
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.NoError(t, option.SetWindowSize(132, 255))
+	assert.Equal(t, []byte{
+		IAC, WILL, NAWS,
+		IAC, SB, NAWS, 0, 80, 0, 24, IAC, SE,
+		IAC, SB, NAWS, 0, 132, 0, IAC, IAC, IAC, SE,
+	}, out.Bytes())
+}
@@ -0,0 +1,76 @@
+package telnet
+
+// NewlineMode is how line endings are represented on the wire in one
+// direction. By default we read NewlineBareLF, since plenty of clients send a
+// bare LF, and write NewlineStrict. Whatever the mode, while TRANSMIT-BINARY is
+// enabled in a direction, newlines in that direction are raw.
+type NewlineMode int
+
+const (
+	// NewlineStrict is RFC 854: a newline is CR LF and a carriage return is
+	// CR NUL. When reading, a bare CR or LF is dropped.
+	NewlineStrict NewlineMode = iota
+
+	// NewlineBareLF is NewlineStrict, except that a newline is a bare LF.
+	// When reading, CR LF is accepted as well.
+	NewlineBareLF
+
+	// NewlineBareCR is NewlineStrict, except that a newline is a bare CR.
+	// When reading, a CR followed by LF or NUL, and a bare LF, are accepted
+	// as well.
+	NewlineBareCR
+
+	// NewlineRaw passes CR and LF through untouched.
+	NewlineRaw
+)
+
+func (m NewlineMode) String() string {
+	switch m {
+	case NewlineStrict:
+		return "strict"
+	case NewlineBareLF:
+		return "bare-lf"
+	case NewlineBareCR:
+		return "bare-cr"
+	case NewlineRaw:
+		return "raw"
+	default:
+		return "unknown"
+	}
+}
+
+func (c *connection) SetNewline(mode NewlineMode) {
+	c.SetReadNewline(mode)
+	c.SetWriteNewline(mode)
+}
+
+func (c *connection) SetReadNewline(mode NewlineMode) {
+	c.readNewline = mode
+	c.updateNewlines()
+}
+
+func (c *connection) SetWriteNewline(mode NewlineMode) {
+	c.writeNewline = mode
+	c.updateNewlines()
+}
+
+// binaryConn is implemented by connections that need to know when
+// TRANSMIT-BINARY is enabled.
+type binaryConn interface {
+	setBinary(read, write bool)
+}
+
+func (c *connection) setBinary(read, write bool) {
+	c.readBinary, c.writeBinary = read, write
+	c.updateNewlines()
+}
+
+func (c *connection) updateNewlines() {
+	c.reader.newline, c.nvt.newline = c.readNewline, c.writeNewline
+	if c.readBinary {
+		c.reader.newline = NewlineRaw
+	}
+	if c.writeBinary {
+		c.nvt.newline = NewlineRaw
+	}
+}
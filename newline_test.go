@@ -0,0 +1,72 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadNewline(t *testing.T) {
+	const in = "a\r\nb\r\x00c\nd\re\r\rf"
+	var tests = []struct {
+		mode     NewlineMode
+		expected string
+	}{
+		{NewlineStrict, "a\nb\rcdef"},
+		{NewlineBareLF, "a\nb\rc\ndef"},
+		{NewlineBareCR, "a\nb\nc\nd\ne\n\nf"},
+		{NewlineRaw, in},
+	}
+	for _, test := range tests {
+		conn := newTestConn(bytes.NewBufferString(in), nil)
+		conn.SetReadNewline(test.mode)
+		data, err := io.ReadAll(conn)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, string(data), test.mode.String())
+	}
+}
+
+func TestWriteNewline(t *testing.T) {
+	var tests = []struct {
+		mode     NewlineMode
+		expected []byte
+	}{
+		{NewlineStrict, []byte{'a', '\r', '\n', 'b', '\r', 0, IAC, IAC}},
+		{NewlineBareLF, []byte{'a', '\n', 'b', '\r', 0, IAC, IAC}},
+		{NewlineBareCR, []byte{'a', '\r', 'b', '\r', 0, IAC, IAC}},
+		{NewlineRaw, []byte{'a', '\n', 'b', '\r', IAC, IAC}},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		conn := newTestConn(nil, &out)
+		conn.SuppressGoAhead(true)
+		conn.SetEncoding(Binary)
+		conn.SetWriteNewline(test.mode)
+		_, err := conn.Write([]byte{'a', '\n', 'b', '\r', IAC})
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, out.Bytes(), test.mode.String())
+	}
+}
+
+func TestTransmitBinaryNewlines(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, TransmitBinary, IAC, DO, TransmitBinary})
+	in.WriteString("a\r\nb")
+	in.Write([]byte{IAC, WONT, TransmitBinary})
+	in.WriteString("\r\nc")
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+	conn.SuppressGoAhead(true)
+	option := NewTransmitBinaryOption()
+	option.Allow(true, true)
+	conn.BindOption(option)
+
+	data, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\r\nb\nc", string(data))
+
+	out.Reset()
+	conn.Write([]byte("x\n"))
+	assert.Equal(t, []byte("x\n"), out.Bytes())
+}
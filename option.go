@@ -1,6 +1,9 @@
 package telnet
 
-import "math"
+import (
+	"math"
+	"sync"
+)
 
 type Option interface {
 	Allow(them, us bool)
@@ -16,7 +19,9 @@ type Option interface {
 	disableUs() error
 	enableThem() error
 	enableUs() error
+	negotiating() bool
 	receive(c byte) error
+	refuse(c byte) error
 }
 
 func newOptionMap() *optionMap {
@@ -50,7 +55,11 @@ type option struct {
 	sink               EventSink
 	code               byte
 	allowUs, allowThem bool
-	us, them           telnetQState
+
+	// mu guards the negotiation state, since the reader answers the peer while
+	// the application may be asking for changes of its own.
+	mu       sync.Mutex
+	us, them telnetQState
 }
 
 func NewOption(c byte) *option {
@@ -62,11 +71,21 @@ func (o *option) Bind(conn Conn, sink EventSink) { o.conn, o.sink = conn, sink }
 func (o *option) Byte() byte                     { return o.code }
 func (o *option) Conn() Conn                     { return o.conn }
 func (o *option) Sink() EventSink                { return o.sink }
-func (o *option) EnabledForThem() bool           { return telnetQYes == o.them }
-func (o *option) EnabledForUs() bool             { return telnetQYes == o.us }
+
+func (o *option) EnabledForThem() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return telnetQYes == o.them
+}
+
+func (o *option) EnabledForUs() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return telnetQYes == o.us
+}
 
 func (o *option) Subnegotiation(bytes []byte) {
-	o.conn.Logf("RECV: IAC SB %s %q IAC SE", optionByte(o.Byte()), bytes)
+	logSubnegotiation(o.conn, logRecv, o.Byte(), nil, bytes)
 }
 
 func (o *option) disableThem() error {
@@ -78,6 +97,8 @@ func (o *option) disableUs() error {
 }
 
 func (o *option) disable(state *telnetQState, cmd byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
 	switch *state {
 	case telnetQNo:
 		// ignore
@@ -103,7 +124,10 @@ func (o *option) enableThem() error {
 func (o *option) enableUs() error {
 	return o.enable(&o.us, WILL)
 }
+
 func (o *option) enable(state *telnetQState, cmd byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
 	switch *state {
 	case telnetQNo:
 		*state = telnetQWantYesEmpty
@@ -122,20 +146,41 @@ func (o *option) enable(state *telnetQState, cmd byte) error {
 	return nil
 }
 
-func (o *option) receive(c byte) (err error) {
-	us, them := o.EnabledForUs(), o.EnabledForThem()
+// negotiating says whether we're waiting for the peer to answer a request of
+// ours.
+func (o *option) negotiating() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.us >= telnetQWantNoEmpty || o.them >= telnetQWantNoEmpty
+}
+
+func (o *option) receive(c byte) error {
+	return o.receiveAllowing(c, true)
+}
+
+// refuse answers c as though the option weren't allowed, so a DO or WILL the
+// peer sends us gets a WONT or DONT, unless it answers a request of ours.
+func (o *option) refuse(c byte) error {
+	return o.receiveAllowing(c, false)
+}
+
+func (o *option) receiveAllowing(c byte, allow bool) (err error) {
+	o.mu.Lock()
+	us, them := o.us, o.them
 	switch c {
 	case DO:
-		err = o.receiveEnableRequest(&o.us, o.allowUs, WILL, WONT)
+		err = o.receiveEnableRequest(&o.us, allow && o.allowUs, WILL, WONT)
 	case DONT:
 		err = o.receiveDisableDemand(&o.us, WILL, WONT)
 	case WILL:
-		err = o.receiveEnableRequest(&o.them, o.allowThem, DO, DONT)
+		err = o.receiveEnableRequest(&o.them, allow && o.allowThem, DO, DONT)
 	case WONT:
 		err = o.receiveDisableDemand(&o.them, DO, DONT)
 	}
-	weChanged := o.EnabledForUs() != us
-	theyChanged := o.EnabledForThem() != them
+	weChanged := (o.us == telnetQYes) != (us == telnetQYes)
+	theyChanged := (o.them == telnetQYes) != (them == telnetQYes)
+	// Listeners are free to ask for changes of their own.
+	o.mu.Unlock()
 	if theyChanged || weChanged {
 		o.sink.SendEvent("update-option", UpdateOptionEvent{o, theyChanged, weChanged})
 	}
@@ -187,7 +232,7 @@ func (o *option) receiveDisableDemand(state *telnetQState, accept, reject byte)
 }
 
 func (o *option) sendOptionCommand(cmd, opt byte) error {
-	o.Conn().Logf("SEND: IAC %s %s", commandByte(cmd), optionByte(opt))
+	logOptionCommand(o.Conn(), logSend, cmd, opt)
 	_, err := o.Conn().Send([]byte{IAC, cmd, opt})
 	return err
 }
@@ -5,7 +5,6 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 )
 
 type qMethodTest struct {
@@ -62,7 +61,7 @@ func TestQMethodReceive(t *testing.T) {
 		o.us, o.them = telnetQNo, telnetQNo
 		*q.state, *q.allow = q.start, q.permitted
 		if q.expected != 0 {
-			conn.EXPECT().Logf(mock.Anything, []any{commandByte(q.expected), optionByte(o.code)})
+			expectOptionCommandLog(conn, logSend, q.expected, o.code)
 			expected := []byte{IAC, q.expected, o.code}
 			conn.EXPECT().Send(expected).Return(len(expected), nil)
 		}
@@ -130,7 +129,7 @@ func TestQMethodEnableOrDisable(t *testing.T) {
 		testMsg := fmt.Sprintf("test %s %s %s", action, who, q.start)
 		*q.state = q.start
 		if q.expected != 0 {
-			conn.EXPECT().Logf(mock.Anything, []any{commandByte(q.expected), optionByte(o.code)})
+			expectOptionCommandLog(conn, logSend, q.expected, o.code)
 			expected := []byte{IAC, q.expected, o.code}
 			conn.EXPECT().Send(expected).Return(len(expected), nil)
 		}
@@ -0,0 +1,190 @@
+package telnet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+type ProxyDirection int
+
+const (
+	ClientToServer ProxyDirection = iota
+	ServerToClient
+)
+
+func (d ProxyDirection) String() string {
+	switch d {
+	case ClientToServer:
+		return "client->server"
+	case ServerToClient:
+		return "server->client"
+	default:
+		return "unknown"
+	}
+}
+
+type FrameType int
+
+const (
+	DataFrame FrameType = iota
+	CommandFrame
+	OptionFrame
+	SubnegotiationFrame
+)
+
+// Frame is a piece of telnet traffic: a chunk of data, a command such as IP or
+// GA, an option command such as IAC DO ECHO, or a subnegotiation. Command is
+// set for the second and third, Option for the third and fourth, and Data for
+// the first and fourth. A data frame's Data is just as it was on the wire, less
+// the doubling of IAC, so line endings are whatever the sender used (CR LF, CR
+// NUL, or anything at all in binary mode).
+type Frame struct {
+	Type    FrameType
+	Command byte
+	Option  byte
+	Data    []byte
+}
+
+// Bytes returns the frame as it goes over the wire.
+func (f Frame) Bytes() []byte {
+	switch f.Type {
+	case DataFrame:
+		var buf bytes.Buffer
+		(&writer{out: &buf, newline: NewlineRaw}).Write(f.Data)
+		return buf.Bytes()
+	case CommandFrame:
+		return []byte{IAC, f.Command}
+	case OptionFrame:
+		return []byte{IAC, f.Command, f.Option}
+	case SubnegotiationFrame:
+		return subnegotiation(f.Option, f.Data)
+	default:
+		return nil
+	}
+}
+
+func newFrame(cmd any) (Frame, error) {
+	switch t := cmd.(type) {
+	case *telnetGoAhead:
+		return Frame{Type: CommandFrame, Command: GA}, nil
+	case *telnetCommand:
+		return Frame{Type: CommandFrame, Command: t.cmd}, nil
+	case *telnetOptionCommand:
+		return Frame{Type: OptionFrame, Command: t.cmd, Option: t.opt}, nil
+	case *telnetSubnegotiation:
+		return Frame{Type: SubnegotiationFrame, Option: t.opt, Data: t.bytes}, nil
+	default:
+		return Frame{}, fmt.Errorf("telnet: unknown command %T", cmd)
+	}
+}
+
+// ProxyHook is called with each frame a Proxy relays, and returns the frames to
+// send in its place: nil to drop it, or more than one to inject some. It is
+// called from a goroutine for each direction of each connection.
+type ProxyHook func(dir ProxyDirection, f Frame) []Frame
+
+// Proxy relays telnet connections to an upstream server, decoding the traffic
+// in both directions so that a hook can inspect and rewrite it. Data is relayed
+// byte for byte, so the proxy is transparent whether or not TRANSMIT-BINARY is
+// on. A subnegotiation longer than DefaultMaxSubnegotiation is dropped, as a
+// Conn would drop it.
+type Proxy struct {
+	upstream string
+	hook     ProxyHook
+}
+
+func NewProxy(upstream string, hook ProxyHook) *Proxy {
+	return &Proxy{upstream: upstream, hook: hook}
+}
+
+// Serve relays each connection accepted on l until l is closed.
+func (p *Proxy) Serve(l net.Listener) error {
+	for {
+		client, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go p.Relay(client)
+	}
+}
+
+// Relay connects client to the upstream server and relays traffic between the
+// two until either closes its connection, then closes both.
+func (p *Proxy) Relay(client net.Conn) error {
+	defer client.Close()
+	server, err := net.Dial("tcp", p.upstream)
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	var clientErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		clientErr = p.relay(ClientToServer, client, server)
+		server.Close()
+	}()
+	err = p.relay(ServerToClient, server, client)
+	client.Close()
+	wg.Wait()
+
+	if err == nil || errors.Is(err, net.ErrClosed) {
+		err = clientErr
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		err = nil
+	}
+	return err
+}
+
+// errProxyCommand makes the reader stop at each command, so that it comes out
+// in order with the data around it.
+var errProxyCommand = errors.New("command")
+
+func (p *Proxy) relay(dir ProxyDirection, src io.Reader, dst io.Writer) error {
+	var cmd any
+	r := newReader(src, func(c any) error {
+		cmd = c
+		return errProxyCommand
+	})
+	r.newline = NewlineRaw
+	r.maxSubnegotiation = DefaultMaxSubnegotiation
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			data := bytes.Clone(buf[:n])
+			if err := p.forward(dir, dst, Frame{Type: DataFrame, Data: data}); err != nil {
+				return err
+			}
+		}
+		if err == errProxyCommand {
+			var f Frame
+			if f, err = newFrame(cmd); err == nil {
+				err = p.forward(dir, dst, f)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Proxy) forward(dir ProxyDirection, dst io.Writer, f Frame) error {
+	frames := []Frame{f}
+	if p.hook != nil {
+		frames = p.hook(dir, f)
+	}
+	for _, f := range frames {
+		if _, err := dst.Write(f.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
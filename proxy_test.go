@@ -0,0 +1,151 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameBytes(t *testing.T) {
+	var tests = []struct {
+		frame    Frame
+		expected []byte
+	}{
+		{Frame{Type: DataFrame, Data: []byte{'a', '\n', IAC, '\r'}}, []byte{'a', '\n', IAC, IAC, '\r'}},
+		{Frame{Type: CommandFrame, Command: GA}, []byte{IAC, GA}},
+		{Frame{Type: OptionFrame, Command: DO, Option: Echo}, []byte{IAC, DO, Echo}},
+		{Frame{Type: SubnegotiationFrame, Option: NAWS, Data: []byte{0, IAC}}, []byte{IAC, SB, NAWS, 0, IAC, IAC, IAC, SE}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.frame.Bytes())
+	}
+}
+
+func TestProxy(t *testing.T) {
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer upstream.Close()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	var mu sync.Mutex
+	var seen []Frame
+	proxy := NewProxy(upstream.Addr().String(), func(dir ProxyDirection, f Frame) []Frame {
+		if dir == ServerToClient {
+			mu.Lock()
+			seen = append(seen, f)
+			mu.Unlock()
+			return []Frame{f}
+		}
+		switch {
+		case f.Type == DataFrame:
+			f.Data = bytes.ReplaceAll(f.Data, []byte("foo"), []byte("bar"))
+			return []Frame{f}
+		case f.Type == CommandFrame && f.Command == IP:
+			return nil
+		case f.Type == OptionFrame:
+			return []Frame{f, {Type: CommandFrame, Command: NOP}}
+		}
+		return []Frame{f}
+	})
+	go proxy.Serve(listener)
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer client.Close()
+	server, err := upstream.Accept()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer server.Close()
+
+	expect := func(r io.Reader, expected []byte) {
+		buf := make([]byte, len(expected))
+		_, err := io.ReadFull(r, buf)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, buf)
+	}
+
+	client.Write([]byte{'f', 'o', 'o', IAC, IP, IAC, DO, Echo})
+	expect(server, []byte{'b', 'a', 'r', IAC, DO, Echo, IAC, NOP})
+
+	fromServer := []byte{IAC, SB, TerminalType, optionSend, IAC, SE, 'h', 'i', '\r', '\n', IAC, GA}
+	server.Write(fromServer)
+	expect(client, fromServer)
+
+	server.Close()
+	_, err = client.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []Frame{
+		{Type: SubnegotiationFrame, Option: TerminalType, Data: []byte{optionSend}},
+		{Type: DataFrame, Data: []byte("hi\r\n")},
+		{Type: CommandFrame, Command: GA},
+	}, seen)
+}
+
+func TestProxyBinary(t *testing.T) {
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer upstream.Close()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	go NewProxy(upstream.Addr().String(), nil).Serve(listener)
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer client.Close()
+	server, err := upstream.Accept()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer server.Close()
+
+	// Every byte, with IAC doubled, and a bare CR and LF on either end.
+	var data []byte
+	data = append(data, IAC, WILL, TransmitBinary, IAC, DO, TransmitBinary, '\r', 'x', '\n')
+	for i := 0; i < 256; i++ {
+		if data = append(data, byte(i)); i == IAC {
+			data = append(data, IAC)
+		}
+	}
+	data = append(data, '\n', '\r', 0, '\r')
+
+	for _, pair := range [][2]net.Conn{{client, server}, {server, client}} {
+		go pair[0].Write(data)
+		buf := make([]byte, len(data))
+		_, err := io.ReadFull(pair[1], buf)
+		assert.NoError(t, err)
+		assert.Equal(t, data, buf)
+	}
+}
+
+func TestProxySubnegotiationLimit(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, SB, NAWS})
+	in.Write(make([]byte, DefaultMaxSubnegotiation+1))
+	in.Write([]byte{IAC, SE, 'h', 'i'})
+	var out bytes.Buffer
+	err := NewProxy("", nil).relay(ClientToServer, in, &out)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "hi", out.String())
+}
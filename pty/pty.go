@@ -0,0 +1,255 @@
+//go:build linux
+
+// Package pty runs local programs behind a telnet session.
+package pty
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/stesla/telnet"
+	"golang.org/x/sys/unix"
+)
+
+// Session is a command running under a pseudo-terminal whose other end is a
+// telnet connection. The window size from NAWS, IP, and the state of ECHO and
+// LINEMODE are passed on to the terminal as they change. A LINEMODE client that
+// edits lines itself puts the terminal in canonical mode, so the command reads
+// the lines it sends.
+type Session struct {
+	conn   telnet.Conn
+	cmd    *exec.Cmd
+	ptm    *os.File
+	output chan struct{}
+	input  chan struct{}
+
+	// icanon is the terminal's ICANON setting from before the client started
+	// editing lines, if it has.
+	icanon *bool
+}
+
+// Run starts cmd with Start and waits for it to finish.
+func Run(conn telnet.Conn, cmd *exec.Cmd) error {
+	s, err := Start(conn, cmd)
+	if err != nil {
+		return err
+	}
+	return s.Wait()
+}
+
+// Start starts cmd with a new pseudo-terminal as its controlling terminal and
+// its standard input and output, unless they are already set. TERM is set from
+// the TERMINAL-TYPE option, and the window size from the NAWS option, so those
+// should be negotiated first. From then on the session reads from conn, so
+// nothing else should.
+func Start(conn telnet.Conn, cmd *exec.Cmd) (*Session, error) {
+	ptm, pts, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer pts.Close()
+
+	s := &Session{conn: conn, cmd: cmd, ptm: ptm, output: make(chan struct{}), input: make(chan struct{})}
+
+	if opt, ok := conn.Option(telnet.TerminalType).(*telnet.TerminalTypeOption); ok && opt.TerminalType() != "" {
+		env := cmd.Env
+		if env == nil {
+			env = os.Environ()
+		}
+		cmd.Env = append(env, "TERM="+strings.ToLower(opt.TerminalType()))
+	}
+	if opt, ok := conn.Option(telnet.NAWS).(*telnet.NAWSOption); ok {
+		if width, height := opt.WindowSize(); width > 0 && height > 0 {
+			if err := s.setWindowSize(width, height); err != nil {
+				ptm.Close()
+				return nil, err
+			}
+		}
+	}
+	if err := s.updateTermios(); err != nil {
+		ptm.Close()
+		return nil, err
+	}
+
+	if cmd.Stdin == nil {
+		cmd.Stdin = pts
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = pts
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = pts
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+	if err := cmd.Start(); err != nil {
+		ptm.Close()
+		return nil, err
+	}
+
+	for _, event := range []string{"command", "linemode", "update-option", "window-size"} {
+		conn.AddListener(event, s)
+	}
+	go func() {
+		// Once the command exits this fails with EIO.
+		io.Copy(conn, ptm)
+		close(s.output)
+	}()
+	go func() {
+		io.Copy(ptm, conn)
+		close(s.input)
+	}()
+	return s, nil
+}
+
+// Wait waits for the command to exit and for all of its output to be sent.
+// It then stops reading from conn, so that the caller can go back to it.
+func (s *Session) Wait() error {
+	err := s.cmd.Wait()
+	<-s.output
+	// The read deadline wakes the copy up if it's waiting on conn.
+	if s.conn.SetReadDeadline(time.Now()) == nil {
+		<-s.input
+		s.conn.SetReadDeadline(time.Time{})
+	}
+	for _, event := range []string{"command", "linemode", "update-option", "window-size"} {
+		s.conn.RemoveListener(event, s)
+	}
+	s.ptm.Close()
+	return err
+}
+
+func (s *Session) HandleEvent(data any) {
+	switch t := data.(type) {
+	case telnet.CommandEvent:
+		if t.Command == telnet.IP {
+			s.interrupt()
+		}
+	case telnet.UpdateOptionEvent:
+		switch t.Option.Byte() {
+		case telnet.Echo, telnet.Linemode:
+			s.updateTermios()
+		}
+	case telnet.LinemodeEvent:
+		s.updateTermios()
+	case telnet.WindowSizeEvent:
+		s.setWindowSize(t.Width, t.Height)
+	}
+}
+
+func (s *Session) control(fn func(fd int) error) error {
+	return control(s.ptm, fn)
+}
+
+// control calls fn with f's descriptor, without taking f out of non-blocking
+// mode the way f.Fd would.
+func control(f *os.File, fn func(fd int) error) error {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var ferr error
+	if err := rc.Control(func(fd uintptr) { ferr = fn(int(fd)) }); err != nil {
+		return err
+	}
+	return ferr
+}
+
+// interrupt sends SIGINT to the terminal's foreground process group, which is
+// what the terminal itself would do on ^C.
+func (s *Session) interrupt() {
+	var pgrp int
+	err := s.control(func(fd int) (err error) {
+		pgrp, err = unix.IoctlGetInt(fd, unix.TIOCGPGRP)
+		return
+	})
+	if err != nil || pgrp <= 0 {
+		s.cmd.Process.Signal(syscall.SIGINT)
+		return
+	}
+	unix.Kill(-pgrp, unix.SIGINT)
+}
+
+func (s *Session) setWindowSize(width, height uint16) error {
+	return s.control(func(fd int) error {
+		return unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{Row: height, Col: width})
+	})
+}
+
+// updateTermios makes the terminal echo only when we've told the client that
+// we will, and it isn't doing its own line editing. While the client is
+// editing, the terminal is canonical. It also leaves newlines alone, since the
+// telnet connection turns them into CR LF itself.
+func (s *Session) updateTermios() error {
+	edit := s.clientEdits()
+	echo := s.conn.Option(telnet.Echo).EnabledForUs() && !edit
+	return s.control(func(fd int) error {
+		t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+		if err != nil {
+			return err
+		}
+		if echo {
+			t.Lflag |= unix.ECHO
+		} else {
+			t.Lflag &^= unix.ECHO
+		}
+		switch {
+		case edit && s.icanon == nil:
+			icanon := t.Lflag&unix.ICANON != 0
+			s.icanon = &icanon
+			t.Lflag |= unix.ICANON
+		case !edit && s.icanon != nil:
+			if !*s.icanon {
+				t.Lflag &^= unix.ICANON
+			}
+			s.icanon = nil
+		}
+		t.Oflag &^= unix.ONLCR
+		return unix.IoctlSetTermios(fd, unix.TCSETS, t)
+	})
+}
+
+// clientEdits reports whether the client is doing its own line editing: it
+// has LINEMODE on, and if we know the mode, it includes EDIT.
+func (s *Session) clientEdits() bool {
+	opt := s.conn.Option(telnet.Linemode)
+	if !opt.EnabledForThem() {
+		return false
+	}
+	if linemode, ok := opt.(*telnet.LinemodeOption); ok {
+		return linemode.Edit()
+	}
+	return true
+}
+
+func open() (ptm, pts *os.File, err error) {
+	ptm, err = os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var n uint32
+	err = control(ptm, func(fd int) (err error) {
+		if err = unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+			return
+		}
+		n, err = unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+		return
+	})
+	if err == nil {
+		pts, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY, 0)
+	}
+	if err != nil {
+		ptm.Close()
+		return nil, nil, err
+	}
+	return ptm, pts, nil
+}
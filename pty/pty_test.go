@@ -0,0 +1,140 @@
+//go:build linux
+
+package pty
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stesla/telnet"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func newSession() (conn telnet.Conn, remote net.Conn, output func() []byte) {
+	local, remote := net.Pipe()
+	conn = telnet.New(local)
+	conn.SuppressGoAhead(true)
+
+	naws := telnet.NewNAWSOption()
+	naws.Allow(true, false)
+	conn.BindOption(naws)
+	ttype := telnet.NewTerminalTypeOption()
+	ttype.Allow(true, false)
+	conn.BindOption(ttype)
+
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&buf, remote)
+		close(done)
+	}()
+	output = func() []byte {
+		conn.Close()
+		<-done
+		return buf.Bytes()
+	}
+	return
+}
+
+func TestRun(t *testing.T) {
+	conn, remote, output := newSession()
+	defer remote.Close()
+
+	go func() {
+		remote.Write([]byte{telnet.IAC, telnet.WILL, telnet.NAWS, telnet.IAC, telnet.WILL, telnet.TerminalType})
+		remote.Write([]byte{telnet.IAC, telnet.SB, telnet.NAWS, 0, 100, 0, 40, telnet.IAC, telnet.SE})
+		remote.Write([]byte{telnet.IAC, telnet.SB, telnet.TerminalType, 0, 'X', 'T', 'E', 'R', 'M', telnet.IAC, telnet.SE})
+		remote.Write([]byte("."))
+	}()
+	_, err := io.ReadFull(conn, make([]byte, 1))
+	assert.NoError(t, err)
+
+	err = Run(conn, exec.Command("/bin/sh", "-c", "echo $TERM; stty size"))
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasSuffix(output(), []byte("xterm\r\n40 100\r\n")))
+}
+
+func TestInterrupt(t *testing.T) {
+	conn, remote, output := newSession()
+	defer remote.Close()
+
+	cmd := exec.Command("sleep", "10")
+	s, err := Start(conn, cmd)
+	assert.NoError(t, err)
+	go remote.Write([]byte{telnet.IAC, telnet.IP})
+
+	err = s.Wait()
+	if assert.Error(t, err) {
+		status := cmd.ProcessState.Sys().(syscall.WaitStatus)
+		assert.Equal(t, syscall.SIGINT, status.Signal())
+	}
+	output()
+}
+
+func TestReadAfterWait(t *testing.T) {
+	conn, remote, output := newSession()
+	defer remote.Close()
+
+	err := Run(conn, exec.Command("true"))
+	assert.NoError(t, err)
+
+	go remote.Write([]byte("after\r\n"))
+	buf := make([]byte, 16)
+	n, err := conn.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "after\n", string(buf[:n]))
+	output()
+}
+
+func TestLinemodeEdit(t *testing.T) {
+	conn, remote, output := newSession()
+	defer remote.Close()
+	linemode := telnet.NewLinemodeOption(telnet.LinemodeEdit)
+	linemode.Allow(true, false)
+	conn.BindOption(linemode)
+
+	cmd := exec.Command("sleep", "10")
+	s, err := Start(conn, cmd)
+	assert.NoError(t, err)
+	icanon := func() bool {
+		var lflag uint32
+		s.control(func(fd int) error {
+			t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+			lflag = t.Lflag
+			return err
+		})
+		return lflag&unix.ICANON != 0
+	}
+	setICANON := func(on bool) {
+		s.control(func(fd int) error {
+			t, _ := unix.IoctlGetTermios(fd, unix.TCGETS)
+			if on {
+				t.Lflag |= unix.ICANON
+			} else {
+				t.Lflag &^= unix.ICANON
+			}
+			return unix.IoctlSetTermios(fd, unix.TCSETS, t)
+		})
+	}
+
+	// The command has put the terminal in raw mode, and then the client
+	// starts editing lines itself.
+	setICANON(false)
+	remote.Write([]byte{telnet.IAC, telnet.WILL, telnet.Linemode})
+	remote.Write([]byte{telnet.IAC, telnet.SB, telnet.Linemode, 1, telnet.LinemodeEdit | 4, telnet.IAC, telnet.SE})
+	assert.Eventually(t, icanon, time.Second, time.Millisecond)
+
+	// When it stops, the terminal goes back the way it was.
+	remote.Write([]byte{telnet.IAC, telnet.SB, telnet.Linemode, 1, 4, telnet.IAC, telnet.SE})
+	assert.Eventually(t, func() bool { return !icanon() }, time.Second, time.Millisecond)
+
+	cmd.Process.Kill()
+	s.Wait()
+	output()
+}
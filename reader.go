@@ -1,25 +1,53 @@
 package telnet
 
 import (
+	"crypto/cipher"
 	"io"
 )
 
 func NewReader(r io.Reader, fn func(any) error) io.Reader {
-	result := &reader{in: r, cmdfn: fn}
+	return newReader(r, fn)
+}
+
+func newReader(r io.Reader, fn func(any) error) *reader {
+	result := &reader{in: r, cmdfn: fn, newline: NewlineBareLF}
 	result.state = result.decodeByte
 	return result
 }
 
 type reader struct {
-	in    io.Reader
-	b     []byte
-	state readerState
-	cmdfn func(any) error
+	in     io.Reader
+	b      []byte
+	state  readerState
+	cmdfn  func(any) error
+	stream cipher.Stream
+
+	newline NewlineMode
+
+	// When splitPrompts is set, a GA or EOR that follows some data is handled
+	// at the start of the next Read instead, so that whoever is reading has
+	// already seen the data that came before the prompt when it is handled.
+	// The same goes for EC and EL when splitEdits is set.
+	splitPrompts bool
+	splitEdits   bool
+	deferred     any
+
+	// When a subnegotiation grows past maxSubnegotiation (if it isn't zero),
+	// overflow says whether to keep what we have so far. Either way, the rest
+	// is discarded.
+	maxSubnegotiation int
+	overflow          func(opt byte) (keep bool, err error)
 }
 
 type readerState func(byte) (readerState, byte, bool, error)
 
 func (r *reader) Read(p []byte) (n int, err error) {
+	if cmd := r.deferred; cmd != nil {
+		r.deferred = nil
+		if err = r.handleCommand(cmd); err != nil {
+			return
+		}
+	}
 	if len(r.b) == 0 {
 		var n int
 		r.b = make([]byte, len(p))
@@ -27,15 +55,34 @@ func (r *reader) Read(p []byte) (n int, err error) {
 		r.b = r.b[:n]
 	}
 	for len(r.b) > 0 && n < len(p) {
-		state, c, ok, err := r.state(r.b[0])
+		// We decrypt one byte at a time, because a command handler can start
+		// or stop decryption partway through the buffer.
+		if r.stream != nil {
+			r.stream.XORKeyStream(r.b[:1], r.b[:1])
+		}
+		state, c, ok, cerr := r.state(r.b[0])
 		r.b = r.b[1:]
 		r.state = state
 		if ok {
 			p[n] = c
 			n++
 		}
-		if err != nil {
-			return n, err
+		if cerr != nil {
+			return n, cerr
+		}
+		if cmd := r.deferred; cmd != nil {
+			if n > 0 {
+				// We'll handle it at the start of the next Read, which also
+				// has to see the rest of the buffer before any error.
+				if len(r.b) > 0 {
+					err = nil
+				}
+				break
+			}
+			r.deferred = nil
+			if cerr := r.handleCommand(cmd); cerr != nil {
+				return n, cerr
+			}
 		}
 	}
 	return
@@ -45,11 +92,28 @@ func (r *reader) decodeByte(c byte) (readerState, byte, bool, error) {
 	switch c {
 	case IAC:
 		return r.decodeCommand, c, false, nil
-	case '\r':
-		return r.decodeCarriageReturn, c, false, nil
-	default:
-		return r.decodeByte, c, true, nil
 	}
+	switch r.newline {
+	case NewlineStrict:
+		switch c {
+		case '\r':
+			return r.decodeCarriageReturn, c, false, nil
+		case '\n':
+			return r.decodeByte, c, false, nil
+		}
+	case NewlineBareLF:
+		if c == '\r' {
+			return r.decodeCarriageReturn, c, false, nil
+		}
+	case NewlineBareCR:
+		switch c {
+		case '\r':
+			return r.decodeAfterNewline, '\n', true, nil
+		case '\n':
+			return r.decodeByte, c, true, nil
+		}
+	}
+	return r.decodeByte, c, true, nil
 }
 
 func (r *reader) decodeCommand(c byte) (readerState, byte, bool, error) {
@@ -59,7 +123,16 @@ func (r *reader) decodeCommand(c byte) (readerState, byte, bool, error) {
 	case DO, DONT, WILL, WONT:
 		return r.decodeOption(c), c, false, nil
 	case GA:
-		err := r.handleCommand(&telnetGoAhead{})
+		err := r.handleSplit(&telnetGoAhead{}, r.splitPrompts)
+		return r.decodeByte, c, false, err
+	case EOR:
+		err := r.handleSplit(&telnetCommand{c}, r.splitPrompts)
+		return r.decodeByte, c, false, err
+	case EC, EL:
+		err := r.handleSplit(&telnetCommand{c}, r.splitEdits)
+		return r.decodeByte, c, false, err
+	case NOP, DM, BRK, IP, AO, AYT:
+		err := r.handleCommand(&telnetCommand{c})
 		return r.decodeByte, c, false, err
 	case SB:
 		return r.decodeSubnegotiation, c, false, nil
@@ -72,10 +145,23 @@ func (r *reader) decodeCarriageReturn(c byte) (readerState, byte, bool, error) {
 	switch c {
 	case '\x00':
 		return r.decodeByte, '\r', true, nil
-	case '\r':
+	case '\n':
+		return r.decodeByte, c, true, nil
+	default:
+		// A bare CR is dropped, but whatever follows it (even another CR or
+		// an IAC) is decoded as usual.
+		return r.decodeByte(c)
+	}
+}
+
+// decodeAfterNewline swallows the LF or NUL that may come after a CR that has
+// already been decoded as a newline.
+func (r *reader) decodeAfterNewline(c byte) (readerState, byte, bool, error) {
+	switch c {
+	case '\n', '\x00':
 		return r.decodeByte, c, false, nil
 	default:
-		return r.decodeByte, c, true, nil
+		return r.decodeByte(c)
 	}
 }
 
@@ -90,27 +176,45 @@ const subnegotiationBufferSize = 256
 
 func (r *reader) decodeSubnegotiation(option byte) (readerState, byte, bool, error) {
 	var buf = make([]byte, 0, subnegotiationBufferSize)
+	var overflowed, keep bool
 
 	var readByte, seenIAC readerState
 
+	add := func(c byte) (readerState, byte, bool, error) {
+		if overflowed {
+			return readByte, c, false, nil
+		}
+		if r.maxSubnegotiation > 0 && len(buf) >= r.maxSubnegotiation {
+			overflowed = true
+			var err error
+			if r.overflow != nil {
+				keep, err = r.overflow(option)
+			}
+			if err != nil {
+				return r.decodeByte, c, false, err
+			}
+			return readByte, c, false, nil
+		}
+		buf = append(buf, c)
+		return readByte, c, false, nil
+	}
+
 	readByte = func(c byte) (readerState, byte, bool, error) {
 		switch c {
 		case IAC:
 			return seenIAC, c, false, nil
 		default:
-			buf = append(buf, c)
-			return readByte, c, false, nil
+			return add(c)
 		}
 	}
 
 	seenIAC = func(c byte) (readerState, byte, bool, error) {
 		switch c {
 		case IAC:
-			buf = append(buf, c)
-			return readByte, c, false, nil
+			return add(c)
 		case SE:
 			var err error
-			if len(buf) > 0 {
+			if len(buf) > 0 && (!overflowed || keep) {
 				err = r.handleCommand(&telnetSubnegotiation{option, buf})
 			}
 			return r.decodeByte, c, false, err
@@ -122,6 +226,14 @@ func (r *reader) decodeSubnegotiation(option byte) (readerState, byte, bool, err
 	return readByte, option, false, nil
 }
 
+func (r *reader) handleSplit(cmd any, split bool) error {
+	if split {
+		r.deferred = cmd
+		return nil
+	}
+	return r.handleCommand(cmd)
+}
+
 func (r *reader) handleCommand(cmd any) (err error) {
 	if r.cmdfn != nil {
 		err = r.cmdfn(cmd)
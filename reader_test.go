@@ -27,6 +27,9 @@ func TestSimple(t *testing.T) {
 		{[]byte{'h', IAC, IAC, 'i'}, []byte{'h', IAC, 'i'}},
 		{[]byte("foo\r\nbar"), []byte("foo\nbar")},
 		{[]byte("foo\r\x00bar"), []byte("foo\rbar")},
+		{[]byte{'h', '\r', IAC, NOP, 'i'}, []byte("hi")},
+		{[]byte{'h', '\r', IAC, IAC, 'i'}, []byte{'h', IAC, 'i'}},
+		{[]byte("h\r\r\x00i"), []byte("h\ri")},
 		{[]byte{'h', IAC, SB, Echo, IAC, SE, 'i'}, []byte("hi")},
 	}
 	for _, test := range tests {
@@ -136,6 +139,8 @@ func TestTelnetCommand(t *testing.T) {
 		cmd          any
 	}{
 		{[]byte{'h', IAC, GA, 'i'}, []byte("hi"), &telnetGoAhead{}},
+		{[]byte{'h', IAC, IP, 'i'}, []byte("hi"), &telnetCommand{IP}},
+		{[]byte{'h', IAC, AYT, 'i'}, []byte("hi"), &telnetCommand{AYT}},
 		{[]byte{'h', IAC, DO, Echo, 'i'}, []byte("hi"), &telnetOptionCommand{DO, Echo}},
 		{[]byte{'h', IAC, DONT, Echo, 'i'}, []byte("hi"), &telnetOptionCommand{DONT, Echo}},
 		{[]byte{'h', IAC, WILL, Echo, 'i'}, []byte("hi"), &telnetOptionCommand{WILL, Echo}},
@@ -164,3 +169,53 @@ func TestTelnetCommand(t *testing.T) {
 		assert.Equal(t, test.expected[:1], buf[:n], msg)
 	}
 }
+
+func TestSplitPrompts(t *testing.T) {
+	var cmds []any
+	r := newReader(bytes.NewBuffer([]byte{'h', IAC, GA, 'i', IAC, EOR}), func(cmd any) error {
+		cmds = append(cmds, cmd)
+		return nil
+	})
+	r.splitPrompts = true
+
+	buf := make([]byte, 16)
+	n, err := r.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("h"), buf[:n])
+	assert.Empty(t, cmds)
+
+	n, err = r.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("i"), buf[:n])
+	assert.Equal(t, []any{&telnetGoAhead{}}, cmds)
+
+	n, err = r.Read(buf)
+	assert.Equal(t, io.EOF, err)
+	assert.Zero(t, n)
+	assert.Equal(t, []any{&telnetGoAhead{}, &telnetCommand{EOR}}, cmds)
+}
+
+func TestSplitEdits(t *testing.T) {
+	var cmds []any
+	r := newReader(bytes.NewBuffer([]byte{'h', IAC, EC, 'i', IAC, GA, IAC, EL}), func(cmd any) error {
+		cmds = append(cmds, cmd)
+		return nil
+	})
+	r.splitEdits = true
+
+	buf := make([]byte, 16)
+	n, err := r.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("h"), buf[:n])
+	assert.Empty(t, cmds)
+
+	n, err = r.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("i"), buf[:n])
+	assert.Equal(t, []any{&telnetCommand{EC}, &telnetGoAhead{}}, cmds)
+
+	n, err = r.Read(buf)
+	assert.Equal(t, io.EOF, err)
+	assert.Zero(t, n)
+	assert.Equal(t, []any{&telnetCommand{EC}, &telnetGoAhead{}, &telnetCommand{EL}}, cmds)
+}
@@ -0,0 +1,288 @@
+package telnet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+type RecordDirection string
+
+const (
+	RecordReceived RecordDirection = "recv"
+	RecordSent     RecordDirection = "send"
+)
+
+// RecordEntry is one line of a transcript. Each chunk of raw bytes read or
+// written gets an entry with Data, followed by an entry with Command for each
+// command it finished, decoded as it would be logged (e.g. "IAC DO ECHO").
+type RecordEntry struct {
+	Time      time.Time       `json:"time"`
+	Direction RecordDirection `json:"dir"`
+	Data      []byte          `json:"data,omitempty"`
+	Command   string          `json:"command,omitempty"`
+}
+
+// Recorder is a net.Conn that writes a transcript of everything read from and
+// written to the connection it wraps, as JSON lines. To record a session, pass
+// a Recorder to New in place of the raw connection.
+type Recorder struct {
+	net.Conn
+
+	mu        sync.Mutex
+	enc       *json.Encoder
+	err       error
+	recv, snd *recordDecoder
+}
+
+func NewRecorder(conn net.Conn, w io.Writer) *Recorder {
+	return &Recorder{
+		Conn: conn,
+		enc:  json.NewEncoder(w),
+		recv: newRecordDecoder(),
+		snd:  newRecordDecoder(),
+	}
+}
+
+// Err returns the first error writing the transcript, if any.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) Read(p []byte) (n int, err error) {
+	n, err = r.Conn.Read(p)
+	if n > 0 {
+		r.record(RecordReceived, r.recv, p[:n])
+	}
+	return
+}
+
+func (r *Recorder) Write(p []byte) (n int, err error) {
+	n, err = r.Conn.Write(p)
+	if n > 0 {
+		r.record(RecordSent, r.snd, p[:n])
+	}
+	return
+}
+
+func (r *Recorder) record(dir RecordDirection, d *recordDecoder, p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.write(RecordEntry{Time: now, Direction: dir, Data: bytes.Clone(p)})
+	for _, cmd := range d.decode(p) {
+		r.write(RecordEntry{Time: now, Direction: dir, Command: cmd})
+	}
+}
+
+func (r *Recorder) write(e RecordEntry) {
+	if r.err == nil {
+		r.err = r.enc.Encode(e)
+	}
+}
+
+// recordDecoder runs the reader over a stream that arrives a chunk at a time,
+// keeping its state between chunks.
+type recordDecoder struct {
+	buf  bytes.Buffer
+	r    *reader
+	cmds []string
+}
+
+func newRecordDecoder() *recordDecoder {
+	d := &recordDecoder{}
+	d.r = newReader(&d.buf, func(cmd any) error {
+		d.cmds = append(d.cmds, fmt.Sprint(cmd))
+		return nil
+	})
+	return d
+}
+
+func (d *recordDecoder) decode(p []byte) []string {
+	d.buf.Write(p)
+	scratch := make([]byte, len(p))
+	for d.buf.Len() > 0 || len(d.r.b) > 0 {
+		d.r.Read(scratch)
+	}
+	cmds := d.cmds
+	d.cmds = nil
+	return cmds
+}
+
+// ReadTranscript reads a transcript written by a Recorder.
+func ReadTranscript(r io.Reader) (entries []RecordEntry, err error) {
+	dec := json.NewDecoder(r)
+	for {
+		var e RecordEntry
+		if err = dec.Decode(&e); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return
+		}
+		entries = append(entries, e)
+	}
+}
+
+// ReadTranscriptFile reads a transcript from a file.
+func ReadTranscriptFile(name string) ([]RecordEntry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTranscript(bufio.NewReader(f))
+}
+
+// transcriptData calls fn with each chunk of data, without any commands, that
+// went in one direction, with each line ending in CR LF the way a terminal
+// expects it.
+func transcriptData(entries []RecordEntry, dir RecordDirection, fn func(time.Time, []byte) error) error {
+	var buf bytes.Buffer
+	r := newReader(&buf, nil)
+	for _, e := range entries {
+		if e.Direction != dir || len(e.Data) == 0 {
+			continue
+		}
+		buf.Write(e.Data)
+		data, _ := io.ReadAll(r)
+		if len(data) == 0 {
+			continue
+		}
+		data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+		if err := fn(e.Time, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteAsciicast exports the data that went in one direction as an asciicast
+// (version 2) recording.
+func WriteAsciicast(w io.Writer, entries []RecordEntry, dir RecordDirection, width, height int) error {
+	var start time.Time
+	if len(entries) > 0 {
+		start = entries[0].Time
+	}
+	enc := json.NewEncoder(w)
+	header := map[string]any{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": start.Unix(),
+	}
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	return transcriptData(entries, dir, func(t time.Time, data []byte) error {
+		return enc.Encode([]any{t.Sub(start).Seconds(), "o", string(data)})
+	})
+}
+
+// WriteTTYRec exports the data that went in one direction in ttyrec format.
+func WriteTTYRec(w io.Writer, entries []RecordEntry, dir RecordDirection) error {
+	return transcriptData(entries, dir, func(t time.Time, data []byte) error {
+		var header [12]byte
+		binary.LittleEndian.PutUint32(header[0:], uint32(t.Unix()))
+		binary.LittleEndian.PutUint32(header[4:], uint32(t.Nanosecond()/1000))
+		binary.LittleEndian.PutUint32(header[8:], uint32(len(data)))
+		if _, err := w.Write(header[:]); err != nil {
+			return err
+		}
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// ReplayConn is a net.Conn that plays back what was received in a transcript,
+// in the same chunks it arrived in, so that passing it to New reproduces the
+// session. It doesn't wait between chunks. Whatever is written to it is kept
+// for comparing with what was sent.
+type ReplayConn struct {
+	mu      sync.Mutex
+	chunks  [][]byte
+	written bytes.Buffer
+	closed  bool
+}
+
+func NewReplayConn(entries []RecordEntry) *ReplayConn {
+	c := &ReplayConn{}
+	for _, e := range entries {
+		if e.Direction == RecordReceived && len(e.Data) > 0 {
+			c.chunks = append(c.chunks, e.Data)
+		}
+	}
+	return c
+}
+
+// TranscriptSent returns everything a transcript says was sent, for comparing
+// with ReplayConn.Written.
+func TranscriptSent(entries []RecordEntry) []byte {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		if e.Direction == RecordSent {
+			buf.Write(e.Data)
+		}
+	}
+	return buf.Bytes()
+}
+
+// Written returns everything written to the connection so far.
+func (c *ReplayConn) Written() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return bytes.Clone(c.written.Bytes())
+}
+
+func (c *ReplayConn) Read(p []byte) (n int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	if len(c.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n = copy(p, c.chunks[0])
+	if n < len(c.chunks[0]) {
+		c.chunks[0] = c.chunks[0][n:]
+	} else {
+		c.chunks = c.chunks[1:]
+	}
+	return
+}
+
+func (c *ReplayConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	return c.written.Write(p)
+}
+
+func (c *ReplayConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *ReplayConn) LocalAddr() net.Addr              { return replayAddr{} }
+func (c *ReplayConn) RemoteAddr() net.Addr             { return replayAddr{} }
+func (c *ReplayConn) SetDeadline(time.Time) error      { return nil }
+func (c *ReplayConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *ReplayConn) SetWriteDeadline(time.Time) error { return nil }
+
+type replayAddr struct{}
+
+func (replayAddr) Network() string { return "replay" }
+func (replayAddr) String() string  { return "replay" }
@@ -0,0 +1,98 @@
+package telnet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func recordSession(t *testing.T) []RecordEntry {
+	input := []byte{IAC, WILL, NAWS, 'h', 'i', '\r', '\n', IAC, SB, NAWS, 0, 80, 0, 24, IAC, SE}
+	var out, transcript bytes.Buffer
+	raw := &testConn{iotest.OneByteReader(bytes.NewReader(input)), &out}
+	conn := New(NewRecorder(raw, &transcript))
+	option := NewNAWSOption()
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	buf, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hi\n"), buf)
+
+	entries, err := ReadTranscript(&transcript)
+	assert.NoError(t, err)
+	return entries
+}
+
+func TestRecorder(t *testing.T) {
+	entries := recordSession(t)
+
+	var received []byte
+	var commands []string
+	for _, e := range entries {
+		if e.Direction == RecordReceived {
+			received = append(received, e.Data...)
+		}
+		if e.Command != "" {
+			commands = append(commands, string(e.Direction)+" "+e.Command)
+		}
+	}
+	assert.Equal(t, []byte{IAC, WILL, NAWS, 'h', 'i', '\r', '\n', IAC, SB, NAWS, 0, 80, 0, 24, IAC, SE}, received)
+	assert.Equal(t, []byte{IAC, DO, NAWS}, TranscriptSent(entries))
+	assert.Equal(t, []string{
+		"recv IAC WILL NAWS",
+		"send IAC DO NAWS",
+		`recv IAC SB NAWS "\x00P\x00\x18" IAC SE`,
+	}, commands)
+}
+
+func TestReplayConn(t *testing.T) {
+	entries := recordSession(t)
+
+	replay := NewReplayConn(entries)
+	conn := New(replay)
+	option := NewNAWSOption()
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	buf, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hi\n"), buf)
+	assert.Equal(t, TranscriptSent(entries), replay.Written())
+	width, height := option.WindowSize()
+	assert.Equal(t, uint16(80), width)
+	assert.Equal(t, uint16(24), height)
+}
+
+func TestExport(t *testing.T) {
+	entries := recordSession(t)
+
+	var cast bytes.Buffer
+	assert.NoError(t, WriteAsciicast(&cast, entries, RecordReceived, 80, 24))
+	dec := json.NewDecoder(&cast)
+	var header map[string]any
+	assert.NoError(t, dec.Decode(&header))
+	assert.Equal(t, float64(2), header["version"])
+	var text string
+	for dec.More() {
+		var event []any
+		assert.NoError(t, dec.Decode(&event))
+		assert.Equal(t, "o", event[1])
+		text += event[2].(string)
+	}
+	assert.Equal(t, "hi\r\n", text)
+
+	var rec bytes.Buffer
+	assert.NoError(t, WriteTTYRec(&rec, entries, RecordReceived))
+	var data []byte
+	for rec.Len() > 0 {
+		header := rec.Next(12)
+		data = append(data, rec.Next(int(binary.LittleEndian.Uint32(header[8:])))...)
+	}
+	assert.Equal(t, []byte("hi\r\n"), data)
+}
@@ -0,0 +1,197 @@
+package telnet
+
+import (
+	"errors"
+	"math"
+)
+
+// StatusReporter is implemented by options that have subnegotiation state
+// worth including when we report our status. Status returns the parameters of
+// a subnegotiation that would re-establish that state, or nil if there isn't
+// any.
+type StatusReporter interface {
+	Status() []byte
+}
+
+// StatusOption implements RFC 859. The side that sent WILL STATUS answers
+// SEND with its current option state, and the side that sent DO can ask for it
+// with RequestStatus.
+type StatusOption struct {
+	Option
+	peer *StatusReport
+}
+
+func NewStatusOption() *StatusOption {
+	return &StatusOption{Option: NewOption(Status)}
+}
+
+// PeerStatus returns the last status the peer sent us, or nil if it has never
+// sent one.
+func (s *StatusOption) PeerStatus() *StatusReport { return s.peer }
+
+// Report returns our own status, as we would send it to the peer.
+func (s *StatusOption) Report() StatusReport {
+	return NewStatusReport(s.Conn())
+}
+
+// RequestStatus asks the peer for its status. The answer is sent with the
+// "status" event.
+func (s *StatusOption) RequestStatus() error {
+	if !s.EnabledForThem() {
+		return errors.New("status option not enabled")
+	}
+	logSubnegotiation(s.Conn(), logSend, s.Byte(), statusByte(statusSend), nil)
+	_, err := s.Conn().Send([]byte{IAC, SB, s.Byte(), statusSend, IAC, SE})
+	return err
+}
+
+func (s *StatusOption) Subnegotiation(buf []byte) {
+	if len(buf) == 0 {
+		logSubnegotiation(s.Conn(), logRecv, s.Byte(), nil, nil)
+		return
+	}
+
+	cmd, buf := buf[0], buf[1:]
+	logSubnegotiation(s.Conn(), logRecv, s.Byte(), statusByte(cmd), buf)
+
+	switch cmd {
+	case statusSend:
+		if s.EnabledForUs() {
+			s.sendIs()
+		}
+	case statusIs:
+		if s.EnabledForThem() {
+			report := parseStatusReport(buf)
+			s.peer = &report
+			s.Sink().SendEvent("status", report)
+		}
+	}
+}
+
+func (s *StatusOption) sendIs() {
+	data := append([]byte{statusIs}, s.Report().encode()...)
+	logSubnegotiation(s.Conn(), logSend, s.Byte(), statusByte(statusIs), data[1:])
+	s.Conn().Send(subnegotiation(s.Byte(), data))
+}
+
+// StatusReport is one side's view of the connection. Will lists the options
+// enabled for that side and Do the options it has enabled for the other side.
+// Subnegotiations holds the parameters reported for options that have state of
+// their own.
+type StatusReport struct {
+	Will, Do        []byte
+	Subnegotiations map[byte][]byte
+}
+
+// NewStatusReport returns the status of conn from its own point of view.
+func NewStatusReport(conn Conn) StatusReport {
+	var report StatusReport
+	for b := byte(0); b < math.MaxUint8; b++ {
+		opt := conn.Option(b)
+		if opt.EnabledForUs() {
+			report.Will = append(report.Will, b)
+		}
+		if opt.EnabledForThem() {
+			report.Do = append(report.Do, b)
+		}
+		if r, ok := opt.(StatusReporter); ok && (opt.EnabledForUs() || opt.EnabledForThem()) {
+			if params := r.Status(); params != nil {
+				if report.Subnegotiations == nil {
+					report.Subnegotiations = map[byte][]byte{}
+				}
+				report.Subnegotiations[b] = params
+			}
+		}
+	}
+	return report
+}
+
+// StatusMismatch is an option on which the peer's status disagrees with ours.
+// Us is true when the disagreement is over whether the option is enabled for
+// us, rather than for the peer, and Peer is what the peer thinks.
+type StatusMismatch struct {
+	Option byte
+	Us     bool
+	Peer   bool
+}
+
+// Mismatches compares a status reported by the peer with the state of conn.
+// What the peer says it WILL do should be enabled for them on our side, and
+// what it says it has asked us to DO should be enabled for us.
+func (r StatusReport) Mismatches(conn Conn) (result []StatusMismatch) {
+	will, do := r.set(r.Will), r.set(r.Do)
+	for b := byte(0); b < math.MaxUint8; b++ {
+		opt := conn.Option(b)
+		if opt.EnabledForThem() != will[b] {
+			result = append(result, StatusMismatch{b, false, will[b]})
+		}
+		if opt.EnabledForUs() != do[b] {
+			result = append(result, StatusMismatch{b, true, do[b]})
+		}
+	}
+	return
+}
+
+func (StatusReport) set(opts []byte) (result [256]bool) {
+	for _, b := range opts {
+		result[b] = true
+	}
+	return
+}
+
+// encode returns the body of an IS, without the IAC doubling, which is done
+// when the subnegotiation is sent.
+func (r StatusReport) encode() (out []byte) {
+	for _, b := range r.Will {
+		out = append(out, WILL, b)
+	}
+	for _, b := range r.Do {
+		out = append(out, DO, b)
+	}
+	for b := 0; b < math.MaxUint8; b++ {
+		params, ok := r.Subnegotiations[byte(b)]
+		if !ok {
+			continue
+		}
+		out = append(out, SB, byte(b))
+		for _, c := range params {
+			if c == SE {
+				out = append(out, SE)
+			}
+			out = append(out, c)
+		}
+		out = append(out, SE)
+	}
+	return
+}
+
+func parseStatusReport(buf []byte) (report StatusReport) {
+	for len(buf) >= 2 {
+		cmd, opt := buf[0], buf[1]
+		buf = buf[2:]
+		switch cmd {
+		case WILL:
+			report.Will = append(report.Will, opt)
+		case DO:
+			report.Do = append(report.Do, opt)
+		case SB:
+			var params []byte
+			for len(buf) > 0 {
+				c := buf[0]
+				buf = buf[1:]
+				if c == SE {
+					if len(buf) == 0 || buf[0] != SE {
+						break
+					}
+					buf = buf[1:]
+				}
+				params = append(params, c)
+			}
+			if report.Subnegotiations == nil {
+				report.Subnegotiations = map[byte][]byte{}
+			}
+			report.Subnegotiations[opt] = params
+		}
+	}
+	return
+}
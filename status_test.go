@@ -0,0 +1,67 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusOptionAnswersSend(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, Status, IAC, DO, TransmitBinary})
+	in.Write([]byte{IAC, SB, Status, statusSend, IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+	conn.SuppressGoAhead(true)
+
+	binary := NewTransmitBinaryOption()
+	binary.Allow(true, true)
+	conn.BindOption(binary)
+	option := NewStatusOption()
+	option.Allow(false, true)
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		IAC, WILL, Status,
+		IAC, WILL, TransmitBinary,
+		IAC, SB, Status, statusIs, WILL, TransmitBinary, WILL, Status, IAC, SE,
+	}, out.Bytes())
+}
+
+func TestStatusOptionRequestsStatus(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, Status})
+	in.Write([]byte{IAC, SB, Status, statusIs,
+		WILL, Status, DO, TransmitBinary,
+		SB, Charset, charsetAccepted, 'a', SE, SE, 'b', SE,
+		IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewStatusOption()
+	assert.Error(t, option.RequestStatus())
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	expected := StatusReport{
+		Will:            []byte{Status},
+		Do:              []byte{TransmitBinary},
+		Subnegotiations: map[byte][]byte{Charset: {charsetAccepted, 'a', SE, 'b'}},
+	}
+	var events []any
+	conn.AddListener("status", FuncListener{func(data any) {
+		events = append(events, data)
+	}})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{expected}, events)
+	assert.Equal(t, &expected, option.PeerStatus())
+	assert.Equal(t, []StatusMismatch{{TransmitBinary, true, true}}, expected.Mismatches(conn))
+
+	out.Reset()
+	assert.NoError(t, option.RequestStatus())
+	assert.Equal(t, []byte{IAC, SB, Status, statusSend, IAC, SE}, out.Bytes())
+}
@@ -0,0 +1,282 @@
+// Package telnettest provides a scripted telnet peer for testing code built on
+// the telnet package.
+package telnettest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stesla/telnet"
+)
+
+const DefaultTimeout = time.Second
+
+// Peer is the far end of a connection under test. It reads everything our side
+// sends in the background, so our side never blocks writing to it, and it
+// sends in the background too, so it never blocks waiting for our side to
+// read. Expect and Run must be called from the test's goroutine.
+type Peer struct {
+	// Timeout is how long Expect waits for data to arrive.
+	Timeout time.Duration
+
+	t         testing.TB
+	conn      net.Conn
+	out       chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+	written   chan struct{} // closed when the writer is done
+
+	mu       sync.Mutex
+	received []byte
+	err      error
+	notify   chan struct{}
+}
+
+// NewPeer returns a peer and the other end of its connection, which is meant
+// to be passed to telnet.New. The peer is closed when the test finishes.
+func NewPeer(t testing.TB) (*Peer, net.Conn) {
+	local, remote := net.Pipe()
+	p := &Peer{
+		Timeout: DefaultTimeout,
+		t:       t,
+		conn:    remote,
+		out:     make(chan []byte, 64),
+		closed:  make(chan struct{}),
+		written: make(chan struct{}),
+		notify:  make(chan struct{}),
+	}
+	go p.read()
+	go p.write()
+	t.Cleanup(func() { p.Close() })
+	return p, local
+}
+
+// Close closes the peer's end of the connection, and waits for the peer to
+// stop sending. Anything it hadn't sent yet is dropped.
+func (p *Peer) Close() (err error) {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+		err = p.conn.Close()
+	})
+	<-p.written
+	return
+}
+
+// Send sends the concatenation of parts to our side. It does nothing once the
+// peer is closed.
+func (p *Peer) Send(parts ...[]byte) {
+	select {
+	case p.out <- bytes.Join(parts, nil):
+	case <-p.closed:
+	}
+}
+
+// Expect fails the test unless the next thing our side sends is the
+// concatenation of parts, and it arrives within the timeout.
+func (p *Peer) Expect(parts ...[]byte) {
+	p.t.Helper()
+	expected := bytes.Join(parts, nil)
+	received, err := p.wait(func(received []byte) bool {
+		return len(received) >= len(expected)
+	})
+
+	if len(received) < len(expected) {
+		reason := fmt.Sprintf("timed out after %v", p.Timeout)
+		if err != nil {
+			reason = err.Error()
+		}
+		p.t.Fatalf("telnettest: %s\nexpected: %s\nreceived: %s", reason, Describe(expected), Describe(received))
+	}
+	if actual := received[:len(expected)]; !bytes.Equal(expected, actual) {
+		p.t.Fatalf("telnettest: unexpected data\nexpected: %s\n  actual: %s", Describe(expected), Describe(actual))
+	}
+	p.consume(len(expected))
+}
+
+// ExpectSilence fails the test if our side sends anything within d.
+func (p *Peer) ExpectSilence(d time.Duration) {
+	p.t.Helper()
+	time.Sleep(d)
+	p.mu.Lock()
+	received := bytes.Clone(p.received)
+	p.mu.Unlock()
+	if len(received) > 0 {
+		p.t.Fatalf("telnettest: expected nothing\nreceived: %s", Describe(received))
+	}
+}
+
+// ExpectClosed fails the test unless our side closes the connection within
+// the timeout, without sending anything else first.
+func (p *Peer) ExpectClosed() {
+	p.t.Helper()
+	received, err := p.wait(func(received []byte) bool {
+		return len(received) > 0
+	})
+	if len(received) > 0 {
+		p.t.Fatalf("telnettest: expected the connection to close\nreceived: %s", Describe(received))
+	}
+	if err == nil {
+		p.t.Fatalf("telnettest: timed out after %v waiting for the connection to close", p.Timeout)
+	}
+}
+
+// Run runs a script.
+func (p *Peer) Run(steps ...Step) {
+	p.t.Helper()
+	for _, step := range steps {
+		step(p)
+	}
+}
+
+func (p *Peer) consume(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.received = p.received[n:]
+}
+
+func (p *Peer) read() {
+	buf := make([]byte, 1024)
+	for {
+		n, err := p.conn.Read(buf)
+		p.mu.Lock()
+		p.received = append(p.received, buf[:n]...)
+		if err != nil {
+			if errors.Is(err, io.ErrClosedPipe) {
+				err = io.EOF
+			}
+			p.err = err
+		}
+		close(p.notify)
+		p.notify = make(chan struct{})
+		p.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// wait waits until done is true of what has been received, the connection is
+// closed, or the timeout passes, and returns what has been received.
+func (p *Peer) wait(done func([]byte) bool) ([]byte, error) {
+	timeout := time.After(p.Timeout)
+	for {
+		p.mu.Lock()
+		received, err, notify := bytes.Clone(p.received), p.err, p.notify
+		p.mu.Unlock()
+		if done(received) || err != nil {
+			return received, err
+		}
+		select {
+		case <-notify:
+		case <-timeout:
+			return received, nil
+		}
+	}
+}
+
+func (p *Peer) write() {
+	defer close(p.written)
+	for {
+		var data []byte
+		select {
+		case data = <-p.out:
+		case <-p.closed:
+			return
+		}
+		if _, err := p.conn.Write(data); err != nil {
+			// Our side closing the connection before reading everything
+			// is up to the test to check, if it cares. Otherwise, since
+			// we're not on the test's goroutine, we can't stop the test.
+			// Close waits for us, so at least the test is still running.
+			if !errors.Is(err, io.ErrClosedPipe) {
+				p.t.Errorf("telnettest: error sending %s: %v", Describe(data), err)
+			}
+			return
+		}
+	}
+}
+
+// Step is one step of a script for Peer.Run.
+type Step func(p *Peer)
+
+// Expect is a step that calls Peer.Expect.
+func Expect(parts ...[]byte) Step {
+	return func(p *Peer) {
+		p.t.Helper()
+		p.Expect(parts...)
+	}
+}
+
+// Send is a step that calls Peer.Send.
+func Send(parts ...[]byte) Step {
+	return func(p *Peer) { p.Send(parts...) }
+}
+
+func Command(cmd byte) []byte { return []byte{telnet.IAC, cmd} }
+func Do(opt byte) []byte      { return []byte{telnet.IAC, telnet.DO, opt} }
+func Dont(opt byte) []byte    { return []byte{telnet.IAC, telnet.DONT, opt} }
+func Will(opt byte) []byte    { return []byte{telnet.IAC, telnet.WILL, opt} }
+func Wont(opt byte) []byte    { return []byte{telnet.IAC, telnet.WONT, opt} }
+
+// Sub returns a subnegotiation, doubling any IAC in data.
+func Sub(opt byte, data ...byte) []byte {
+	out := []byte{telnet.IAC, telnet.SB, opt}
+	for _, c := range data {
+		if c == telnet.IAC {
+			out = append(out, telnet.IAC)
+		}
+		out = append(out, c)
+	}
+	return append(out, telnet.IAC, telnet.SE)
+}
+
+// Text returns s the way it goes over the wire, with CR LF line endings.
+func Text(s string) []byte {
+	var buf bytes.Buffer
+	telnet.NewWriter(&buf).Write([]byte(s))
+	return buf.Bytes()
+}
+
+// WindowSize returns a NAWS subnegotiation.
+func WindowSize(width, height uint16) []byte {
+	data := binary.BigEndian.AppendUint16(nil, width)
+	return Sub(telnet.NAWS, binary.BigEndian.AppendUint16(data, height)...)
+}
+
+// errStop makes the reader stop at each command, so that Describe can put it
+// in order with the text around it.
+var errStop = errors.New("stop")
+
+// Describe renders telnet traffic readably, e.g. `"hi\n" IAC GA`.
+func Describe(p []byte) string {
+	if len(p) == 0 {
+		return "nothing"
+	}
+	var parts []string
+	var cmd any
+	r := telnet.NewReader(bytes.NewReader(p), func(c any) error {
+		cmd = c
+		return errStop
+	})
+	buf := make([]byte, len(p))
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			parts = append(parts, fmt.Sprintf("%q", buf[:n]))
+		}
+		if err == errStop {
+			parts = append(parts, fmt.Sprint(cmd))
+		} else if err != nil {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,114 @@
+package telnettest
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stesla/telnet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNAWSNegotiation(t *testing.T) {
+	peer, c := NewPeer(t)
+	conn := telnet.New(c)
+	go io.Copy(io.Discard, conn)
+
+	option := telnet.NewNAWSOption()
+	option.Allow(true, false)
+	conn.BindOption(option)
+	events := make(chan any, 1)
+	conn.AddListener("window-size", telnet.FuncListener{Func: func(data any) { events <- data }})
+
+	assert.NoError(t, conn.EnableOptionForThem(telnet.NAWS, true))
+	peer.Run(
+		Expect(Do(telnet.NAWS)),
+		Send(Will(telnet.NAWS), WindowSize(80, 24)),
+	)
+	select {
+	case event := <-events:
+		assert.Equal(t, telnet.WindowSizeEvent{Width: 80, Height: 24}, event)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for window-size event")
+	}
+
+	conn.Write([]byte("hi\n"))
+	peer.Expect(Text("hi\n"), Command(telnet.GA))
+	peer.ExpectSilence(10 * time.Millisecond)
+
+	conn.Close()
+	peer.ExpectClosed()
+}
+
+func TestDescribe(t *testing.T) {
+	var tests = []struct {
+		input    []byte
+		expected string
+	}{
+		{nil, "nothing"},
+		{Text("hi\n"), `"hi\n"`},
+		{Do(telnet.Echo), "IAC DO ECHO"},
+		{append(Text("> "), Command(telnet.GA)...), `"> " IAC GA`},
+		{WindowSize(80, 24), `IAC SB NAWS "\x00P\x00\x18" IAC SE`},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Describe(test.input))
+	}
+}
+
+// fakeTB records the first fatal failure, and stops the goroutine it is called
+// from the way testing.T does.
+type fakeTB struct {
+	testing.TB
+	failure string
+	errors  []string
+}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.failure = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func (f *fakeTB) Helper() {}
+
+func run(tb *fakeTB, fn func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	<-done
+}
+
+func TestExpectMismatch(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	peer, c := NewPeer(tb)
+	c.Write(Will(telnet.Echo))
+	run(tb, func() { peer.Expect(Will(telnet.SuppressGoAhead)) })
+	assert.Equal(t, "telnettest: unexpected data\nexpected: IAC WILL SUPPRESS-GO-AHEAD\n  actual: IAC WILL ECHO", tb.failure)
+}
+
+func TestExpectTimeout(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	peer, c := NewPeer(tb)
+	peer.Timeout = 10 * time.Millisecond
+	c.Write(Text("hi"))
+	run(tb, func() { peer.Expect(Text("hi there")) })
+	assert.Equal(t, "telnettest: timed out after 10ms\nexpected: \"hi there\"\nreceived: \"hi\"", tb.failure)
+}
+
+func TestCloseWithUnreadData(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	peer, _ := NewPeer(tb)
+	peer.Send(Text("nobody reads this"))
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, peer.Close())
+	peer.Send(Text("or this"))
+	assert.Nil(t, tb.errors)
+}
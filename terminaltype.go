@@ -0,0 +1,177 @@
+package telnet
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TerminalTypeOption implements RFC 1091. As a client it answers each SEND
+// with the next of the types it was created with, repeating the last one to
+// show that the list is over before starting again. As a server it asks for
+// the client's terminal type as soon as the client agrees to send it.
+type TerminalTypeOption struct {
+	Option
+	types []string
+	next  int
+
+	mu        sync.Mutex
+	peerType  string
+	peerTypes []string
+	cycle     bool
+}
+
+// maxTerminalTypes bounds how many types we'll ask for when cycling, in case
+// the client never repeats itself.
+const maxTerminalTypes = 8
+
+func NewTerminalTypeOption(types ...string) *TerminalTypeOption {
+	return &TerminalTypeOption{Option: NewOption(TerminalType), types: types}
+}
+
+func (t *TerminalTypeOption) Bind(conn Conn, sink EventSink) {
+	t.Option.Bind(conn, sink)
+	conn.AddListener("update-option", t)
+}
+
+// RequestTerminalType asks the client for its next terminal type.
+func (t *TerminalTypeOption) RequestTerminalType() {
+	if t.EnabledForThem() {
+		t.send(optionSend, nil)
+	}
+}
+
+// CycleTypes makes us, as a server, keep asking for terminal types until the
+// client repeats one. That's how a client lists all the types it supports, and
+// how MTTS clients report their name, their terminal type and then their
+// capabilities as "MTTS <bits>".
+func (t *TerminalTypeOption) CycleTypes(cycle bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cycle = cycle
+}
+
+// TerminalType returns the last terminal type the peer sent us.
+func (t *TerminalTypeOption) TerminalType() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.peerType
+}
+
+// TerminalTypes returns the different terminal types the peer has sent us, in
+// the order it sent them.
+func (t *TerminalTypeOption) TerminalTypes() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.peerTypes)
+}
+
+// MTTS returns the capabilities the peer reported with MTTS, and whether it
+// did.
+func (t *TerminalTypeOption) MTTS() (MTTSFlags, bool) {
+	for _, peerType := range t.TerminalTypes() {
+		if flags, ok := strings.CutPrefix(peerType, "MTTS "); ok {
+			return parseMTTS(flags)
+		}
+	}
+	return 0, false
+}
+
+func (t *TerminalTypeOption) HandleEvent(data any) {
+	event, ok := data.(UpdateOptionEvent)
+	if !ok || event.Option.Byte() != TerminalType {
+		return
+	}
+	if event.TheyChanged && event.EnabledForThem() {
+		t.mu.Lock()
+		t.peerTypes = nil
+		t.mu.Unlock()
+		t.send(optionSend, nil)
+	}
+	if event.WeChanged {
+		t.next = 0
+	}
+}
+
+func (t *TerminalTypeOption) Subnegotiation(buf []byte) {
+	if len(buf) == 0 {
+		logSubnegotiation(t.Conn(), logRecv, t.Byte(), nil, nil)
+		return
+	}
+
+	cmd, buf := buf[0], buf[1:]
+	logSubnegotiation(t.Conn(), logRecv, t.Byte(), isSendByte(cmd), buf)
+
+	switch cmd {
+	case optionSend:
+		if t.EnabledForUs() {
+			t.send(optionIs, []byte(t.nextType()))
+		}
+	case optionIs:
+		if t.EnabledForThem() {
+			peerType := string(buf)
+			t.mu.Lock()
+			t.peerType = peerType
+			repeated := slices.Contains(t.peerTypes, peerType)
+			if !repeated {
+				t.peerTypes = append(t.peerTypes, peerType)
+			}
+			again := t.cycle && !repeated && len(t.peerTypes) < maxTerminalTypes
+			t.mu.Unlock()
+			t.Sink().SendEvent("terminal-type", TerminalTypeEvent{peerType})
+			if again {
+				t.send(optionSend, nil)
+			}
+		}
+	}
+}
+
+func (t *TerminalTypeOption) nextType() string {
+	switch {
+	case len(t.types) == 0:
+		return "UNKNOWN"
+	case t.next < len(t.types):
+		t.next++
+		return t.types[t.next-1]
+	default:
+		t.next = 0
+		return t.types[len(t.types)-1]
+	}
+}
+
+func (t *TerminalTypeOption) send(cmd byte, data []byte) {
+	logSubnegotiation(t.Conn(), logSend, t.Byte(), isSendByte(cmd), data)
+	t.Conn().Send(subnegotiation(t.Byte(), append([]byte{cmd}, data...)))
+}
+
+type TerminalTypeEvent struct {
+	Type string
+}
+
+// MTTSFlags are the capabilities a client reports with the Mud Terminal Type
+// Standard.
+type MTTSFlags int
+
+const (
+	MTTSANSI MTTSFlags = 1 << iota
+	MTTSVT100
+	MTTSUTF8
+	MTTS256Colors
+	MTTSMouseTracking
+	MTTSOSCColorPalette
+	MTTSScreenReader
+	MTTSProxy
+	MTTSTrueColor
+	MTTSMNES
+	MTTSMSLP
+	MTTSSSL
+)
+
+func parseMTTS(s string) (MTTSFlags, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return MTTSFlags(n), true
+}
@@ -0,0 +1,84 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminalTypeServer(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, TerminalType})
+	in.Write([]byte{IAC, SB, TerminalType, optionIs})
+	in.WriteString("XTERM-256COLOR")
+	in.Write([]byte{IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewTerminalTypeOption()
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	var events []any
+	conn.AddListener("terminal-type", FuncListener{func(data any) { events = append(events, data) }})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{TerminalTypeEvent{"XTERM-256COLOR"}}, events)
+	assert.Equal(t, "XTERM-256COLOR", option.TerminalType())
+	assert.Equal(t, []byte{
+		IAC, DO, TerminalType,
+		IAC, SB, TerminalType, optionSend, IAC, SE,
+	}, out.Bytes())
+}
+
+func TestTerminalTypeClientCyclesThroughTypes(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, TerminalType})
+	for i := 0; i < 4; i++ {
+		in.Write([]byte{IAC, SB, TerminalType, optionSend, IAC, SE})
+	}
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewTerminalTypeOption("A", "B")
+	option.Allow(false, true)
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	expected := []byte{IAC, WILL, TerminalType}
+	for _, c := range "ABBA" {
+		expected = append(expected, IAC, SB, TerminalType, optionIs, byte(c), IAC, SE)
+	}
+	assert.Equal(t, expected, out.Bytes())
+}
+
+func TestTerminalTypeServerCyclesForMTTS(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, TerminalType})
+	for _, s := range []string{"MUDLET", "XTERM-256COLOR", "MTTS 137", "MTTS 137"} {
+		in.Write([]byte{IAC, SB, TerminalType, optionIs})
+		in.WriteString(s)
+		in.Write([]byte{IAC, SE})
+	}
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewTerminalTypeOption()
+	option.Allow(true, false)
+	option.CycleTypes(true)
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"MUDLET", "XTERM-256COLOR", "MTTS 137"}, option.TerminalTypes())
+	flags, ok := option.MTTS()
+	assert.True(t, ok)
+	assert.Equal(t, MTTSANSI|MTTS256Colors|MTTSProxy, flags)
+
+	expected := []byte{IAC, DO, TerminalType}
+	for i := 0; i < 4; i++ {
+		expected = append(expected, IAC, SB, TerminalType, optionSend, IAC, SE)
+	}
+	assert.Equal(t, expected, out.Bytes())
+}
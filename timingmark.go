@@ -0,0 +1,95 @@
+package telnet
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TimingMarkOption implements RFC 860. Unlike other options, TIMING-MARK is
+// never actually enabled: each DO is a one-shot request that the other side
+// answers once the output that preceded it has been sent, so it bypasses the
+// Q method entirely.
+type TimingMarkOption struct {
+	Option
+
+	// owed is closed when the peer answers our DO. It's nil when we aren't
+	// waiting on an answer, and we only ever have one DO outstanding.
+	mu   sync.Mutex
+	owed chan struct{}
+}
+
+func NewTimingMarkOption() *TimingMarkOption {
+	return &TimingMarkOption{Option: NewOption(TimingMark)}
+}
+
+func (t *TimingMarkOption) Subnegotiation([]byte) {}
+
+// Ping sends DO TIMING-MARK and waits for the reply, returning the round-trip
+// time. The reply is handled when it is read, so something else must be
+// reading from the connection while Ping waits. If an earlier Ping gave up
+// before the peer answered, this one first waits for that answer, so that
+// it isn't mistaken for ours.
+func (t *TimingMarkOption) Ping(ctx context.Context) (time.Duration, error) {
+	var done chan struct{}
+	for done == nil {
+		t.mu.Lock()
+		owed := t.owed
+		if owed == nil {
+			done = make(chan struct{})
+			t.owed = done
+		}
+		t.mu.Unlock()
+		if owed != nil {
+			select {
+			case <-owed:
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+	}
+
+	start := time.Now()
+	if err := t.sendOptionCommand(DO); err != nil {
+		t.answered()
+		return 0, err
+	}
+
+	select {
+	case <-done:
+		return time.Since(start), nil
+	case <-ctx.Done():
+		// The peer still owes us a reply, so we leave it to the next Ping to
+		// wait for it.
+		return 0, ctx.Err()
+	}
+}
+
+// answered settles the DO we have outstanding, if there is one.
+func (t *TimingMarkOption) answered() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.owed != nil {
+		close(t.owed)
+		t.owed = nil
+	}
+}
+
+func (t *TimingMarkOption) receive(c byte) error {
+	switch c {
+	case DO:
+		// Anything we wrote before the DO arrived has already been sent, since
+		// writes and commands go through the same lock, so we can answer right
+		// away.
+		return t.sendOptionCommand(WILL)
+	case WILL, WONT:
+		t.answered()
+	}
+	return nil
+}
+
+func (t *TimingMarkOption) sendOptionCommand(cmd byte) error {
+	logOptionCommand(t.Conn(), logSend, cmd, t.Byte())
+	_, err := t.Conn().Send([]byte{IAC, cmd, t.Byte()})
+	return err
+}
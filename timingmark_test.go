@@ -0,0 +1,132 @@
+package telnet
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimingMarkAnswersDo(t *testing.T) {
+	in := bytes.NewBuffer([]byte{'h', IAC, DO, TimingMark, 'i', IAC, DO, TimingMark})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewTimingMarkOption()
+	conn.BindOption(option)
+
+	buf, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hi"), buf)
+	assert.Equal(t, []byte{IAC, WILL, TimingMark, IAC, WILL, TimingMark}, out.Bytes())
+	assert.False(t, option.EnabledForUs())
+	assert.False(t, option.EnabledForThem())
+}
+
+func TestTimingMarkPing(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+
+	conn := New(local)
+	option := NewTimingMarkOption()
+	conn.BindOption(option)
+	go io.Copy(io.Discard, conn)
+
+	go func() {
+		buf := make([]byte, 3)
+		for _, reply := range []byte{WILL, WONT} {
+			_, err := io.ReadFull(remote, buf)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte{IAC, DO, TimingMark}, buf)
+			remote.Write([]byte{IAC, reply, TimingMark})
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		d, err := option.Ping(ctx)
+		assert.NoError(t, err)
+		assert.Greater(t, d, time.Duration(0))
+	}
+	assert.False(t, option.EnabledForThem())
+}
+
+func TestTimingMarkPingTimeout(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+
+	conn := New(local)
+	option := NewTimingMarkOption()
+	conn.BindOption(option)
+	go io.Copy(io.Discard, conn)
+
+	late := make(chan struct{})
+	go func() {
+		buf := make([]byte, 3)
+		io.ReadFull(remote, buf)
+		<-late
+		// This is the reply to the first Ping, which has already timed out, so
+		// it must not count as the reply to the second.
+		remote.Write([]byte{IAC, WILL, TimingMark})
+		io.ReadFull(remote, buf)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := option.Ping(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(late)
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = option.Ping(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestTimingMarkPingOnlyOneOutstanding(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+
+	conn := New(local)
+	option := NewTimingMarkOption()
+	conn.BindOption(option)
+	go io.Copy(io.Discard, conn)
+
+	received := make(chan []byte, 10)
+	go func() {
+		for {
+			buf := make([]byte, 3)
+			if _, err := io.ReadFull(remote, buf); err != nil {
+				return
+			}
+			received <- buf
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err := option.Ping(ctx)
+		cancel()
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+	assert.Equal(t, []byte{IAC, DO, TimingMark}, <-received)
+	assert.Empty(t, received)
+
+	remote.Write([]byte{IAC, WILL, TimingMark})
+	go func() {
+		<-received
+		remote.Write([]byte{IAC, WILL, TimingMark})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := option.Ping(ctx)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,106 @@
+package telnet
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TerminalSpeedOption implements RFC 1079. As a client it answers SEND with
+// the speeds it was created with, and as a server it asks for the client's
+// speeds as soon as the client agrees to send them.
+type TerminalSpeedOption struct {
+	Option
+	txSpeed, rxSpeed int
+
+	mu                       sync.Mutex
+	peerTxSpeed, peerRxSpeed int
+}
+
+func NewTerminalSpeedOption(transmit, receive int) *TerminalSpeedOption {
+	return &TerminalSpeedOption{
+		Option:  NewOption(TerminalSpeed),
+		txSpeed: transmit,
+		rxSpeed: receive,
+	}
+}
+
+func (t *TerminalSpeedOption) Bind(conn Conn, sink EventSink) {
+	t.Option.Bind(conn, sink)
+	conn.AddListener("update-option", t)
+}
+
+// TerminalSpeed returns the speeds the peer sent us, which are zero until it
+// has done so.
+func (t *TerminalSpeedOption) TerminalSpeed() (transmit, receive int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.peerTxSpeed, t.peerRxSpeed
+}
+
+func (t *TerminalSpeedOption) HandleEvent(data any) {
+	event, ok := data.(UpdateOptionEvent)
+	if !ok || event.Option.Byte() != TerminalSpeed {
+		return
+	}
+	if event.TheyChanged && event.EnabledForThem() {
+		t.send(optionSend, nil)
+	}
+}
+
+func (t *TerminalSpeedOption) Subnegotiation(buf []byte) {
+	if len(buf) == 0 {
+		logSubnegotiation(t.Conn(), logRecv, t.Byte(), nil, nil)
+		return
+	}
+
+	cmd, buf := buf[0], buf[1:]
+	logSubnegotiation(t.Conn(), logRecv, t.Byte(), isSendByte(cmd), buf)
+
+	switch cmd {
+	case optionSend:
+		if t.EnabledForUs() {
+			t.send(optionIs, []byte(fmt.Sprintf("%d,%d", t.txSpeed, t.rxSpeed)))
+		}
+	case optionIs:
+		if !t.EnabledForThem() {
+			return
+		}
+		transmit, receive, ok := parseTerminalSpeed(string(buf))
+		if !ok {
+			logFailure(t.Conn(), t.Byte(), "invalid terminal speed", nil, slog.String("payload", string(buf)))
+			return
+		}
+		t.mu.Lock()
+		t.peerTxSpeed, t.peerRxSpeed = transmit, receive
+		t.mu.Unlock()
+		t.Sink().SendEvent("terminal-speed", TerminalSpeedEvent{transmit, receive})
+	}
+}
+
+func (t *TerminalSpeedOption) send(cmd byte, data []byte) {
+	logSubnegotiation(t.Conn(), logSend, t.Byte(), isSendByte(cmd), data)
+	t.Conn().Send(subnegotiation(t.Byte(), append([]byte{cmd}, data...)))
+}
+
+func parseTerminalSpeed(s string) (transmit, receive int, ok bool) {
+	ts, rs, ok := strings.Cut(s, ",")
+	if !ok {
+		return
+	}
+	transmit, err := strconv.Atoi(ts)
+	if err != nil {
+		return 0, 0, false
+	}
+	receive, err = strconv.Atoi(rs)
+	if err != nil {
+		return 0, 0, false
+	}
+	return transmit, receive, true
+}
+
+type TerminalSpeedEvent struct {
+	Transmit, Receive int
+}
@@ -0,0 +1,72 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminalSpeedServer(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, TerminalSpeed})
+	in.Write([]byte{IAC, SB, TerminalSpeed, optionIs})
+	in.WriteString("38400,19200")
+	in.Write([]byte{IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewTerminalSpeedOption(0, 0)
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	var events []any
+	conn.AddListener("terminal-speed", FuncListener{func(data any) { events = append(events, data) }})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{TerminalSpeedEvent{38400, 19200}}, events)
+	transmit, receive := option.TerminalSpeed()
+	assert.Equal(t, 38400, transmit)
+	assert.Equal(t, 19200, receive)
+	assert.Equal(t, []byte{
+		IAC, DO, TerminalSpeed,
+		IAC, SB, TerminalSpeed, optionSend, IAC, SE,
+	}, out.Bytes())
+}
+
+func TestTerminalSpeedServerIgnoresGarbage(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, TerminalSpeed})
+	in.Write([]byte{IAC, SB, TerminalSpeed, optionIs})
+	in.WriteString("fast")
+	in.Write([]byte{IAC, SE})
+	conn := newTestConn(in, nil)
+
+	option := NewTerminalSpeedOption(0, 0)
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	transmit, receive := option.TerminalSpeed()
+	assert.Zero(t, transmit)
+	assert.Zero(t, receive)
+}
+
+func TestTerminalSpeedClient(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, TerminalSpeed})
+	in.Write([]byte{IAC, SB, TerminalSpeed, optionSend, IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewTerminalSpeedOption(9600, 4800)
+	option.Allow(false, true)
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	expected := []byte{IAC, WILL, TerminalSpeed, IAC, SB, TerminalSpeed, optionIs}
+	expected = append(expected, "9600,4800"...)
+	expected = append(expected, IAC, SE)
+	assert.Equal(t, expected, out.Bytes())
+}
@@ -0,0 +1,739 @@
+// Package vt is a terminal emulator for the client side of a telnet
+// connection. A Terminal keeps track of what a full-screen host has drawn, so
+// that automation can look at the screen the way a person would.
+package vt
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/stesla/telnet"
+)
+
+// Color is a terminal color: DefaultColor, one of the 256 indexed colors, or
+// an RGB color made with RGB.
+type Color int32
+
+const DefaultColor Color = -1
+
+const rgbFlag = 1 << 24
+
+func RGB(r, g, b uint8) Color {
+	return Color(rgbFlag | int32(r)<<16 | int32(g)<<8 | int32(b))
+}
+
+// RGB returns the components of an RGB color, and whether it is one.
+func (c Color) RGB() (r, g, b uint8, ok bool) {
+	if c < 0 || c&rgbFlag == 0 {
+		return 0, 0, 0, false
+	}
+	return uint8(c >> 16), uint8(c >> 8), uint8(c), true
+}
+
+type Flags uint8
+
+const (
+	Bold Flags = 1 << iota
+	Faint
+	Italic
+	Underline
+	Blink
+	Reverse
+	Hidden
+	Strike
+)
+
+// Attr is how a cell is drawn, as set by SGR.
+type Attr struct {
+	Fg, Bg Color
+	Flags  Flags
+}
+
+var defaultAttr = Attr{Fg: DefaultColor, Bg: DefaultColor}
+
+type Cell struct {
+	Rune rune
+	Attr Attr
+}
+
+var blank = Cell{' ', defaultAttr}
+
+type cursor struct {
+	x, y int
+	attr Attr
+}
+
+type parserState int
+
+const (
+	stateGround parserState = iota
+	stateEscape
+	stateEscapeIntermediate
+	stateCSI
+	stateString
+	stateStringEscape
+)
+
+// Terminal emulates enough of a VT100 (and the xterm extensions that
+// full-screen programs rely on, like the alternate screen) to keep track of
+// the screen. Write the data read from a Conn to it.
+//
+// Since a telnet reader turns CR LF into "\n", LF (but not VT or FF) is taken
+// to mean both.
+type Terminal struct {
+	// Types are the terminal types Bind reports, in order.
+	Types []string
+
+	mu            sync.Mutex
+	width, height int
+	cells         [][]Cell
+	main          [][]Cell // while the alternate screen is showing
+	x, y          int
+	wrapPending   bool
+	attr          Attr
+	top, bottom   int
+	saved         cursor
+	savedMain     cursor
+	autowrap      bool
+	cursorVisible bool
+
+	state   parserState
+	params  []int
+	private byte
+	partial []byte
+
+	reply   io.Writer
+	replies []byte // to write once the lock is released
+	naws    *telnet.NAWSOption
+}
+
+func New(width, height int) *Terminal {
+	t := &Terminal{Types: []string{"XTERM", "VT100"}}
+	t.resize(width, height)
+	t.reset()
+	return t
+}
+
+// Bind binds NAWS and TERMINAL-TYPE options to conn, so that the terminal
+// reports its size and type to the host, and sends the answers to status
+// requests (DSR and DA) to it.
+func (t *Terminal) Bind(conn telnet.Conn) {
+	t.mu.Lock()
+	width, height := t.width, t.height
+	t.reply = conn
+	t.naws = telnet.NewNAWSOption()
+	naws := t.naws
+	t.mu.Unlock()
+
+	naws.Allow(false, true)
+	naws.SetWindowSize(uint16(width), uint16(height))
+	conn.BindOption(naws)
+
+	ttype := telnet.NewTerminalTypeOption(t.Types...)
+	ttype.Allow(false, true)
+	conn.BindOption(ttype)
+}
+
+// Resize changes the size of the screen, and tells the host if it's bound.
+func (t *Terminal) Resize(width, height int) error {
+	t.mu.Lock()
+	t.resize(width, height)
+	naws := t.naws
+	t.mu.Unlock()
+	if naws != nil {
+		return naws.SetWindowSize(uint16(width), uint16(height))
+	}
+	return nil
+}
+
+func (t *Terminal) Size() (width, height int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.width, t.height
+}
+
+// Cursor returns the position of the cursor, counting from zero.
+func (t *Terminal) Cursor() (x, y int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.x, t.y
+}
+
+func (t *Terminal) CursorVisible() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cursorVisible
+}
+
+// AltScreen says whether the alternate screen is showing.
+func (t *Terminal) AltScreen() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.main != nil
+}
+
+func (t *Terminal) Cell(x, y int) Cell {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if x < 0 || x >= t.width || y < 0 || y >= t.height {
+		return blank
+	}
+	return t.cells[y][x]
+}
+
+// Line returns the text of a row, without trailing spaces.
+func (t *Terminal) Line(y int) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if y < 0 || y >= t.height {
+		return ""
+	}
+	return t.line(y)
+}
+
+// Text returns a snapshot of the screen as text, one line per row, without
+// trailing spaces or blank rows.
+func (t *Terminal) Text() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := make([]string, t.height)
+	for y := range lines {
+		lines[y] = t.line(y)
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+func (t *Terminal) line(y int) string {
+	var b strings.Builder
+	for _, c := range t.cells[y] {
+		b.WriteRune(c.Rune)
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+func (t *Terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	t.write(p)
+	reply, replies := t.reply, t.replies
+	t.replies = nil
+	t.mu.Unlock()
+	if reply != nil && len(replies) > 0 {
+		reply.Write(replies)
+	}
+	return len(p), nil
+}
+
+func (t *Terminal) write(p []byte) {
+	data := p
+	if len(t.partial) > 0 {
+		data = append(t.partial, p...)
+		t.partial = nil
+	}
+	for len(data) > 0 {
+		if !utf8.FullRune(data) {
+			t.partial = append([]byte(nil), data...)
+			break
+		}
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		t.put(r)
+	}
+}
+
+func (t *Terminal) put(r rune) {
+	switch t.state {
+	case stateGround:
+		switch {
+		case r < 0x20:
+			t.control(r)
+		case r != 0x7f:
+			t.print(r)
+		}
+	case stateEscape:
+		t.escape(r)
+	case stateEscapeIntermediate:
+		// The character set designations (ESC ( B and so on) and the
+		// like take one more character, which we ignore.
+		t.state = stateGround
+	case stateCSI:
+		t.csi(r)
+	case stateString:
+		switch r {
+		case 0x07:
+			t.state = stateGround
+		case 0x1b:
+			t.state = stateStringEscape
+		}
+	case stateStringEscape:
+		// ESC \ ends the string. Anything else was a mistake, but it
+		// ends it too.
+		t.state = stateGround
+	}
+}
+
+func (t *Terminal) control(r rune) {
+	switch r {
+	case '\b':
+		if t.x > 0 {
+			t.x--
+		}
+		t.wrapPending = false
+	case '\t':
+		t.x = min((t.x/8+1)*8, t.width-1)
+		t.wrapPending = false
+	case '\n':
+		t.x = 0
+		t.lineFeed()
+	case '\v', '\f':
+		t.lineFeed()
+	case '\r':
+		t.x = 0
+		t.wrapPending = false
+	case 0x1b:
+		t.state = stateEscape
+	}
+}
+
+func (t *Terminal) print(r rune) {
+	if t.wrapPending {
+		t.x = 0
+		t.lineFeed()
+	}
+	t.cells[t.y][t.x] = Cell{r, t.attr}
+	if t.x < t.width-1 {
+		t.x++
+	} else if t.autowrap {
+		t.wrapPending = true
+	}
+}
+
+func (t *Terminal) escape(r rune) {
+	t.state = stateGround
+	switch r {
+	case '[':
+		t.state = stateCSI
+		t.params, t.private = t.params[:0], 0
+	case ']', 'P', 'X', '^', '_':
+		t.state = stateString
+	case '(', ')', '*', '+', '#', '%':
+		t.state = stateEscapeIntermediate
+	case '7':
+		t.saved = cursor{t.x, t.y, t.attr}
+	case '8':
+		t.restore(t.saved)
+	case 'D':
+		t.lineFeed()
+	case 'E':
+		t.x = 0
+		t.lineFeed()
+	case 'M':
+		t.reverseIndex()
+	case 'c':
+		t.reset()
+	}
+}
+
+func (t *Terminal) csi(r rune) {
+	switch {
+	case r >= '0' && r <= '9':
+		if len(t.params) == 0 {
+			t.params = append(t.params, 0)
+		}
+		last := &t.params[len(t.params)-1]
+		*last = min(*last*10+int(r-'0'), 65535)
+	case r == ';' || r == ':':
+		if len(t.params) == 0 {
+			t.params = append(t.params, 0)
+		}
+		t.params = append(t.params, 0)
+	case r >= '<' && r <= '?':
+		t.private = byte(r)
+	case r >= 0x20 && r <= 0x2f:
+		// Intermediates; none of the sequences we handle use them.
+	case r >= 0x40 && r <= 0x7e:
+		t.state = stateGround
+		t.dispatch(r)
+	case r < 0x20:
+		t.control(r)
+	default:
+		t.state = stateGround
+	}
+}
+
+// param returns the ith parameter, or def if it's missing or zero.
+func (t *Terminal) param(i, def int) int {
+	if i >= len(t.params) || t.params[i] == 0 {
+		return def
+	}
+	return t.params[i]
+}
+
+func (t *Terminal) dispatch(final rune) {
+	if t.private == '?' {
+		switch final {
+		case 'h':
+			t.setModes(true)
+		case 'l':
+			t.setModes(false)
+		}
+		return
+	} else if t.private != 0 {
+		return
+	}
+
+	t.wrapPending = false
+	n := t.param(0, 1)
+	switch final {
+	case '@':
+		t.insertCells(n)
+	case 'A':
+		t.moveTo(t.x, max(t.y-n, t.upperLimit()))
+	case 'B':
+		t.moveTo(t.x, min(t.y+n, t.lowerLimit()))
+	case 'C':
+		t.moveTo(t.x+n, t.y)
+	case 'D':
+		t.moveTo(t.x-n, t.y)
+	case 'E':
+		t.moveTo(0, min(t.y+n, t.lowerLimit()))
+	case 'F':
+		t.moveTo(0, max(t.y-n, t.upperLimit()))
+	case 'G', '`':
+		t.moveTo(n-1, t.y)
+	case 'H', 'f':
+		t.moveTo(t.param(1, 1)-1, n-1)
+	case 'J':
+		t.eraseDisplay(t.param(0, 0))
+	case 'K':
+		t.eraseLine(t.param(0, 0))
+	case 'L':
+		if t.y >= t.top && t.y <= t.bottom {
+			t.scrollDown(t.y, n)
+		}
+	case 'M':
+		if t.y >= t.top && t.y <= t.bottom {
+			t.scrollUp(t.y, n)
+		}
+	case 'P':
+		t.deleteCells(n)
+	case 'S':
+		t.scrollUp(t.top, n)
+	case 'T':
+		t.scrollDown(t.top, n)
+	case 'X':
+		t.clear(t.y, t.x, min(t.x+n, t.width))
+	case 'd':
+		t.moveTo(t.x, n-1)
+	case 'm':
+		t.sgr()
+	case 'r':
+		top, bottom := t.param(0, 1)-1, t.param(1, t.height)-1
+		if top < bottom && bottom < t.height {
+			t.top, t.bottom = top, bottom
+			t.moveTo(0, 0)
+		}
+	case 's':
+		t.saved = cursor{t.x, t.y, t.attr}
+	case 'u':
+		t.restore(t.saved)
+	case 'n':
+		switch t.param(0, 0) {
+		case 5:
+			t.respond("\x1b[0n")
+		case 6:
+			t.respond(fmt.Sprintf("\x1b[%d;%dR", t.y+1, t.x+1))
+		}
+	case 'c':
+		if t.param(0, 0) == 0 {
+			t.respond("\x1b[?1;2c")
+		}
+	}
+}
+
+func (t *Terminal) setModes(on bool) {
+	for _, mode := range t.params {
+		switch mode {
+		case 7:
+			t.autowrap = on
+		case 25:
+			t.cursorVisible = on
+		case 47, 1047:
+			t.altScreen(on, mode == 1047 && !on)
+		case 1049:
+			if on {
+				t.savedMain = cursor{t.x, t.y, t.attr}
+				t.altScreen(true, true)
+			} else {
+				t.altScreen(false, false)
+				t.restore(t.savedMain)
+			}
+		}
+	}
+}
+
+func (t *Terminal) altScreen(on, clear bool) {
+	if on && t.main == nil {
+		t.main = t.cells
+		t.cells = newCells(t.width, t.height)
+	} else if !on && t.main != nil {
+		t.cells, t.main = t.main, nil
+		return
+	}
+	if clear {
+		t.cells = newCells(t.width, t.height)
+	}
+}
+
+func (t *Terminal) sgr() {
+	if len(t.params) == 0 {
+		t.attr = defaultAttr
+		return
+	}
+	for i := 0; i < len(t.params); i++ {
+		switch p := t.params[i]; {
+		case p == 0:
+			t.attr = defaultAttr
+		case p == 1:
+			t.attr.Flags |= Bold
+		case p == 2:
+			t.attr.Flags |= Faint
+		case p == 3:
+			t.attr.Flags |= Italic
+		case p == 4:
+			t.attr.Flags |= Underline
+		case p == 5 || p == 6:
+			t.attr.Flags |= Blink
+		case p == 7:
+			t.attr.Flags |= Reverse
+		case p == 8:
+			t.attr.Flags |= Hidden
+		case p == 9:
+			t.attr.Flags |= Strike
+		case p == 22:
+			t.attr.Flags &^= Bold | Faint
+		case p == 23:
+			t.attr.Flags &^= Italic
+		case p == 24:
+			t.attr.Flags &^= Underline
+		case p == 25:
+			t.attr.Flags &^= Blink
+		case p == 27:
+			t.attr.Flags &^= Reverse
+		case p == 28:
+			t.attr.Flags &^= Hidden
+		case p == 29:
+			t.attr.Flags &^= Strike
+		case p >= 30 && p <= 37:
+			t.attr.Fg = Color(p - 30)
+		case p == 38:
+			t.attr.Fg, i = t.extendedColor(i)
+		case p == 39:
+			t.attr.Fg = DefaultColor
+		case p >= 40 && p <= 47:
+			t.attr.Bg = Color(p - 40)
+		case p == 48:
+			t.attr.Bg, i = t.extendedColor(i)
+		case p == 49:
+			t.attr.Bg = DefaultColor
+		case p >= 90 && p <= 97:
+			t.attr.Fg = Color(p - 90 + 8)
+		case p >= 100 && p <= 107:
+			t.attr.Bg = Color(p - 100 + 8)
+		}
+	}
+}
+
+// extendedColor parses the color after a 38 or 48 at i (5;n or 2;r;g;b), and
+// returns it along with the index of its last parameter.
+func (t *Terminal) extendedColor(i int) (Color, int) {
+	switch t.param(i+1, 0) {
+	case 5:
+		return Color(uint8(t.param(i+2, 0))), i + 2
+	case 2:
+		return RGB(uint8(t.param(i+2, 0)), uint8(t.param(i+3, 0)), uint8(t.param(i+4, 0))), i + 4
+	default:
+		return DefaultColor, len(t.params)
+	}
+}
+
+// respond queues an answer to the host, which Write sends once it has let go
+// of the lock.
+func (t *Terminal) respond(s string) {
+	if t.reply != nil {
+		t.replies = append(t.replies, s...)
+	}
+}
+
+func (t *Terminal) moveTo(x, y int) {
+	t.x = max(0, min(x, t.width-1))
+	t.y = max(0, min(y, t.height-1))
+	t.wrapPending = false
+}
+
+// upperLimit and lowerLimit are as far as the cursor can move up or down: the
+// margins of the scroll region if it's inside it, or else the screen's.
+func (t *Terminal) upperLimit() int {
+	if t.y >= t.top {
+		return t.top
+	}
+	return 0
+}
+
+func (t *Terminal) lowerLimit() int {
+	if t.y <= t.bottom {
+		return t.bottom
+	}
+	return t.height - 1
+}
+
+func (t *Terminal) restore(c cursor) {
+	t.moveTo(c.x, c.y)
+	t.attr = c.attr
+}
+
+func (t *Terminal) lineFeed() {
+	t.wrapPending = false
+	if t.y == t.bottom {
+		t.scrollUp(t.top, 1)
+	} else if t.y < t.height-1 {
+		t.y++
+	}
+}
+
+func (t *Terminal) reverseIndex() {
+	t.wrapPending = false
+	if t.y == t.top {
+		t.scrollDown(t.top, 1)
+	} else if t.y > 0 {
+		t.y--
+	}
+}
+
+// scrollUp scrolls the rows from top to the bottom of the scroll region up n
+// rows, and scrollDown scrolls them down.
+func (t *Terminal) scrollUp(top, n int) {
+	n = min(n, t.bottom-top+1)
+	copy(t.cells[top:t.bottom+1], t.cells[top+n:t.bottom+1])
+	for y := t.bottom - n + 1; y <= t.bottom; y++ {
+		t.cells[y] = newRow(t.width)
+	}
+}
+
+func (t *Terminal) scrollDown(top, n int) {
+	n = min(n, t.bottom-top+1)
+	copy(t.cells[top+n:t.bottom+1], t.cells[top:t.bottom+1-n])
+	for y := top; y < top+n; y++ {
+		t.cells[y] = newRow(t.width)
+	}
+}
+
+func (t *Terminal) insertCells(n int) {
+	row := t.cells[t.y]
+	n = min(n, t.width-t.x)
+	copy(row[t.x+n:], row[t.x:])
+	t.clear(t.y, t.x, t.x+n)
+}
+
+func (t *Terminal) deleteCells(n int) {
+	row := t.cells[t.y]
+	n = min(n, t.width-t.x)
+	copy(row[t.x:], row[t.x+n:])
+	t.clear(t.y, t.width-n, t.width)
+}
+
+func (t *Terminal) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		t.clear(t.y, t.x, t.width)
+		for y := t.y + 1; y < t.height; y++ {
+			t.clear(y, 0, t.width)
+		}
+	case 1:
+		for y := 0; y < t.y; y++ {
+			t.clear(y, 0, t.width)
+		}
+		t.clear(t.y, 0, t.x+1)
+	case 2, 3:
+		for y := 0; y < t.height; y++ {
+			t.clear(y, 0, t.width)
+		}
+	}
+}
+
+func (t *Terminal) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		t.clear(t.y, t.x, t.width)
+	case 1:
+		t.clear(t.y, 0, t.x+1)
+	case 2:
+		t.clear(t.y, 0, t.width)
+	}
+}
+
+// clear blanks the cells in row y from x0 up to x1.
+func (t *Terminal) clear(y, x0, x1 int) {
+	for x := x0; x < x1; x++ {
+		t.cells[y][x] = blank
+	}
+}
+
+func (t *Terminal) reset() {
+	if t.main != nil {
+		t.cells, t.main = t.main, nil
+	}
+	t.cells = newCells(t.width, t.height)
+	t.x, t.y, t.wrapPending = 0, 0, false
+	t.attr = defaultAttr
+	t.top, t.bottom = 0, t.height-1
+	t.saved, t.savedMain = cursor{attr: defaultAttr}, cursor{attr: defaultAttr}
+	t.autowrap, t.cursorVisible = true, true
+	t.state = stateGround
+}
+
+func (t *Terminal) resize(width, height int) {
+	width, height = max(width, 1), max(height, 1)
+	t.cells = resizeCells(t.cells, width, height)
+	if t.main != nil {
+		t.main = resizeCells(t.main, width, height)
+	}
+	t.width, t.height = width, height
+	t.top, t.bottom = 0, height-1
+	t.moveTo(t.x, t.y)
+}
+
+func newRow(width int) []Cell {
+	row := make([]Cell, width)
+	for x := range row {
+		row[x] = blank
+	}
+	return row
+}
+
+func newCells(width, height int) [][]Cell {
+	return resizeCells(nil, width, height)
+}
+
+func resizeCells(cells [][]Cell, width, height int) [][]Cell {
+	result := make([][]Cell, height)
+	for y := range result {
+		result[y] = newRow(width)
+		if y < len(cells) {
+			copy(result[y], cells[y])
+		}
+	}
+	return result
+}
+
+func (c Color) String() string {
+	if c == DefaultColor {
+		return "default"
+	} else if r, g, b, ok := c.RGB(); ok {
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	}
+	return strconv.Itoa(int(c))
+}
@@ -0,0 +1,219 @@
+package vt
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stesla/telnet"
+	"github.com/stesla/telnet/telnettest"
+	"github.com/stretchr/testify/assert"
+)
+
+func write(t *Terminal, s string) {
+	t.Write([]byte(s))
+}
+
+func TestText(t *testing.T) {
+	term := New(10, 4)
+	write(term, "hello\nworld\r\x00!\tx")
+	assert.Equal(t, "hello\n!orld   x", term.Text())
+	x, y := term.Cursor()
+	assert.Equal(t, 9, x)
+	assert.Equal(t, 1, y)
+}
+
+func TestWrapAndScroll(t *testing.T) {
+	term := New(4, 3)
+	write(term, "abcdefgh")
+	assert.Equal(t, "abcd\nefgh", term.Text())
+	x, y := term.Cursor()
+	assert.Equal(t, 3, x)
+	assert.Equal(t, 1, y)
+
+	write(term, "ijklmn")
+	assert.Equal(t, "efgh\nijkl\nmn", term.Text())
+
+	write(term, "\x1b[?7l\x1b[H\x1b[2Jwxyz!")
+	assert.Equal(t, "wxy!", term.Text())
+}
+
+func TestSplitUTF8(t *testing.T) {
+	term := New(10, 2)
+	write(term, "caf\xc3")
+	write(term, "\xa9")
+	assert.Equal(t, "café", term.Text())
+}
+
+func TestCursorMovementAndErase(t *testing.T) {
+	term := New(10, 5)
+	write(term, "0123456789\n0123456789\n0123456789")
+	write(term, "\x1b[2;3H\x1b[K")
+	write(term, "\x1b[3;5H\x1b[1K")
+	write(term, "\x1b[1;9H\x1b[2P")
+	write(term, "\x1b[1;1H\x1b[2@")
+	assert.Equal(t, "  01234567\n01\n     56789", term.Text())
+
+	write(term, "\x1b[3;1H\x1b[Aab\x1b[Bc\x1b[5Gd\x1b[3De")
+	x, y := term.Cursor()
+	assert.Equal(t, 3, x)
+	assert.Equal(t, 2, y)
+	assert.Equal(t, "ab", term.Line(1))
+	assert.Equal(t, "  e d56789", term.Line(2))
+
+	write(term, "\x1b[2;1H\x1b[J")
+	assert.Equal(t, "  01234567", term.Text())
+}
+
+func TestSGR(t *testing.T) {
+	term := New(10, 2)
+	write(term, "\x1b[1;31;44ma\x1b[22;4;38;5;200mb\x1b[38;2;1;2;3;49mc\x1b[0md\x1b[7;92;103me\x1b[me")
+	assert.Equal(t, Attr{Fg: 1, Bg: 4, Flags: Bold}, term.Cell(0, 0).Attr)
+	assert.Equal(t, Attr{Fg: 200, Bg: 4, Flags: Underline}, term.Cell(1, 0).Attr)
+	assert.Equal(t, Attr{Fg: RGB(1, 2, 3), Bg: DefaultColor, Flags: Underline}, term.Cell(2, 0).Attr)
+	assert.Equal(t, defaultAttr, term.Cell(3, 0).Attr)
+	assert.Equal(t, Attr{Fg: 10, Bg: 11, Flags: Reverse}, term.Cell(4, 0).Attr)
+	assert.Equal(t, defaultAttr, term.Cell(5, 0).Attr)
+	assert.Equal(t, "#010203", RGB(1, 2, 3).String())
+}
+
+func TestScrollRegion(t *testing.T) {
+	term := New(5, 5)
+	write(term, "1\n2\n3\n4\n5")
+	write(term, "\x1b[2;4r")
+	x, y := term.Cursor()
+	assert.Equal(t, 0, x)
+	assert.Equal(t, 0, y)
+
+	write(term, "\x1b[4;1H\nx")
+	assert.Equal(t, "1\n3\n4\nx\n5", term.Text())
+
+	write(term, "\x1b[2;1H\x1bMy")
+	assert.Equal(t, "1\ny\n3\n4\n5", term.Text())
+
+	write(term, "\x1b[3;1H\x1b[L")
+	assert.Equal(t, "1\ny\n\n3\n5", term.Text())
+
+	write(term, "\x1b[2;1H\x1b[2M")
+	assert.Equal(t, "1\n3\n\n\n5", term.Text())
+
+	write(term, "\x1b[r\x1b[5;1H\n")
+	assert.Equal(t, "3\n\n\n5", term.Text())
+}
+
+func TestAltScreen(t *testing.T) {
+	term := New(10, 3)
+	write(term, "shell$ \x1b[?1049h\x1b[?25l")
+	assert.True(t, term.AltScreen())
+	assert.False(t, term.CursorVisible())
+	assert.Equal(t, "", term.Text())
+
+	write(term, "\x1b[2;2Hfull")
+	assert.Equal(t, "\n full", term.Text())
+
+	write(term, "\x1b[?1049l\x1b[?25h")
+	assert.False(t, term.AltScreen())
+	assert.True(t, term.CursorVisible())
+	assert.Equal(t, "shell$", term.Text())
+	x, y := term.Cursor()
+	assert.Equal(t, 7, x)
+	assert.Equal(t, 0, y)
+}
+
+func TestIgnoredSequences(t *testing.T) {
+	term := New(10, 2)
+	write(term, "a\x1b]0;title\x07b\x1b]2;x\x1b\\c\x1b(Bd\x1b=\x1b[>0ce")
+	assert.Equal(t, "abcde", term.Text())
+}
+
+func TestResize(t *testing.T) {
+	term := New(5, 2)
+	write(term, "abcde\nfg")
+	term.Resize(3, 3)
+	assert.Equal(t, "abc\nfg", term.Text())
+	w, h := term.Size()
+	assert.Equal(t, 3, w)
+	assert.Equal(t, 3, h)
+}
+
+func TestStatusReports(t *testing.T) {
+	term := New(10, 5)
+	var out bytes.Buffer
+	term.reply = &out
+	write(term, "\x1b[3;4H\x1b[6n\x1b[5n\x1b[c")
+	assert.Equal(t, "\x1b[3;4R\x1b[0n\x1b[?1;2c", out.String())
+}
+
+func TestBind(t *testing.T) {
+	peer, c := telnettest.NewPeer(t)
+	conn := telnet.New(c)
+	conn.SuppressGoAhead(true)
+	term := New(80, 24)
+	term.Bind(conn)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(term, conn)
+	}()
+
+	peer.Run(
+		telnettest.Send(telnettest.Do(telnet.NAWS), telnettest.Do(telnet.TerminalType)),
+		telnettest.Expect(telnettest.Will(telnet.NAWS), telnettest.WindowSize(80, 24), telnettest.Will(telnet.TerminalType)),
+		telnettest.Send(telnettest.Sub(telnet.TerminalType, 1)),
+		telnettest.Expect(telnettest.Sub(telnet.TerminalType, append([]byte{0}, "XTERM"...)...)),
+		telnettest.Send(telnettest.Text("hi\x1b[6n")),
+		telnettest.Expect(telnettest.Text("\x1b[1;3R")),
+	)
+	assert.NoError(t, term.Resize(100, 30))
+	peer.Expect(telnettest.WindowSize(100, 30))
+	assert.Equal(t, "hi", term.Text())
+
+	peer.Close()
+	<-done
+}
+
+func TestResizeWhileNegotiating(t *testing.T) {
+	peer, c := telnettest.NewPeer(t)
+	conn := telnet.New(c)
+	term := New(80, 24)
+	term.Bind(conn)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(term, conn)
+	}()
+
+	resized := make(chan struct{})
+	go func() {
+		defer close(resized)
+		for i := 0; i < 10; i++ {
+			term.Resize(81+i, 25+i)
+		}
+	}()
+	peer.Send(telnettest.Do(telnet.NAWS))
+	<-resized
+	peer.Expect(telnettest.Will(telnet.NAWS))
+
+	peer.Close()
+	<-done
+	w, h := term.Size()
+	assert.Equal(t, 90, w)
+	assert.Equal(t, 34, h)
+}
+
+type replyFunc func([]byte) (int, error)
+
+func (f replyFunc) Write(p []byte) (int, error) { return f(p) }
+
+func TestRespondWithoutLock(t *testing.T) {
+	term := New(10, 5)
+	var x, y int
+	term.reply = replyFunc(func(p []byte) (int, error) {
+		// Whoever gets the reply is free to look at the terminal.
+		x, y = term.Cursor()
+		return len(p), nil
+	})
+	write(term, "\x1b[3;4H\x1b[6n")
+	assert.Equal(t, 3, x)
+	assert.Equal(t, 2, y)
+}
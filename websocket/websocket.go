@@ -0,0 +1,227 @@
+// Package websocket bridges WebSocket connections from web clients to telnet
+// connections.
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/stesla/telnet"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	TypeError          = "error"
+	TypeOption         = "option"
+	TypePrompt         = "prompt"
+	TypeSubnegotiation = "subnegotiation"
+	TypeText           = "text"
+)
+
+// Message is the JSON envelope for everything sent over the WebSocket, in
+// either direction:
+//
+//	{"type": "text", "text": "look\n"}
+//	{"type": "prompt"}
+//	{"type": "option", "option": 201, "us": false, "them": true}
+//	{"type": "subnegotiation", "option": 201, "data": "Q29yZS5IZWxsbyB7fQ=="}
+//	{"type": "error", "text": "connection refused"}
+//
+// An option message from the telnet side reports the option's new state, and
+// one from the web client asks for that state. A prompt is sent for each GA or
+// EOR, after the text that came before it. A subnegotiation's data is base64,
+// since it need not be text. Only the web client is ever sent errors, which
+// include an option or subnegotiation message for an option that isn't passed
+// through.
+type Message struct {
+	Type   string `json:"type"`
+	Text   string `json:"text,omitempty"`
+	Option byte   `json:"option"`
+	Us     bool   `json:"us,omitempty"`
+	Them   bool   `json:"them,omitempty"`
+	Data   []byte `json:"data,omitempty"`
+}
+
+// Handler returns a websocket.Handler that calls dial for each WebSocket and
+// bridges the two with Bridge.
+func Handler(dial func() (telnet.Conn, error), passthrough ...byte) websocket.Handler {
+	return func(ws *websocket.Conn) {
+		conn, err := dial()
+		if err != nil {
+			websocket.JSON.Send(ws, Message{Type: TypeError, Text: err.Error()})
+			return
+		}
+		Bridge(ws, conn, passthrough...)
+	}
+}
+
+// Bridge shuttles messages between ws and conn until either of them is closed,
+// and then closes the other. The subnegotiations of the passthrough options
+// (e.g. GMCP) are passed along as they are, and the web client is free to
+// enable them. Those are the only options the web client can enable, or send
+// subnegotiations for, and those can be no longer than
+// telnet.DefaultMaxSubnegotiation.
+func Bridge(ws *websocket.Conn, conn telnet.Conn, passthrough ...byte) error {
+	b := &bridge{ws: ws, conn: conn, passthrough: passthrough}
+	for _, code := range passthrough {
+		opt := &passthroughOption{Option: telnet.NewOption(code), b: b}
+		opt.Allow(true, true)
+		conn.BindOption(opt)
+	}
+	conn.SplitAtPrompts(true)
+	conn.AddListener("command", b)
+	conn.AddListener("update-option", b)
+
+	errc := make(chan error, 2)
+	go func() { errc <- b.fromTelnet() }()
+	go func() { errc <- b.fromWebSocket() }()
+	err := <-errc
+	ws.Close()
+	conn.Close()
+	<-errc
+
+	if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		err = nil
+	}
+	return err
+}
+
+type bridge struct {
+	ws          *websocket.Conn
+	conn        telnet.Conn
+	passthrough []byte
+	mu          sync.Mutex
+}
+
+func (b *bridge) HandleEvent(data any) {
+	switch t := data.(type) {
+	case telnet.CommandEvent:
+		if t.Command == telnet.GA || t.Command == telnet.EOR {
+			b.send(Message{Type: TypePrompt})
+		}
+	case telnet.UpdateOptionEvent:
+		b.send(Message{
+			Type:   TypeOption,
+			Option: t.Option.Byte(),
+			Us:     t.EnabledForUs(),
+			Them:   t.EnabledForThem(),
+		})
+	}
+}
+
+func (b *bridge) fromTelnet() error {
+	buf := make([]byte, 4096)
+	var start int
+	for {
+		n, err := b.conn.Read(buf[start:])
+		n += start
+		// Hold back a rune that's been cut off, until the rest of it arrives.
+		text := n
+		if err == nil {
+			text = completeRunes(buf[:n])
+		}
+		if text > 0 {
+			if err := b.send(Message{Type: TypeText, Text: string(buf[:text])}); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+		start = copy(buf, buf[text:n])
+	}
+}
+
+// completeRunes returns the length of p without any incomplete rune at the
+// end of it.
+func completeRunes(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if utf8.FullRune(p[i:]) {
+				return len(p)
+			}
+			return i
+		}
+	}
+	return len(p)
+}
+
+func (b *bridge) fromWebSocket() (err error) {
+	for {
+		var msg Message
+		if err := websocket.JSON.Receive(b.ws, &msg); err != nil {
+			return err
+		}
+		if refusal := b.check(msg); refusal != nil {
+			err = b.send(Message{Type: TypeError, Text: refusal.Error()})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		switch msg.Type {
+		case TypeText:
+			_, err = b.conn.Write([]byte(msg.Text))
+		case TypeOption:
+			if err = b.conn.EnableOptionForUs(msg.Option, msg.Us); err == nil {
+				err = b.conn.EnableOptionForThem(msg.Option, msg.Them)
+			}
+		case TypeSubnegotiation:
+			_, err = b.conn.Send(subnegotiation(msg.Option, msg.Data))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// check says why we won't act on a message from the web client, if we won't.
+func (b *bridge) check(msg Message) error {
+	if msg.Type != TypeOption && msg.Type != TypeSubnegotiation {
+		return nil
+	}
+	if !slices.Contains(b.passthrough, msg.Option) {
+		return fmt.Errorf("%s is not passed through", telnet.OptionName(msg.Option))
+	}
+	if len(msg.Data) > telnet.DefaultMaxSubnegotiation {
+		return fmt.Errorf("%s subnegotiation too long", telnet.OptionName(msg.Option))
+	}
+	return nil
+}
+
+func (b *bridge) send(msg Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return websocket.JSON.Send(b.ws, msg)
+}
+
+type passthroughOption struct {
+	telnet.Option
+	b *bridge
+}
+
+func (p *passthroughOption) Subnegotiation(buf []byte) {
+	p.Conn().LogAttrs(slog.LevelDebug, "subnegotiation",
+		slog.String("direction", "recv"),
+		slog.String("option", telnet.OptionName(p.Byte())),
+		slog.String("payload", string(buf)),
+	)
+	p.b.send(Message{Type: TypeSubnegotiation, Option: p.Byte(), Data: append([]byte(nil), buf...)})
+}
+
+func subnegotiation(opt byte, data []byte) []byte {
+	out := []byte{telnet.IAC, telnet.SB, opt}
+	for _, c := range data {
+		if c == telnet.IAC {
+			out = append(out, telnet.IAC)
+		}
+		out = append(out, c)
+	}
+	return append(out, telnet.IAC, telnet.SE)
+}
@@ -0,0 +1,161 @@
+package websocket
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stesla/telnet"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+	"golang.org/x/text/encoding"
+)
+
+func startBridge(t *testing.T, dial func(addr string) (telnet.Conn, error), passthrough ...byte) (ws *websocket.Conn, remote net.Conn, ok bool) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := httptest.NewServer(Handler(func() (telnet.Conn, error) {
+		return dial(listener.Addr().String())
+	}, passthrough...))
+	t.Cleanup(server.Close)
+
+	ws, err = websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	t.Cleanup(func() { ws.Close() })
+
+	remote, err = listener.Accept()
+	if !assert.NoError(t, err) {
+		return
+	}
+	t.Cleanup(func() { remote.Close() })
+	return ws, remote, true
+}
+
+func TestBridge(t *testing.T) {
+	ws, remote, ok := startBridge(t, telnet.Dial, telnet.GMCP)
+	if !ok {
+		return
+	}
+
+	expect := func(expected []byte) {
+		buf := make([]byte, len(expected))
+		_, err := io.ReadFull(remote, buf)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, buf)
+	}
+	receive := func(expected Message) {
+		var msg Message
+		assert.NoError(t, websocket.JSON.Receive(ws, &msg))
+		assert.Equal(t, expected, msg)
+	}
+
+	remote.Write([]byte{telnet.IAC, telnet.WILL, telnet.GMCP})
+	expect([]byte{telnet.IAC, telnet.DO, telnet.GMCP})
+	receive(Message{Type: TypeOption, Option: telnet.GMCP, Them: true})
+
+	remote.Write([]byte("hello\r\n> "))
+	remote.Write([]byte{telnet.IAC, telnet.GA, 'x'})
+	receive(Message{Type: TypeText, Text: "hello\n> "})
+	receive(Message{Type: TypePrompt})
+	receive(Message{Type: TypeText, Text: "x"})
+
+	remote.Write([]byte{telnet.IAC, telnet.SB, telnet.GMCP})
+	remote.Write([]byte("Core.Hello {}"))
+	remote.Write([]byte{telnet.IAC, telnet.SE})
+	receive(Message{Type: TypeSubnegotiation, Option: telnet.GMCP, Data: []byte("Core.Hello {}")})
+
+	assert.NoError(t, websocket.JSON.Send(ws, Message{Type: TypeText, Text: "look\n"}))
+	expect([]byte{'l', 'o', 'o', 'k', '\r', '\n', telnet.IAC, telnet.GA})
+
+	assert.NoError(t, websocket.JSON.Send(ws, Message{Type: TypeSubnegotiation, Option: telnet.GMCP, Data: []byte("Core.Ping")}))
+	expected := []byte{telnet.IAC, telnet.SB, telnet.GMCP}
+	expected = append(expected, "Core.Ping"...)
+	expect(append(expected, telnet.IAC, telnet.SE))
+
+	assert.NoError(t, websocket.JSON.Send(ws, Message{Type: TypeOption, Option: telnet.GMCP, Us: true, Them: true}))
+	expect([]byte{telnet.IAC, telnet.WILL, telnet.GMCP})
+
+	// Subnegotiations needn't be text.
+	remote.Write([]byte{telnet.IAC, telnet.SB, telnet.GMCP, 0xff, 0xff, 0x80, 0x00, telnet.IAC, telnet.SE})
+	receive(Message{Type: TypeSubnegotiation, Option: telnet.GMCP, Data: []byte{0xff, 0x80, 0x00}})
+
+	assert.NoError(t, websocket.JSON.Send(ws, Message{Type: TypeSubnegotiation, Option: telnet.GMCP, Data: []byte{0xc3, 0xff}}))
+	expect([]byte{telnet.IAC, telnet.SB, telnet.GMCP, 0xc3, 0xff, 0xff, telnet.IAC, telnet.SE})
+
+	// The web client can only touch the passthrough options.
+	assert.NoError(t, websocket.JSON.Send(ws, Message{Type: TypeOption, Option: telnet.TransmitBinary, Us: true}))
+	receive(Message{Type: TypeError, Text: "TRANSMIT-BINARY is not passed through"})
+	assert.NoError(t, websocket.JSON.Send(ws, Message{Type: TypeSubnegotiation, Option: telnet.NAWS, Data: []byte{0, 80, 0, 24}}))
+	receive(Message{Type: TypeError, Text: "NAWS is not passed through"})
+	assert.NoError(t, websocket.JSON.Send(ws, Message{Type: TypeSubnegotiation, Option: telnet.GMCP, Data: make([]byte, telnet.DefaultMaxSubnegotiation+1)}))
+	receive(Message{Type: TypeError, Text: "GMCP subnegotiation too long"})
+	assert.NoError(t, websocket.JSON.Send(ws, Message{Type: TypeText, Text: "!"}))
+	expect([]byte{'!', telnet.IAC, telnet.GA})
+
+	// Closing the telnet side closes the WebSocket.
+	remote.Close()
+	var msg Message
+	assert.Error(t, websocket.JSON.Receive(ws, &msg))
+}
+
+func TestBridgeSplitRune(t *testing.T) {
+	ws, remote, ok := startBridge(t, func(addr string) (telnet.Conn, error) {
+		conn, err := telnet.Dial(addr)
+		if err == nil {
+			conn.SetReadEncoding(encoding.Nop)
+		}
+		return conn, err
+	})
+	if !ok {
+		return
+	}
+
+	remote.Write([]byte("caf\xc3"))
+	time.Sleep(10 * time.Millisecond)
+	remote.Write([]byte("\xa9"))
+
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	var text string
+	for text != "café" {
+		var msg Message
+		if !assert.NoError(t, websocket.JSON.Receive(ws, &msg)) {
+			return
+		}
+		assert.True(t, utf8.ValidString(msg.Text), "%q", msg.Text)
+		text += msg.Text
+	}
+}
+
+func TestMessageOptionZero(t *testing.T) {
+	buf, err := json.Marshal(Message{Type: TypeOption, Option: telnet.TransmitBinary, Us: true})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type": "option", "option": 0, "us": true}`, string(buf))
+}
+
+func TestHandlerDialError(t *testing.T) {
+	server := httptest.NewServer(Handler(func() (telnet.Conn, error) {
+		return nil, io.ErrUnexpectedEOF
+	}))
+	defer server.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close()
+
+	var msg Message
+	assert.NoError(t, websocket.JSON.Receive(ws, &msg))
+	assert.Equal(t, Message{Type: TypeError, Text: io.ErrUnexpectedEOF.Error()}, msg)
+}
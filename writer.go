@@ -2,7 +2,9 @@ package telnet
 
 import (
 	"bytes"
+	"crypto/cipher"
 	"io"
+	"sync"
 )
 
 func NewWriter(w io.Writer) io.Writer {
@@ -10,20 +12,23 @@ func NewWriter(w io.Writer) io.Writer {
 }
 
 type writer struct {
-	out io.Writer
+	out     io.Writer
+	newline NewlineMode
 }
 
 func (w *writer) Write(p []byte) (n int, err error) {
 	var buf bytes.Buffer
 	for _, c := range p {
 		var b []byte
-		switch c {
-		case '\n':
-			b = []byte("\r\n")
-		case '\r':
-			b = []byte("\r\x00")
-		case IAC:
+		switch {
+		case c == IAC:
 			b = []byte{IAC, IAC}
+		case w.newline == NewlineRaw:
+			b = []byte{c}
+		case c == '\n':
+			b = w.newlineBytes()
+		case c == '\r':
+			b = []byte("\r\x00")
 		default:
 			b = []byte{c}
 		}
@@ -35,3 +40,54 @@ func (w *writer) Write(p []byte) (n int, err error) {
 	_, err = w.out.Write(buf.Bytes())
 	return
 }
+
+func (w *writer) newlineBytes() []byte {
+	switch w.newline {
+	case NewlineBareLF:
+		return []byte("\n")
+	case NewlineBareCR:
+		return []byte("\r")
+	default:
+		return []byte("\r\n")
+	}
+}
+
+// cipherWriter is where everything we send ends up. Its lock means that
+// commands sent from other goroutines go out between writes, never in the
+// middle of one, and that once Write returns everything before it is gone.
+type cipherWriter struct {
+	sync.Mutex
+	out    io.Writer
+	stream cipher.Stream
+}
+
+func (w *cipherWriter) setStream(stream cipher.Stream) {
+	w.Lock()
+	defer w.Unlock()
+	w.stream = stream
+}
+
+func (w *cipherWriter) Write(p []byte) (n int, err error) {
+	w.Lock()
+	defer w.Unlock()
+	return w.write(p)
+}
+
+// writeThenSetStream writes p and then switches streams, without letting
+// another Write in between.
+func (w *cipherWriter) writeThenSetStream(p []byte, stream cipher.Stream) error {
+	w.Lock()
+	defer w.Unlock()
+	_, err := w.write(p)
+	w.stream = stream
+	return err
+}
+
+func (w *cipherWriter) write(p []byte) (n int, err error) {
+	if w.stream == nil {
+		return w.out.Write(p)
+	}
+	buf := make([]byte, len(p))
+	w.stream.XORKeyStream(buf, p)
+	return w.out.Write(buf)
+}
@@ -25,3 +25,22 @@ func TestWriteAscii(t *testing.T) {
 		assert.Equal(t, test.expected, buf.Bytes())
 	}
 }
+
+func TestCipherWriterSwitchesAfterWrite(t *testing.T) {
+	var out bytes.Buffer
+	w := &cipherWriter{out: &out}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			w.Write([]byte{'x'})
+		}
+	}()
+	assert.NoError(t, w.writeThenSetStream([]byte{'!'}, xorStream(0x55)))
+	<-done
+
+	// Everything after the '!' is encrypted, and nothing before it is.
+	i := bytes.IndexByte(out.Bytes(), '!')
+	assert.Equal(t, bytes.Repeat([]byte{'x'}, i), out.Bytes()[:i])
+	assert.Equal(t, xorBytes(0x55, bytes.Repeat([]byte{'x'}, 100-i)), out.Bytes()[i+1:])
+}
@@ -0,0 +1,78 @@
+package telnet
+
+import "sync"
+
+// XDisplayLocationOption implements RFC 1096. As a client it answers SEND with
+// the display it was created with, and as a server it asks for the client's
+// display as soon as the client agrees to send it.
+type XDisplayLocationOption struct {
+	Option
+	display string
+
+	mu          sync.Mutex
+	peerDisplay string
+}
+
+func NewXDisplayLocationOption(display string) *XDisplayLocationOption {
+	return &XDisplayLocationOption{
+		Option:  NewOption(XDisplayLocation),
+		display: display,
+	}
+}
+
+func (x *XDisplayLocationOption) Bind(conn Conn, sink EventSink) {
+	x.Option.Bind(conn, sink)
+	conn.AddListener("update-option", x)
+}
+
+// DisplayLocation returns the display the peer sent us, which is empty until
+// it has done so.
+func (x *XDisplayLocationOption) DisplayLocation() string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.peerDisplay
+}
+
+func (x *XDisplayLocationOption) HandleEvent(data any) {
+	event, ok := data.(UpdateOptionEvent)
+	if !ok || event.Option.Byte() != XDisplayLocation {
+		return
+	}
+	if event.TheyChanged && event.EnabledForThem() {
+		x.send(optionSend, nil)
+	}
+}
+
+func (x *XDisplayLocationOption) Subnegotiation(buf []byte) {
+	if len(buf) == 0 {
+		logSubnegotiation(x.Conn(), logRecv, x.Byte(), nil, nil)
+		return
+	}
+
+	cmd, buf := buf[0], buf[1:]
+	logSubnegotiation(x.Conn(), logRecv, x.Byte(), isSendByte(cmd), buf)
+
+	switch cmd {
+	case optionSend:
+		if x.EnabledForUs() {
+			x.send(optionIs, []byte(x.display))
+		}
+	case optionIs:
+		if x.EnabledForThem() {
+			display := string(buf)
+			x.mu.Lock()
+			x.peerDisplay = display
+			x.mu.Unlock()
+			x.Sink().SendEvent("x-display-location", XDisplayLocationEvent{display})
+		}
+	}
+}
+
+func (x *XDisplayLocationOption) send(cmd byte, data []byte) {
+	logSubnegotiation(x.Conn(), logSend, x.Byte(), isSendByte(cmd), data)
+	x.Conn().Send(subnegotiation(x.Byte(), append([]byte{cmd}, data...)))
+}
+
+type XDisplayLocationEvent struct {
+	Display string
+}
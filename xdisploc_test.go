@@ -0,0 +1,52 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXDisplayLocationServer(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, WILL, XDisplayLocation})
+	in.Write([]byte{IAC, SB, XDisplayLocation, optionIs})
+	in.WriteString("example.com:0.0")
+	in.Write([]byte{IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewXDisplayLocationOption("")
+	option.Allow(true, false)
+	conn.BindOption(option)
+
+	var events []any
+	conn.AddListener("x-display-location", FuncListener{func(data any) { events = append(events, data) }})
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{XDisplayLocationEvent{"example.com:0.0"}}, events)
+	assert.Equal(t, "example.com:0.0", option.DisplayLocation())
+	assert.Equal(t, []byte{
+		IAC, DO, XDisplayLocation,
+		IAC, SB, XDisplayLocation, optionSend, IAC, SE,
+	}, out.Bytes())
+}
+
+func TestXDisplayLocationClient(t *testing.T) {
+	in := bytes.NewBuffer([]byte{IAC, DO, XDisplayLocation})
+	in.Write([]byte{IAC, SB, XDisplayLocation, optionSend, IAC, SE})
+	var out bytes.Buffer
+	conn := newTestConn(in, &out)
+
+	option := NewXDisplayLocationOption(":0")
+	option.Allow(false, true)
+	conn.BindOption(option)
+
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		IAC, WILL, XDisplayLocation,
+		IAC, SB, XDisplayLocation, optionIs, ':', '0', IAC, SE,
+	}, out.Bytes())
+}